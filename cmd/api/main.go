@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
 	"log/slog"
 	"os"
 	"zennews/db"
 	"zennews/internal/handler"
+	"zennews/internal/pipeline"
 	"zennews/internal/repository"
 
 	"github.com/gin-contrib/cors"
@@ -23,12 +25,50 @@ func main() {
 	}
 	defer db.Close()
 
+	// Only the queue-backed admin routes need Redis; they answer 503 until it
+	// is reachable, and the client reconnects on its own.
+	err = db.ConnectRedis()
+	if err != nil {
+		slog.Warn("Redis unavailable, queue admin routes disabled until it is back", "error", err)
+	}
+	defer db.CloseRedis()
+	requireRedis := handler.RequireService("Redis", func(ctx context.Context) error {
+		return db.Redis.Ping(ctx).Err()
+	})
+
 	articleRepo := repository.NewArticleRepository(db.DB)
 	articleHandler := handler.NewArticleHandler(articleRepo)
 
+	usageRepo := repository.NewUsageRepository(db.DB)
+	budget, err := pipeline.BudgetFromEnv(usageRepo)
+	if err != nil {
+		log.Fatalf("error reading LLM budget: %v", err)
+	}
+	if budget != nil {
+		articleHandler.WithBudget(budget)
+	}
+
 	summaryRepo := repository.NewSummaryRepository(db.DB)
 	summaryHandler := handler.NewSummaryHandler(summaryRepo)
 
+	deadLetterHandler := handler.NewDeadLetterHandler(
+		articleRepo,
+		db.NewQueue(db.Redis, db.DeadLetterKey, ""),
+		db.NewQueue(db.Redis, db.TransformQueueKey, ""),
+	)
+
+	retransformHandler := handler.NewRetransformHandler(
+		articleRepo,
+		db.NewQueue(db.Redis, db.RetransformKey, ""),
+	)
+
+	experimentHandler := handler.NewExperimentHandler(repository.NewExperimentRepository(db.DB))
+	usageHandler := handler.NewUsageHandler(usageRepo)
+	sourceHandler := handler.NewSourceHandler(
+		repository.NewSourceRepository(db.DB),
+		db.NewQueue(db.Redis, db.FetchTriggerKey, ""),
+	)
+
 	r := gin.Default()
 
 	allowedOrigins := []string{"http://localhost:3000"}
@@ -42,7 +82,7 @@ func main() {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins: allowedOrigins,
 		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders: []string{"Origin", "Content-Type"},
+		AllowHeaders: []string{"Origin", "Content-Type", "Authorization"},
 	}))
 
 	r.GET("/feed/:id", articleHandler.GetArticle)
@@ -55,6 +95,18 @@ func main() {
 	r.GET("/stories", summaryHandler.GetStories)
 	r.GET("/health", articleHandler.GetHealth)
 
+	admin := r.Group("/admin", handler.RequireAdminToken(os.Getenv("ADMIN_API_TOKEN")))
+	admin.GET("/dead-letters", requireRedis, deadLetterHandler.ListDeadLetters)
+	admin.GET("/dead-letters/:id", requireRedis, deadLetterHandler.GetDeadLetter)
+	admin.POST("/dead-letters/:id/requeue", requireRedis, deadLetterHandler.RequeueDeadLetter)
+	admin.DELETE("/dead-letters/:id", requireRedis, deadLetterHandler.DiscardDeadLetter)
+	admin.POST("/retransform", requireRedis, retransformHandler.Retransform)
+	admin.POST("/retransform/rollback", retransformHandler.Rollback)
+	admin.GET("/experiments/:name", experimentHandler.GetExperimentStats)
+	admin.GET("/usage", usageHandler.GetUsage)
+	admin.GET("/sources", sourceHandler.GetSources)
+	admin.POST("/fetch", requireRedis, sourceHandler.TriggerFetch)
+
 	err = r.Run(":8080")
 	if err != nil {
 		log.Fatalf("error starting server: %v", err)
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"zennews/db"
+	"zennews/internal/eval"
+	"zennews/internal/pipeline"
+	"zennews/internal/repository"
+	"zennews/pkg/llm"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+
+	fixtures := flag.String("fixtures", "eval/golden.jsonl", "golden dataset, one JSON case per line")
+	basePrompt := flag.String("base-prompt", "", "transform prompt version for the base run (default: built-in prompt)")
+	candidatePrompt := flag.String("candidate-prompt", "", "transform prompt version for the candidate run")
+	candidatePromptFile := flag.String("candidate-prompt-file", "", "file holding an unsaved transform prompt for the candidate run")
+	tolerance := flag.Float64("tolerance", 0.02, "how much worse a candidate metric may be before the run fails")
+	verbose := flag.Bool("v", false, "list every case that failed or lost points")
+	flag.Parse()
+
+	godotenv.Load()
+
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	cases, err := eval.LoadCases(*fixtures)
+	if err != nil {
+		log.Fatalf("error loading fixtures: %v", err)
+	}
+
+	// The prompt table is only needed to pin stored prompt versions.
+	var prompts pipeline.PromptStore
+	if *basePrompt != "" || *candidatePrompt != "" {
+		err = db.Connect()
+		if err != nil {
+			log.Fatalf("error connecting to DB: %v", err)
+		}
+		defer db.Close()
+		prompts = repository.NewPromptRepository(db.DB)
+	}
+
+	base, err := newClient("EVAL_BASE", prompts, *basePrompt, "")
+	if err != nil {
+		log.Fatalf("error configuring base: %v", err)
+	}
+
+	hasCandidate := *candidatePrompt != "" || *candidatePromptFile != "" ||
+		os.Getenv("EVAL_CANDIDATE_LLM_PROVIDER") != "" || os.Getenv("EVAL_CANDIDATE_LLM_MODEL") != ""
+
+	reports := []*eval.Report{eval.Run("base", base, cases)}
+
+	if hasCandidate {
+		candidate, err := newClient("EVAL_CANDIDATE", prompts, *candidatePrompt, *candidatePromptFile)
+		if err != nil {
+			log.Fatalf("error configuring candidate: %v", err)
+		}
+		reports = append(reports, eval.Run("candidate", candidate, cases))
+	}
+
+	fmt.Printf("%d cases from %s\n\n", len(cases), *fixtures)
+	eval.WriteReport(os.Stdout, reports...)
+
+	if *verbose {
+		fmt.Println()
+		for _, r := range reports {
+			eval.WriteFailures(os.Stdout, r)
+		}
+	}
+
+	if !hasCandidate {
+		return
+	}
+
+	regressions := eval.Compare(reports[0], reports[1], *tolerance)
+	if len(regressions) == 0 {
+		fmt.Println("\nno regressions")
+		return
+	}
+
+	fmt.Println()
+	for _, r := range regressions {
+		fmt.Println(r)
+	}
+	os.Exit(1)
+}
+
+// newClient builds a client from <prefix>_LLM_*, falling back to the
+// transformer's config, with the transform prompt taken from a stored version
+// or a file when given.
+func newClient(prefix string, prompts pipeline.PromptStore, version, file string) (llm.LLMClient, error) {
+	cfg, err := llm.ConfigFromEnv(prefix, "TRANSFORMER")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Prompts = llm.DefaultPrompts()
+	switch {
+	case file != "":
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Prompts.Transform = llm.Prompt{Name: llm.PromptTransform, Version: file, Body: string(body)}
+	case version != "":
+		cfg.Prompts, err = pipeline.WithTransformPrompt(prompts, cfg.Prompts, version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return llm.New(cfg)
+}
@@ -1,19 +1,54 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 	"zennews/db"
-	"zennews/internal/model"
+	"zennews/internal/pipeline"
 	"zennews/internal/repository"
 	"zennews/pkg/news"
 
 	"github.com/joho/godotenv"
 )
 
+const (
+	fetchLimit      = 50
+	outboxBatchSize = 500
+	reconcileLimit  = 1000
+)
+
 func main() {
 
+	reconcile := flag.Bool("reconcile", false, "re-enqueue articles stuck in pending/processing instead of fetching")
+	staleAfter := flag.Duration("stale-after", 30*time.Minute, "how long an article may sit in pending/processing before it is re-enqueued")
+	sourcesPath := flag.String("sources", "config/sources.yaml", "YAML or JSON file listing the news sources to fetch")
+	parallel := flag.Int("parallel", 4, "how many sources to fetch at once")
+	sourceTimeout := flag.Duration("source-timeout", time.Minute, "how long one source may take before its fetch is cancelled (0 for no limit)")
+	daemon := flag.Bool("daemon", false, "keep running and fetch each source on its schedule")
+	sessionInterval := flag.Duration("session-interval", 5*time.Minute, "daemon: how often to fetch a source without a schedule while the NYSE is open")
+	offHoursInterval := flag.Duration("off-hours-interval", 30*time.Minute, "daemon: how often to fetch a source without a schedule outside the session on weekdays")
+	weekendInterval := flag.Duration("weekend-interval", 2*time.Hour, "daemon: how often to fetch a source without a schedule on weekends")
+	jitter := flag.Duration("jitter", 30*time.Second, "daemon: maximum random delay added to each scheduled fetch")
+	lockTTL := flag.Duration("lock-ttl", 30*time.Second, "daemon: how long the leader lock outlives a replica that stopped renewing it")
+	flag.Parse()
+
+	if *parallel < 1 {
+		log.Fatalf("-parallel must be at least 1, got %d", *parallel)
+	}
+	if *daemon && *reconcile {
+		log.Fatal("-daemon and -reconcile cannot be combined")
+	}
+	if *daemon && (*sessionInterval <= 0 || *offHoursInterval <= 0 || *weekendInterval <= 0 || *lockTTL <= 0 || *jitter < 0) {
+		log.Fatal("-session-interval, -off-hours-interval, -weekend-interval and -lock-ttl must be positive and -jitter not negative")
+	}
+
 	godotenv.Load()
 
 	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
@@ -24,71 +59,128 @@ func main() {
 	}
 	defer db.Close()
 
-	var clients []news.NewsClient
-	if key := os.Getenv("FINNHUB_API_KEY"); key != "" {
-		clients = append(clients, news.NewFinnHubClient(key))
+	// Rate limits and quotas are shared through Redis. Without it a one-shot
+	// fetch still runs, unlimited, and fails before draining the outbox.
+	redisErr := db.ConnectRedis()
+	if redisErr != nil && *daemon {
+		log.Fatalf("error connecting to Redis: %v", redisErr)
 	}
-	if key := os.Getenv("ALPHA_VANTAGE_API_KEY"); key != "" {
-		clients = append(clients, news.NewAlphaVantageClient(key))
+	if redisErr != nil && !*reconcile {
+		slog.Warn("Redis unavailable, fetching without rate limits or quotas", "error", redisErr)
 	}
-	if key := os.Getenv("MASSIVE_API_KEY"); key != "" {
-		clients = append(clients, news.NewMassiveClient(key))
+	defer db.CloseRedis()
+
+	var limiter news.Limiter
+	var quotas pipeline.QuotaStore
+	if redisErr == nil {
+		limiter = db.NewRateLimiter(db.Redis, db.RateLimitKey)
+		quotas = db.NewQuotaTracker(db.Redis, db.QuotaKey)
 	}
-	if key := os.Getenv("MARKETAUX_API_KEY"); key != "" {
-		clients = append(clients, news.NewMarketauxClient(key, 4))
+
+	repo := repository.NewArticleRepository(db.DB)
+	fetcher := &pipeline.Fetcher{
+		Store:    repo,
+		Sources:  repository.NewSourceRepository(db.DB),
+		Limit:    fetchLimit,
+		Parallel: *parallel,
+		Timeout:  *sourceTimeout,
+		Quotas:   quotas,
 	}
 
-	// RSS feeds (no API key required)
-	clients = append(clients,
-		news.NewRSSClient("https://search.cnbc.com/rs/search/combinedcms/view.xml?partnerId=wrss01&id=100727362", "CNBC"),
-		news.NewRSSClient("https://search.cnbc.com/rs/search/combinedcms/view.xml?partnerId=wrss01&id=15837362", "CNBC"),
-	)
+	if *daemon {
+		sources, err := loadSources(*sourcesPath, limiter)
+		if err != nil {
+			log.Fatalf("error loading news sources: %v", err)
+		}
+		market := pipeline.MarketSchedule{Session: *sessionInterval, OffHours: *offHoursInterval, Weekend: *weekendInterval}
+		scheduled, err := schedule(sources, market)
+		if err != nil {
+			log.Fatalf("error loading news sources: %v", err)
+		}
+
+		scheduler := &pipeline.Scheduler{
+			Fetcher:     fetcher,
+			Sources:     scheduled,
+			Leader:      db.NewLock(db.Redis, db.FetchLeaderKey, "", *lockTTL),
+			Triggers:    db.NewQueue(db.Redis, db.FetchTriggerKey, ""),
+			Queue:       db.NewQueue(db.Redis, db.TransformQueueKey, ""),
+			OutboxBatch: outboxBatchSize,
+			Tick:        *lockTTL / 3,
+			Jitter:      *jitter,
+		}
 
-	if len(clients) == 0 {
-		slog.Error("no news source API keys configured")
+		// SIGINT/SIGTERM stop the scheduler after cancelling the fetches in
+		// flight and release the lock for another replica.
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		slog.Info("fetch scheduler started", "sources", len(scheduled))
+		scheduler.Run(ctx)
 		return
 	}
 
-	repo := repository.NewArticleRepository(db.DB)
-
-	for _, client := range clients {
-		source := client.Name()
-
-		fetchedArticles, err := client.Fetch(50)
+	if *reconcile {
+		requeued, err := repo.RequeueStale(*staleAfter, reconcileLimit)
+		if err != nil {
+			log.Fatalf("error requeueing stale articles: %v", err)
+		}
+		slog.Info("stale articles requeued", "count", requeued, "stale_after", staleAfter.String())
+	} else {
+		sources, err := loadSources(*sourcesPath, limiter)
 		if err != nil {
-			slog.Error("error fetching articles", "source", source, "error", err)
-			continue
+			log.Fatalf("error loading news sources: %v", err)
+		}
+		clients := make([]news.NewsClient, len(sources))
+		for i, s := range sources {
+			clients[i] = s
 		}
 
-		var saved, duplicated, errors int
-
-		for _, a := range fetchedArticles {
-			article := model.OriginalArticle{
-				Headline:    a.Headline,
-				Detail:      a.Detail,
-				URL:         a.URL,
-				Source:      a.Source,
-				Publisher:   a.Publisher,
-				PublishedAt: a.PublishedAt,
-				ExternalID:  a.ExternalID,
-			}
+		// SIGINT/SIGTERM abort the requests in flight; what was already saved
+		// stays saved.
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		fetcher.Run(ctx, clients)
+		stop()
+	}
 
-			success, err := repo.SaveOriginalWithSymbols(&article, a.Symbols)
-			if err != nil {
-				slog.Error("error saving article", "source", source, "error", err)
-				errors++
-				continue
-			}
+	// Articles are saved even if Redis is unavailable; their outbox rows are
+	// picked up by the next run.
+	if redisErr != nil {
+		log.Fatalf("error connecting to Redis: %v", redisErr)
+	}
 
-			if !success {
-				slog.Info("duplicate article skipped", "source", source, "url", a.URL)
-				duplicated++
-				continue
-			}
+	queue := db.NewQueue(db.Redis, db.TransformQueueKey, "")
 
-			saved++
-		}
+	enqueued, err := pipeline.DrainOutbox(db.Ctx, repo, queue, outboxBatchSize)
+	if err != nil {
+		slog.Error("error draining transform outbox", "enqueued", enqueued, "error", err)
+		return
+	}
+
+	slog.Info("transform outbox drained", "enqueued", enqueued)
+}
+
+// loadSources returns every enabled source in the config at path whose API
+// key is set, holding their requests to their limits with limiter.
+func loadSources(path string, limiter news.Limiter) ([]*news.Source, error) {
+	cfg, err := news.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return news.NewRegistry(limiter).Sources(cfg)
+}
 
-		slog.Info("fetch complete", "source", source, "saved", saved, "duplicated", duplicated, "errors", errors)
+// schedule pairs each source with its configured schedule, or market when it
+// has none.
+func schedule(sources []*news.Source, market pipeline.Schedule) ([]pipeline.ScheduledSource, error) {
+	scheduled := make([]pipeline.ScheduledSource, len(sources))
+	for i, s := range sources {
+		scheduled[i] = pipeline.ScheduledSource{Client: s, Schedule: market}
+		if expr := s.Config().Schedule; expr != "" {
+			sched, err := pipeline.ParseSchedule(expr)
+			if err != nil {
+				return nil, fmt.Errorf("source %s: %w", s.Name(), err)
+			}
+			scheduled[i].Schedule = sched
+		}
 	}
+	return scheduled, nil
 }
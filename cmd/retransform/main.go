@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"zennews/db"
+	"zennews/internal/model"
+	"zennews/internal/pipeline"
+	"zennews/internal/repository"
+	"zennews/pkg/llm"
+
+	"github.com/joho/godotenv"
+)
+
+// visibilityTimeout is how long an article may stay in flight on the
+// retransform queue before a later run returns it to the queue.
+const visibilityTimeout = 10 * time.Minute
+
+func main() {
+
+	ids := flag.String("ids", "", "comma-separated original article IDs")
+	promptVersion := flag.String("prompt-version", "", "only articles whose active version used this prompt version")
+	modelUsed := flag.String("model", "", "only articles whose active version was produced by this model")
+	category := flag.String("category", "", "only articles in this category")
+	from := flag.String("from", "", "only articles published at or after this date (YYYY-MM-DD or RFC 3339)")
+	to := flag.String("to", "", "only articles published before this date (YYYY-MM-DD or RFC 3339)")
+	limit := flag.Int("limit", 0, "maximum number of articles to select (0 for no limit)")
+	rollback := flag.Bool("rollback", false, "reactivate the previous version of the selected articles instead of retransforming")
+	dryRun := flag.Bool("dry-run", false, "only report how many articles match")
+	workers := flag.Int("workers", 1, "number of concurrent retransform workers")
+	flag.Parse()
+
+	godotenv.Load()
+
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	filter, err := parseFilter(*ids, *promptVersion, *modelUsed, *category, *from, *to, *limit)
+	if err != nil {
+		log.Fatalf("invalid filter: %v", err)
+	}
+	hasFilter := len(filter.IDs) > 0 || *promptVersion != "" || *modelUsed != "" ||
+		*category != "" || *from != "" || *to != ""
+
+	if (*rollback || *dryRun) && !hasFilter {
+		log.Fatalf("-rollback and -dry-run need at least one filter")
+	}
+
+	if *workers < 1 {
+		log.Fatalf("-workers must be at least 1, got %d", *workers)
+	}
+
+	validation, err := pipeline.ParseValidationMode(os.Getenv("TRANSFORMER_VALIDATION"))
+	if err != nil {
+		log.Fatalf("invalid TRANSFORMER_VALIDATION: %v", err)
+	}
+
+	err = db.Connect()
+	if err != nil {
+		log.Fatalf("error connecting to DB: %v", err)
+	}
+	defer db.Close()
+
+	articleRepository := repository.NewArticleRepository(db.DB)
+	usageRepository := repository.NewUsageRepository(db.DB)
+
+	if *dryRun {
+		matched, err := articleRepository.GetRetransformIDs(filter)
+		if err != nil {
+			log.Fatalf("error selecting articles: %v", err)
+		}
+		slog.Info("articles matching filter", "count", len(matched))
+		return
+	}
+
+	if *rollback {
+		rolledBack, err := articleRepository.RollbackTransformed(filter)
+		if err != nil {
+			log.Fatalf("error rolling back transformed articles: %v", err)
+		}
+		slog.Info("transformed articles rolled back", "count", rolledBack)
+		return
+	}
+
+	err = db.ConnectRedis()
+	if err != nil {
+		log.Fatalf("error connecting to Redis: %v", err)
+	}
+	defer db.CloseRedis()
+
+	workerID := db.DefaultWorkerID()
+	queue := db.NewQueue(db.Redis, db.RetransformKey, workerID)
+
+	// Articles left in flight by a crashed run go back on the queue.
+	requeued, err := queue.Reap(db.Ctx, visibilityTimeout)
+	if err != nil {
+		slog.Error("error reaping in-flight articles", "error", err)
+	}
+	if requeued > 0 {
+		slog.Warn("requeued stale in-flight articles", "count", requeued)
+	}
+
+	// Without a filter the command only drains articles queued through the
+	// admin API.
+	if hasFilter {
+		queued, err := pipeline.EnqueueRetransform(db.Ctx, articleRepository, queue, filter)
+		if err != nil {
+			log.Fatalf("error queueing articles for retransform (queued %d): %v", queued, err)
+		}
+		slog.Info("articles queued for retransform", "count", queued)
+	}
+
+	llmConfig, err := llm.ConfigFromEnv("TRANSFORMER")
+	if err != nil {
+		log.Fatalf("error reading LLM config: %v", err)
+	}
+	llmConfig.Prompts, err = pipeline.LoadPrompts(repository.NewPromptRepository(db.DB))
+	if err != nil {
+		log.Fatalf("error loading prompts: %v", err)
+	}
+	llmClient, err := llm.New(llmConfig)
+	if err != nil {
+		log.Fatalf("error creating LLM client: %v", err)
+	}
+
+	budget, err := pipeline.BudgetFromEnv(usageRepository)
+	if err != nil {
+		log.Fatalf("error reading LLM budget: %v", err)
+	}
+	throttle, err := pipeline.NewThrottle(budget, llmConfig, nil)
+	if err != nil {
+		log.Fatalf("error configuring LLM budget: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	slog.Info("starting retransform", "workers", *workers, "llm_provider", llmConfig.Provider,
+		"llm_model", llmConfig.Model, "prompt_version", llmConfig.Prompts.Transform.Version)
+
+	var wg sync.WaitGroup
+	for i := 0; i < *workers; i++ {
+		id := fmt.Sprintf("%s-%d", workerID, i)
+		w := &pipeline.Worker{
+			ID:          id,
+			Store:       articleRepository,
+			Client:      llmClient,
+			Queue:       db.NewQueue(db.Redis, db.RetransformKey, id),
+			Retransform: true,
+			Validation:  validation,
+			Throttle:    throttle,
+			Usage:       usageRepository,
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Run(ctx)
+		}()
+	}
+
+	wg.Wait()
+
+	slog.Info("retransform finished")
+}
+
+func parseFilter(ids, promptVersion, modelUsed, category, from, to string, limit int) (model.RetransformFilter, error) {
+	filter := model.RetransformFilter{
+		PromptVersion: promptVersion,
+		ModelUsed:     modelUsed,
+		Category:      category,
+		Limit:         limit,
+	}
+
+	for _, s := range strings.Split(ids, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid article id %q", s)
+		}
+		filter.IDs = append(filter.IDs, id)
+	}
+
+	var err error
+	if filter.From, err = parseDate(from); err != nil {
+		return filter, fmt.Errorf("invalid -from: %w", err)
+	}
+	if filter.To, err = parseDate(to); err != nil {
+		return filter, fmt.Errorf("invalid -to: %w", err)
+	}
+
+	return filter, nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"zennews/db"
 	"zennews/internal/model"
+	"zennews/internal/pipeline"
 	"zennews/internal/repository"
 	"zennews/pkg/llm"
 
@@ -25,81 +26,50 @@ func main() {
 
 	articleRepo := repository.NewArticleRepository(db.DB)
 	summaryRepo := repository.NewSummaryRepository(db.DB)
-	openAIClient := llm.NewOpenAIClient(os.Getenv("OPENAI_API_KEY"))
+	usageRepo := repository.NewUsageRepository(db.DB)
 
-	fromID, err := summaryRepo.GetLastToArticleID()
+	llmConfig, err := llm.ConfigFromEnv("SUMMARIZER")
 	if err != nil {
-		log.Fatalf("error getting last summary article id: %v", err)
+		log.Fatalf("error reading LLM config: %v", err)
 	}
-
-	articles, err := summaryRepo.GetArticlesForSummary(fromID)
+	llmConfig.Prompts, err = pipeline.LoadPrompts(repository.NewPromptRepository(db.DB))
 	if err != nil {
-		log.Fatalf("error fetching articles for summary: %v", err)
+		log.Fatalf("error loading prompts: %v", err)
 	}
-
-	if len(articles) == 0 {
-		slog.Info("no new articles to summarize, exiting")
-		return
+	llmClient, err := llm.New(llmConfig)
+	if err != nil {
+		log.Fatalf("error creating LLM client: %v", err)
 	}
 
-	slog.Info("summarizing articles", "count", len(articles), "from_id", fromID)
-
-	// Batch-load symbols for all articles
-	articleIDs := make([]int64, len(articles))
-	for i, a := range articles {
-		articleIDs[i] = a.ID
+	budget, err := pipeline.BudgetFromEnv(usageRepo)
+	if err != nil {
+		log.Fatalf("error reading LLM budget: %v", err)
 	}
-	symbolsMap, err := articleRepo.GetSymbolsByOriginalIDs(articleIDs)
+	throttle, err := pipeline.NewThrottle(budget, llmConfig, nil)
 	if err != nil {
-		log.Fatalf("error fetching symbols: %v", err)
+		log.Fatalf("error configuring LLM budget: %v", err)
 	}
-
-	inputs := make([]llm.SummaryInput, len(articles))
-	for i, a := range articles {
-		inputs[i] = llm.SummaryInput{
-			ID:          a.ID,
-			Headline:    a.Headline,
-			Detail:      a.Detail,
-			Publisher:   a.Publisher,
-			PublishedAt: a.PublishedAt,
-			Symbols:     symbolsMap[a.ID],
+	switch throttle.Level() {
+	case model.BudgetHard:
+		slog.Warn("daily LLM budget exhausted, skipping summary")
+		return
+	case model.BudgetSoft:
+		if throttle.Cheap != nil {
+			slog.Info("LLM budget is low, summarizing with the budget model", "llm_model", llmConfig.BudgetModel)
+			llmClient = throttle.Cheap
 		}
 	}
 
-	result, err := openAIClient.ClusterAndSummarize(inputs)
-	if err != nil {
-		log.Fatalf("error generating cluster summary: %v", err)
-	}
-
-	summary := &model.NewsSummary{
-		Paragraph:     "",
-		Bullets:       []string{},
-		ArticleCount:  len(articles),
-		FromArticleID: articles[0].ID,
-		ToArticleID:   articles[len(articles)-1].ID,
-		ModelUsed:     result.ModelUsed,
-	}
+	slog.Info("starting summarizer", "llm_provider", llmConfig.Provider, "llm_model", llmConfig.Model)
 
-	err = summaryRepo.SaveSummary(summary)
+	summary, stories, err := pipeline.Summarize(summaryRepo, articleRepo, usageRepo, llmClient)
 	if err != nil {
-		log.Fatalf("error saving summary: %v", err)
+		log.Fatalf("error summarizing articles: %v", err)
 	}
 
-	stories := make([]model.NewsStory, len(result.Stories))
-	for i, s := range result.Stories {
-		stories[i] = model.NewsStory{
-			Headline:   s.Headline,
-			Summary:    s.Summary,
-			Angles:     s.Angles,
-			Tickers:    s.Tickers,
-			Publishers: s.Publishers,
-			TimeRange:  s.TimeRange,
-		}
-	}
-
-	err = summaryRepo.SaveStories(summary.ID, stories)
-	if err != nil {
-		log.Fatalf("error saving stories: %v", err)
+	if summary == nil {
+		slog.Info("no new articles to summarize, exiting")
+		return
 	}
 
 	slog.Info("summary saved successfully", "summary_id", summary.ID, "article_count", summary.ArticleCount, "story_count", len(stories))
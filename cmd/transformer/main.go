@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"log/slog"
 	"os"
-	"strconv"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 	"zennews/db"
-	"zennews/internal/model"
+	"zennews/internal/pipeline"
 	"zennews/internal/repository"
 	"zennews/pkg/llm"
 
@@ -16,13 +22,40 @@ import (
 
 func main() {
 
+	workerID := flag.String("worker-id", "", "identifier for this process's in-flight lists (default hostname-pid)")
+	workers := flag.Int("workers", 1, "number of concurrent transform workers")
+	daemon := flag.Bool("daemon", false, "keep running when the queue is empty instead of exiting")
+	visibilityTimeout := flag.Duration("visibility-timeout", 10*time.Minute, "how long an article may stay in flight before it is returned to the queue")
+	pack := flag.Int("pack", 1, "maximum number of already queued articles to transform in one LLM call")
+	batch := flag.Bool("batch", false, "transform pending articles through the provider's batch API instead of the queue; failed articles are retried by a queue-based transformer")
+	batchSize := flag.Int("batch-size", 1000, "maximum number of articles per batch")
+	batchPoll := flag.Duration("batch-poll", time.Minute, "how often to check on a submitted batch")
+	flag.Parse()
+
 	godotenv.Load()
 
 	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
 
-	const maxRetries = 3
+	if *workers < 1 {
+		log.Fatalf("-workers must be at least 1, got %d", *workers)
+	}
+	if *pack < 1 {
+		log.Fatalf("-pack must be at least 1, got %d", *pack)
+	}
+	if *batchSize < 1 {
+		log.Fatalf("-batch-size must be at least 1, got %d", *batchSize)
+	}
+
+	validation, err := pipeline.ParseValidationMode(os.Getenv("TRANSFORMER_VALIDATION"))
+	if err != nil {
+		log.Fatalf("invalid TRANSFORMER_VALIDATION: %v", err)
+	}
+
+	if *workerID == "" {
+		*workerID = db.DefaultWorkerID()
+	}
 
-	err := db.ConnectRedis()
+	err = db.ConnectRedis()
 	if err != nil {
 		log.Fatalf("error connecting to Redis: %v", err)
 	}
@@ -35,100 +68,227 @@ func main() {
 	defer db.Close()
 
 	articleRepository := repository.NewArticleRepository(db.DB)
+	usageRepository := repository.NewUsageRepository(db.DB)
 
-	openAIClient := llm.NewOpenAIClient(os.Getenv("OPENAI_API_KEY"))
+	llmConfig, err := llm.ConfigFromEnv("TRANSFORMER")
+	if err != nil {
+		log.Fatalf("error reading LLM config: %v", err)
+	}
+	promptRepository := repository.NewPromptRepository(db.DB)
+	llmConfig.Prompts, err = pipeline.LoadPrompts(promptRepository)
+	if err != nil {
+		log.Fatalf("error loading prompts: %v", err)
+	}
+	llmConfig.Cache, err = cacheFromEnv()
+	if err != nil {
+		log.Fatalf("error configuring LLM response cache: %v", err)
+	}
+	llmClient, err := llm.New(llmConfig)
+	if err != nil {
+		log.Fatalf("error creating LLM client: %v", err)
+	}
 
-	for {
-		id, err := db.PopFromQueue(db.TransformQueueKey, 10*time.Second)
-		if err != nil {
-			slog.Error("error popping from Redis queue", "error", err)
-			break
+	budget, err := pipeline.BudgetFromEnv(usageRepository)
+	if err != nil {
+		log.Fatalf("error reading LLM budget: %v", err)
+	}
+	var deferPublishers []string
+	for _, p := range strings.Split(os.Getenv("TRANSFORMER_DEFER_PUBLISHERS"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			deferPublishers = append(deferPublishers, p)
 		}
+	}
+	throttle, err := pipeline.NewThrottle(budget, llmConfig, deferPublishers)
+	if err != nil {
+		log.Fatalf("error configuring LLM budget: %v", err)
+	}
 
-		if id == "" {
-			slog.Info("Queue is empty, exiting")
-			break
-		}
+	experiment, err := experimentFromEnv(promptRepository, llmConfig.Prompts, llmConfig.Cache)
+	if err != nil {
+		log.Fatalf("error configuring experiment: %v", err)
+	}
 
-		articleId, err := strconv.ParseInt(id, 10, 64)
-		if err != nil {
-			slog.Error("invalid article id in queue", "id", id, "error", err)
-			continue
+	// SIGINT/SIGTERM stop workers from taking new articles; in-flight LLM
+	// calls still complete and are saved before the process exits.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if *batch {
+		runBatcher(ctx, llmConfig, &pipeline.Worker{
+			ID:          *workerID,
+			Store:       articleRepository,
+			DeadLetters: db.NewQueue(db.Redis, db.DeadLetterKey, *workerID),
+			Validation:  validation,
+			Usage:       usageRepository,
+			Throttle:    throttle,
+		}, *batchSize, *batchPoll, *daemon)
+		return
+	}
+
+	reaperQueue := db.NewQueue(db.Redis, db.TransformQueueKey, *workerID)
+	reaperCtx, stopReaper := context.WithCancel(db.Ctx)
+	go runReaper(reaperCtx, reaperQueue, *visibilityTimeout)
+
+	slog.Info("starting transformer", "workers", *workers, "pack", *pack, "daemon", *daemon, "worker_id", *workerID,
+		"llm_provider", llmConfig.Provider, "llm_model", llmConfig.Model, "prompt_version", llmConfig.Prompts.Transform.Version,
+		"validation", validation, "budget", budget != nil, "cache", llmConfig.Cache != nil)
+	if experiment != nil {
+		for _, arm := range experiment.Arms {
+			slog.Info("experiment arm", "experiment", experiment.Name, "arm", arm.Name, "weight", arm.Weight)
 		}
+	}
 
-		errorCount, err := articleRepository.GetErrorCount(articleId)
-		if err != nil {
-			slog.Error("error getting error count", "error", err, "article_id", articleId)
-			continue
+	var wg sync.WaitGroup
+	for i := 0; i < *workers; i++ {
+		id := fmt.Sprintf("%s-%d", *workerID, i)
+		w := &pipeline.Worker{
+			ID:          id,
+			Store:       articleRepository,
+			Client:      llmClient,
+			Experiment:  experiment,
+			Queue:       db.NewQueue(db.Redis, db.TransformQueueKey, id),
+			DeadLetters: db.NewQueue(db.Redis, db.DeadLetterKey, id),
+			Daemon:      *daemon,
+			Validation:  validation,
+			Usage:       usageRepository,
+			Throttle:    throttle,
+			Pack:        *pack,
 		}
 
-		if errorCount >= maxRetries {
-			slog.Warn("article exceeded max retries, marking as failed", "article_id", articleId, "error_count", errorCount)
-			articleRepository.UpdateStatus(articleId, model.StatusFailed)
-			continue
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Run(ctx)
+		}()
+	}
+
+	wg.Wait()
+	stopReaper()
+
+	slog.Info("transformer stopped")
+}
+
+// runBatcher transforms pending articles through the provider's batch API.
+// Experiments, deferral and the response cache only apply to the queue.
+func runBatcher(ctx context.Context, cfg llm.Config, worker *pipeline.Worker, size int, poll time.Duration, daemon bool) {
+	client, err := llm.NewBatchClient(cfg)
+	if err != nil {
+		log.Fatalf("error creating LLM batch client: %v", err)
+	}
+
+	provider := cfg.Provider
+	if provider == "" {
+		provider = llm.ProviderOpenAI
+	}
+
+	slog.Info("starting batch transformer", "batch_size", size, "poll", poll, "daemon", daemon,
+		"llm_provider", provider, "llm_model", cfg.Model, "prompt_version", cfg.Prompts.Transform.Version)
+
+	b := &pipeline.Batcher{
+		Worker:   worker,
+		Client:   client,
+		Store:    repository.NewBatchRepository(db.DB),
+		Provider: provider,
+		Size:     size,
+		Poll:     poll,
+		Daemon:   daemon,
+	}
+	b.Run(ctx)
+
+	slog.Info("batch transformer stopped")
+}
+
+// runReaper periodically returns articles whose worker died mid-transform and
+// articles whose retry delay has passed to the queue until ctx is cancelled.
+func runReaper(ctx context.Context, queue *db.Queue, visibility time.Duration) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		requeued, err := queue.Reap(ctx, visibility)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("error reaping in-flight articles", "error", err)
+		}
+		if requeued > 0 {
+			slog.Warn("requeued stale in-flight articles", "count", requeued)
 		}
 
-		article, err := articleRepository.GetOriginalByID(articleId)
-		if err != nil {
-			slog.Error("error getting article from DB", "error", err, "article_id", articleId)
-			continue
+		promoted, err := queue.PromoteDelayed(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("error promoting delayed articles", "error", err)
+		}
+		if promoted > 0 {
+			slog.Info("requeued articles after retry delay", "count", promoted)
 		}
 
-		if article == nil {
-			slog.Warn("article not found in DB", "article_id", articleId)
-			continue
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
+	}
+}
 
-		input := llm.TransformInput{
-			Headline: article.Headline,
-			Detail:   article.Detail,
+// cacheFromEnv returns the transform response cache, Redis in front of
+// Postgres, unless TRANSFORMER_CACHE is "off". Redis entries expire after
+// TRANSFORMER_CACHE_TTL (default 168h).
+func cacheFromEnv() (llm.ResponseCache, error) {
+	if strings.EqualFold(os.Getenv("TRANSFORMER_CACHE"), "off") {
+		return nil, nil
+	}
+
+	ttl := 7 * 24 * time.Hour
+	if v := os.Getenv("TRANSFORMER_CACHE_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid TRANSFORMER_CACHE_TTL %q", v)
 		}
+		ttl = d
+	}
 
-		result, err := openAIClient.Transform(input)
-		if err != nil {
-			slog.Error("error transforming article", "error", err, "article_id", articleId)
+	return llm.TieredCache{
+		db.NewCache(db.Redis, db.ResponseCacheKey, ttl),
+		repository.NewCacheRepository(db.DB),
+	}, nil
+}
 
-			articleRepository.SaveError(articleId, err.Error(), "llm_error")
+// experimentFromEnv builds the experiment named by TRANSFORMER_EXPERIMENT, or
+// returns nil when none is configured. TRANSFORMER_EXPERIMENT_ARMS lists the
+// arms as "name[:weight],...". Each arm reads its LLM config from
+// TRANSFORMER_ARM_<NAME>_LLM_*, falling back to the transformer's, and may pin
+// a transform prompt with TRANSFORMER_ARM_<NAME>_PROMPT_VERSION.
+func experimentFromEnv(prompts pipeline.PromptStore, active llm.PromptSet, cache llm.ResponseCache) (*pipeline.Experiment, error) {
+	name := os.Getenv("TRANSFORMER_EXPERIMENT")
+	if name == "" {
+		return nil, nil
+	}
 
-			db.PushToQueue(db.TransformQueueKey, strconv.FormatInt(articleId, 10))
+	arms, err := pipeline.ParseArms(os.Getenv("TRANSFORMER_EXPERIMENT_ARMS"))
+	if err != nil {
+		return nil, err
+	}
 
-			time.Sleep(5 * time.Second)
-			continue
-		}
+	for i := range arms {
+		prefix := "TRANSFORMER_ARM_" + strings.ToUpper(strings.ReplaceAll(arms[i].Name, "-", "_"))
 
-		category, err := articleRepository.GetCategoryByName(result.Category)
+		cfg, err := llm.ConfigFromEnv(prefix, "TRANSFORMER")
 		if err != nil {
-			slog.Error("error getting category", "error", err, "category", result.Category)
+			return nil, fmt.Errorf("arm %s: %w", arms[i].Name, err)
 		}
 
-		if category == nil {
-			slog.Warn("LLM returned unknown category, falling back to Others", "category", result.Category, "article_id", articleId)
-			category, err = articleRepository.GetCategoryByName(model.OthersCategory)
-
+		cfg.Prompts, cfg.Cache = active, cache
+		if version := os.Getenv(prefix + "_PROMPT_VERSION"); version != "" {
+			cfg.Prompts, err = pipeline.WithTransformPrompt(prompts, active, version)
 			if err != nil {
-				slog.Error("error getting Others category", "error", err, "article_id", articleId)
-				continue
+				return nil, fmt.Errorf("arm %s: %w", arms[i].Name, err)
 			}
 		}
 
-		transformedArticle := model.TransformedArticle{
-			Headline:       result.Headline,
-			Detail:         result.Detail,
-			OriginalID:     article.ID,
-			CategoryID:     category.ID,
-			SentimentScore: result.SentimentScore,
-			PromptVersion:  result.PromptVersion,
-			ModelUsed:      result.ModelUsed,
-			TransformedAt:  time.Now(),
-		}
-
-		err = articleRepository.SaveTransformedAndComplete(&transformedArticle, article.ID)
+		arms[i].Client, err = llm.New(cfg)
 		if err != nil {
-			slog.Error("error saving transformed article", "error", err, "article_id", articleId)
-			continue
+			return nil, fmt.Errorf("arm %s: %w", arms[i].Name, err)
 		}
-
-		slog.Info("article transformed successfully", "article_id", article.ID)
 	}
 
+	return &pipeline.Experiment{Name: name, Arms: arms}, nil
 }
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache is a key-value cache in Redis whose entries expire after ttl. Keys
+// are stored under prefix.
+type Cache struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+func NewCache(client *redis.Client, prefix string, ttl time.Duration) *Cache {
+	return &Cache{client: client, prefix: prefix, ttl: ttl}
+}
+
+// Get reports a missing or expired entry as false with a nil error.
+func (c *Cache) Get(key string) ([]byte, bool, error) {
+	value, err := c.client.Get(Ctx, c.prefix+":"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (c *Cache) Set(key string, value []byte) error {
+	return c.client.Set(Ctx, c.prefix+":"+key, value, c.ttl).Err()
+}
+
+func (c *Cache) Delete(key string) error {
+	return c.client.Del(Ctx, c.prefix+":"+key).Err()
+}
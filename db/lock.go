@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireScript takes the lock for ARGV[1], or extends it when ARGV[1]
+// already holds it, for ARGV[2] milliseconds. It returns 1 when ARGV[1]
+// holds the lock.
+//
+// KEYS[1] = lock
+var acquireScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// releaseScript deletes the lock if ARGV[1] holds it.
+//
+// KEYS[1] = lock
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Lock is a Redis lock held by one owner at a time. It expires ttl after it
+// was last acquired, so a crashed owner's lock passes to another.
+type Lock struct {
+	client *redis.Client
+	key    string
+	owner  string
+	ttl    time.Duration
+}
+
+// NewLock returns the lock on key for owner. An empty owner defaults to
+// DefaultWorkerID.
+func NewLock(client *redis.Client, key, owner string, ttl time.Duration) *Lock {
+	if owner == "" {
+		owner = DefaultWorkerID()
+	}
+	return &Lock{client: client, key: key, owner: owner, ttl: ttl}
+}
+
+// Acquire takes the lock, or extends it when this owner already holds it,
+// and reports whether this owner holds it.
+func (l *Lock) Acquire(ctx context.Context) (bool, error) {
+	held, err := acquireScript.Run(ctx, l.client, []string{l.key}, l.owner, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return held == 1, nil
+}
+
+// Release gives up the lock if this owner holds it.
+func (l *Lock) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, l.client, []string{l.key}, l.owner).Err()
+}
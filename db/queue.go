@@ -0,0 +1,221 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// reapScript returns every ID in one processing list whose lease is older than
+// the cutoff to the head of the queue. IDs without a lease (the worker crashed
+// between BLMOVE and ZADD) are given one so they are reaped on a later pass.
+//
+// KEYS[1] = queue, KEYS[2] = leases, KEYS[3] = processing list
+// ARGV[1] = now (unix seconds), ARGV[2] = cutoff (unix seconds)
+var reapScript = redis.NewScript(`
+local requeued = 0
+for _, id in ipairs(redis.call('LRANGE', KEYS[3], 0, -1)) do
+	local member = KEYS[3] .. '|' .. id
+	local score = redis.call('ZSCORE', KEYS[2], member)
+	if not score then
+		redis.call('ZADD', KEYS[2], ARGV[1], member)
+	elseif tonumber(score) <= tonumber(ARGV[2]) then
+		redis.call('LREM', KEYS[3], 1, id)
+		redis.call('ZREM', KEYS[2], member)
+		redis.call('RPUSH', KEYS[1], id)
+		requeued = requeued + 1
+	end
+end
+return requeued
+`)
+
+// promoteScript moves up to ARGV[2] delayed IDs that are due at ARGV[1] onto
+// the queue.
+//
+// KEYS[1] = queue, KEYS[2] = delayed set
+var promoteScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, id in ipairs(due) do
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('LPUSH', KEYS[1], id)
+end
+return #due
+`)
+
+// Queue is a reliable Redis list queue. Popped IDs are moved atomically into a
+// per-worker processing list and leased until they are acked or nacked, so an
+// ID survives a worker crash and is returned to the queue by Reap.
+type Queue struct {
+	client        *redis.Client
+	key           string
+	processingKey string
+	leaseKey      string
+	delayedKey    string
+}
+
+// NewQueue returns a queue on key for the given worker. An empty workerID
+// defaults to DefaultWorkerID.
+func NewQueue(client *redis.Client, key string, workerID string) *Queue {
+	if workerID == "" {
+		workerID = DefaultWorkerID()
+	}
+	return &Queue{
+		client:        client,
+		key:           key,
+		processingKey: key + ":processing:" + workerID,
+		leaseKey:      key + ":leases",
+		delayedKey:    key + ":delayed",
+	}
+}
+
+// DefaultWorkerID identifies the current process as hostname-pid.
+func DefaultWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+func (q *Queue) Key() string {
+	return q.key
+}
+
+func (q *Queue) Push(ctx context.Context, id string) error {
+	return q.client.LPush(ctx, q.key, id).Err()
+}
+
+// Pop blocks for up to timeout waiting for an ID. It returns "" with a nil
+// error when the queue stayed empty.
+func (q *Queue) Pop(ctx context.Context, timeout time.Duration) (string, error) {
+	id, err := q.client.BLMove(ctx, q.key, q.processingKey, "RIGHT", "LEFT", timeout).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// A failed lease write is recovered by Reap, which leases unleased IDs.
+	q.client.ZAdd(ctx, q.leaseKey, redis.Z{Score: float64(time.Now().Unix()), Member: q.leaseMember(id)})
+
+	return id, nil
+}
+
+// TryPop is Pop without blocking.
+func (q *Queue) TryPop(ctx context.Context) (string, error) {
+	id, err := q.client.LMove(ctx, q.key, q.processingKey, "RIGHT", "LEFT").Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	q.client.ZAdd(ctx, q.leaseKey, redis.Z{Score: float64(time.Now().Unix()), Member: q.leaseMember(id)})
+
+	return id, nil
+}
+
+// Ack removes a finished ID from this worker's processing list.
+func (q *Queue) Ack(ctx context.Context, id string) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, q.processingKey, 1, id)
+		pipe.ZRem(ctx, q.leaseKey, q.leaseMember(id))
+		return nil
+	})
+	return err
+}
+
+// Nack returns an ID to the back of the queue.
+func (q *Queue) Nack(ctx context.Context, id string) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, q.processingKey, 1, id)
+		pipe.ZRem(ctx, q.leaseKey, q.leaseMember(id))
+		pipe.LPush(ctx, q.key, id)
+		return nil
+	})
+	return err
+}
+
+// Retry removes an in-flight ID and schedules it to rejoin the queue once
+// delay has passed. Delayed IDs are moved back by PromoteDelayed.
+func (q *Queue) Retry(ctx context.Context, id string, delay time.Duration) error {
+	readyAt := float64(time.Now().Add(delay).Unix())
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, q.processingKey, 1, id)
+		pipe.ZRem(ctx, q.leaseKey, q.leaseMember(id))
+		pipe.ZAdd(ctx, q.delayedKey, redis.Z{Score: readyAt, Member: id})
+		return nil
+	})
+	return err
+}
+
+// PromoteDelayed pushes delayed IDs whose retry time has passed onto the queue.
+func (q *Queue) PromoteDelayed(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := promoteScript.Run(ctx, q.client, []string{q.key, q.delayedKey}, time.Now().Unix(), 100).Int()
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < 100 {
+			return total, nil
+		}
+	}
+}
+
+// Reap returns IDs that have been in flight for longer than visibility, from
+// any worker, to the queue.
+func (q *Queue) Reap(ctx context.Context, visibility time.Duration) (int, error) {
+	now := time.Now()
+	cutoff := now.Add(-visibility)
+
+	total := 0
+	iter := q.client.Scan(ctx, 0, q.key+":processing:*", 100).Iterator()
+	for iter.Next(ctx) {
+		n, err := reapScript.Run(ctx, q.client, []string{q.key, q.leaseKey, iter.Val()}, now.Unix(), cutoff.Unix()).Int()
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+
+	return total, iter.Err()
+}
+
+func (q *Queue) Len(ctx context.Context) (int64, error) {
+	return q.client.LLen(ctx, q.key).Result()
+}
+
+// List returns up to limit queued IDs starting at offset, newest first.
+func (q *Queue) List(ctx context.Context, offset, limit int64) ([]string, error) {
+	return q.client.LRange(ctx, q.key, offset, offset+limit-1).Result()
+}
+
+func (q *Queue) Contains(ctx context.Context, id string) (bool, error) {
+	_, err := q.client.LPos(ctx, q.key, id, redis.LPosArgs{}).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Remove deletes every occurrence of id from the queue and reports whether
+// any were found.
+func (q *Queue) Remove(ctx context.Context, id string) (bool, error) {
+	n, err := q.client.LRem(ctx, q.key, 0, id).Result()
+	return n > 0, err
+}
+
+func (q *Queue) leaseMember(id string) string {
+	return q.processingKey + "|" + id
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-playground/assert/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	m := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestQueuePopAck(t *testing.T) {
+	ctx := context.Background()
+	client := newTestRedis(t)
+	q := NewQueue(client, "test:queue", "w1")
+
+	assert.Equal(t, nil, q.Push(ctx, "1"))
+	assert.Equal(t, nil, q.Push(ctx, "2"))
+
+	// First in, first out.
+	id, err := q.Pop(ctx, time.Second)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "1", id)
+
+	// The popped ID is in flight and leased until it is acked.
+	assert.Equal(t, []string{"1"}, client.LRange(ctx, "test:queue:processing:w1", 0, -1).Val())
+	assert.Equal(t, int64(1), client.ZCard(ctx, "test:queue:leases").Val())
+
+	assert.Equal(t, nil, q.Ack(ctx, id))
+	assert.Equal(t, int64(0), client.LLen(ctx, "test:queue:processing:w1").Val())
+	assert.Equal(t, int64(0), client.ZCard(ctx, "test:queue:leases").Val())
+
+	n, err := q.Len(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func TestQueuePopEmpty(t *testing.T) {
+	ctx := context.Background()
+	q := NewQueue(newTestRedis(t), "test:queue", "w1")
+
+	id, err := q.TryPop(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "", id)
+
+	id, err = q.Pop(ctx, 10*time.Millisecond)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "", id)
+}
+
+func TestQueueNack(t *testing.T) {
+	ctx := context.Background()
+	client := newTestRedis(t)
+	q := NewQueue(client, "test:queue", "w1")
+
+	q.Push(ctx, "1")
+	q.Push(ctx, "2")
+	id, _ := q.TryPop(ctx)
+	assert.Equal(t, nil, q.Nack(ctx, id))
+
+	// A nacked ID goes to the back of the queue.
+	assert.Equal(t, []string{"1", "2"}, client.LRange(ctx, "test:queue", 0, -1).Val())
+	assert.Equal(t, int64(0), client.LLen(ctx, "test:queue:processing:w1").Val())
+	assert.Equal(t, int64(0), client.ZCard(ctx, "test:queue:leases").Val())
+}
+
+func TestQueueRetryAndPromote(t *testing.T) {
+	ctx := context.Background()
+	client := newTestRedis(t)
+	q := NewQueue(client, "test:queue", "w1")
+
+	q.Push(ctx, "1")
+	q.Push(ctx, "2")
+	first, _ := q.TryPop(ctx)
+	second, _ := q.TryPop(ctx)
+	assert.Equal(t, nil, q.Retry(ctx, first, 0))
+	assert.Equal(t, nil, q.Retry(ctx, second, time.Hour))
+	assert.Equal(t, int64(0), client.LLen(ctx, "test:queue:processing:w1").Val())
+	assert.Equal(t, int64(0), client.ZCard(ctx, "test:queue:leases").Val())
+
+	// Only the ID whose delay has passed rejoins the queue.
+	n, err := q.PromoteDelayed(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"1"}, client.LRange(ctx, "test:queue", 0, -1).Val())
+	assert.Equal(t, []string{"2"}, client.ZRange(ctx, "test:queue:delayed", 0, -1).Val())
+}
+
+func TestQueueReap(t *testing.T) {
+	ctx := context.Background()
+	client := newTestRedis(t)
+	crashed := NewQueue(client, "test:queue", "w1")
+	other := NewQueue(client, "test:queue", "w2")
+
+	crashed.Push(ctx, "1")
+	crashed.Push(ctx, "2")
+	crashed.TryPop(ctx)
+	other.TryPop(ctx)
+
+	// Leases younger than the visibility timeout are left alone.
+	n, err := other.Reap(ctx, time.Hour)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, n)
+
+	// Expired leases of every worker are returned to the queue.
+	n, err = other.Reap(ctx, 0)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, int64(2), client.LLen(ctx, "test:queue").Val())
+	assert.Equal(t, int64(0), client.LLen(ctx, "test:queue:processing:w1").Val())
+	assert.Equal(t, int64(0), client.ZCard(ctx, "test:queue:leases").Val())
+}
+
+func TestQueueReapLeasesUnleasedIDs(t *testing.T) {
+	ctx := context.Background()
+	client := newTestRedis(t)
+	q := NewQueue(client, "test:queue", "w1")
+
+	// A worker that crashed between the move and the lease write.
+	client.LPush(ctx, "test:queue:processing:w1", "1")
+
+	n, err := q.Reap(ctx, 0)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, int64(1), client.ZCard(ctx, "test:queue:leases").Val())
+
+	n, err = q.Reap(ctx, 0)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"1"}, client.LRange(ctx, "test:queue", 0, -1).Val())
+}
+
+func TestQueueRemove(t *testing.T) {
+	ctx := context.Background()
+	q := NewQueue(newTestRedis(t), "test:queue", "w1")
+
+	q.Push(ctx, "1")
+	q.Push(ctx, "2")
+	q.Push(ctx, "1")
+
+	ok, err := q.Contains(ctx, "1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, ok)
+
+	removed, err := q.Remove(ctx, "1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, removed)
+
+	ok, _ = q.Contains(ctx, "1")
+	assert.Equal(t, false, ok)
+	ids, _ := q.List(ctx, 0, 10)
+	assert.Equal(t, []string{"2"}, ids)
+
+	removed, _ = q.Remove(ctx, "3")
+	assert.Equal(t, false, removed)
+}
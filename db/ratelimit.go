@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimiter counts requests in fixed windows in Redis, so every process
+// shares the same limits. Windows are aligned to the Unix epoch: a day's
+// window starts at midnight UTC.
+type RateLimiter struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRateLimiter(client *redis.Client, prefix string) *RateLimiter {
+	return &RateLimiter{client: client, prefix: prefix}
+}
+
+// Allow counts a request in the current window of key and reports whether it
+// is within limit, and otherwise how long until the window ends.
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	now := time.Now()
+	start := now.Truncate(window)
+	end := start.Add(window)
+	windowKey := l.prefix + ":" + key + ":" + strconv.FormatInt(start.Unix(), 10)
+
+	var count *redis.IntCmd
+	_, err := l.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		count = pipe.Incr(ctx, windowKey)
+		pipe.ExpireAt(ctx, windowKey, end.Add(time.Minute))
+		return nil
+	})
+	if err != nil {
+		return false, 0, err
+	}
+
+	if count.Val() > int64(limit) {
+		return false, end.Sub(now), nil
+	}
+	return true, 0, nil
+}
+
+// QuotaTracker remembers until when each source is out of quota, so every
+// process skips it until then.
+type QuotaTracker struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewQuotaTracker(client *redis.Client, prefix string) *QuotaTracker {
+	return &QuotaTracker{client: client, prefix: prefix}
+}
+
+// GetQuotaReset returns when source's quota resets, or the zero time when it
+// is not out of quota.
+func (q *QuotaTracker) GetQuotaReset(source string) (time.Time, error) {
+	unix, err := q.client.Get(Ctx, q.prefix+":"+source).Int64()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(unix, 0), nil
+}
+
+// SaveQuotaReset marks source out of quota until until.
+func (q *QuotaTracker) SaveQuotaReset(source string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+	return q.client.Set(Ctx, q.prefix+":"+source, until.Unix(), ttl).Err()
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -15,6 +14,12 @@ var Ctx = context.Background()
 const (
 	TransformQueueKey = "zennews:queue:transform"
 	DeadLetterKey     = "zennews:queue:failed"
+	RetransformKey    = "zennews:queue:retransform"
+	ResponseCacheKey  = "zennews:cache:transform"
+	FetchTriggerKey   = "zennews:queue:fetch"
+	FetchLeaderKey    = "zennews:lock:fetcher"
+	RateLimitKey      = "zennews:ratelimit"
+	QuotaKey          = "zennews:quota"
 )
 
 func ConnectRedis() error {
@@ -39,19 +44,3 @@ func CloseRedis() {
 		Redis.Close()
 	}
 }
-
-func PushToQueue(queueKey string, data string) error {
-	return Redis.LPush(Ctx, queueKey, data).Err()
-}
-
-func PopFromQueue(queueKey string, timeout time.Duration) (string, error) {
-	result, err := Redis.BRPop(Ctx, timeout, queueKey).Result()
-	if err != nil {
-		return "", err
-	}
-	return result[1], nil
-}
-
-func GetqueueLength(queueKey string) (int64, error) {
-	return Redis.LLen(Ctx, queueKey).Result()
-}
@@ -0,0 +1,103 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Metric is one report figure and which direction counts as better.
+type Metric struct {
+	Name           string
+	HigherIsBetter bool
+	Value          func(r *Report) float64
+}
+
+// Metrics are the figures a comparison gates on. Headline length is reported
+// but not gated, since shorter is not always better.
+var Metrics = []Metric{
+	{"error_rate", false, (*Report).ErrorRate},
+	{"banned_word_rate", false, func(r *Report) float64 { return r.BannedWordRate }},
+	{"fact_recall", true, func(r *Report) float64 { return r.FactRecall }},
+	{"category_accuracy", true, func(r *Report) float64 { return r.CategoryAccuracy }},
+	{"long_headline_rate", false, func(r *Report) float64 { return r.LongHeadlineRate }},
+}
+
+// Regression is a metric the candidate got worse at by more than the
+// tolerance.
+type Regression struct {
+	Metric    string
+	Base      float64
+	Candidate float64
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s regressed from %.3f to %.3f", r.Metric, r.Base, r.Candidate)
+}
+
+// Compare returns every metric where candidate is worse than base by more
+// than tolerance, an absolute difference in rate.
+func Compare(base, candidate *Report, tolerance float64) []Regression {
+	var regressions []Regression
+	for _, m := range Metrics {
+		b, c := m.Value(base), m.Value(candidate)
+		delta := c - b
+		if m.HigherIsBetter {
+			delta = -delta
+		}
+		if delta > tolerance {
+			regressions = append(regressions, Regression{Metric: m.Name, Base: b, Candidate: c})
+		}
+	}
+	return regressions
+}
+
+// WriteReport prints the reports side by side, one row per metric.
+func WriteReport(w io.Writer, reports ...*Report) {
+	fmt.Fprintf(w, "%-22s", "metric")
+	for _, r := range reports {
+		fmt.Fprintf(w, "%16s", r.Label)
+	}
+	fmt.Fprintln(w)
+
+	row := func(name string, value func(r *Report) float64) {
+		fmt.Fprintf(w, "%-22s", name)
+		for _, r := range reports {
+			fmt.Fprintf(w, "%16.3f", value(r))
+		}
+		fmt.Fprintln(w)
+	}
+
+	for _, m := range Metrics {
+		row(m.Name, m.Value)
+	}
+	row("avg_headline_length", func(r *Report) float64 { return r.AvgHeadlineLength })
+}
+
+// WriteFailures lists the cases of a report that failed or lost points.
+func WriteFailures(w io.Writer, r *Report) {
+	for _, res := range r.Results {
+		var problems []string
+		switch {
+		case res.Err != nil:
+			problems = append(problems, "error: "+res.Err.Error())
+		default:
+			if len(res.Score.BannedWords) > 0 {
+				problems = append(problems, "banned words: "+strings.Join(res.Score.BannedWords, ", "))
+			}
+			if len(res.Score.MissingFacts) > 0 {
+				problems = append(problems, "missing facts: "+strings.Join(res.Score.MissingFacts, ", "))
+			}
+			if !res.Score.CategoryCorrect {
+				problems = append(problems, fmt.Sprintf("category %q, want %q", res.Output.Category, res.Case.Category))
+			}
+			if res.Score.HeadlineLength > MaxHeadlineLength {
+				problems = append(problems, fmt.Sprintf("headline is %d characters", res.Score.HeadlineLength))
+			}
+		}
+
+		if len(problems) > 0 {
+			fmt.Fprintf(w, "[%s] %s: %s\n", r.Label, res.Case.ID, strings.Join(problems, "; "))
+		}
+	}
+}
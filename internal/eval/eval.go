@@ -0,0 +1,130 @@
+// Package eval scores transform output against a golden dataset of
+// headlines so prompt and model changes can be compared offline.
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"zennews/pkg/llm"
+)
+
+// Case is one golden article. Category is the expected label and Tickers
+// the symbols that must survive the rewrite when they appear in the text.
+type Case struct {
+	ID       string   `json:"id"`
+	Headline string   `json:"headline"`
+	Detail   string   `json:"detail"`
+	Category string   `json:"category"`
+	Tickers  []string `json:"tickers"`
+}
+
+// LoadCases reads one JSON case per line, skipping blank lines.
+func LoadCases(path string) ([]Case, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var cases []Case
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var c Case
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if c.ID == "" || c.Headline == "" {
+			return nil, fmt.Errorf("%s:%d: id and headline are required", path, line)
+		}
+		cases = append(cases, c)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return cases, nil
+}
+
+// Result is the outcome of one case. Err is set when Transform failed, in
+// which case Output and Score are empty.
+type Result struct {
+	Case   Case
+	Output *llm.TransformResult
+	Score  Score
+	Err    error
+}
+
+// Report aggregates a run over every case. Rates are fractions of the cases
+// that transformed successfully.
+type Report struct {
+	Label             string
+	Results           []Result
+	Errors            int
+	BannedWordRate    float64
+	FactRecall        float64
+	CategoryAccuracy  float64
+	AvgHeadlineLength float64
+	LongHeadlineRate  float64
+}
+
+// Run transforms every case with client and scores the output.
+func Run(label string, client llm.LLMClient, cases []Case) *Report {
+	report := &Report{Label: label}
+
+	var scored, banned, facts, kept, labeled, correct, long, length int
+	for _, c := range cases {
+		output, err := client.Transform(llm.TransformInput{Headline: c.Headline, Detail: c.Detail})
+		if err != nil {
+			report.Errors++
+			report.Results = append(report.Results, Result{Case: c, Err: err})
+			continue
+		}
+
+		s := ScoreResult(c, output)
+		report.Results = append(report.Results, Result{Case: c, Output: output, Score: s})
+
+		scored++
+		if len(s.BannedWords) > 0 {
+			banned++
+		}
+		facts += s.Facts
+		kept += s.Facts - len(s.MissingFacts)
+		if c.Category != "" {
+			labeled++
+			if s.CategoryCorrect {
+				correct++
+			}
+		}
+		length += s.HeadlineLength
+		if s.HeadlineLength > MaxHeadlineLength {
+			long++
+		}
+	}
+
+	report.BannedWordRate = ratio(banned, scored)
+	report.FactRecall = ratio(kept, facts)
+	report.CategoryAccuracy = ratio(correct, labeled)
+	report.AvgHeadlineLength = ratio(length, scored)
+	report.LongHeadlineRate = ratio(long, scored)
+
+	return report
+}
+
+// ErrorRate is the fraction of cases whose Transform failed.
+func (r *Report) ErrorRate() float64 {
+	return ratio(r.Errors, len(r.Results))
+}
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
@@ -0,0 +1,96 @@
+package eval
+
+import (
+	"errors"
+	"testing"
+	"zennews/pkg/llm"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// echoClient returns the original text unchanged, like a prompt that stopped
+// neutralizing anything.
+type echoClient struct{}
+
+func (echoClient) Transform(input llm.TransformInput) (*llm.TransformResult, error) {
+	if input.Headline == "" {
+		return nil, errors.New("empty headline")
+	}
+	return &llm.TransformResult{Headline: input.Headline, Detail: input.Detail, Category: "Company News"}, nil
+}
+
+func (c echoClient) TransformBatch(inputs []llm.TransformInput) ([]*llm.TransformResult, []error) {
+	results := make([]*llm.TransformResult, len(inputs))
+	errs := make([]error, len(inputs))
+	for i, input := range inputs {
+		results[i], errs[i] = c.Transform(input)
+	}
+	return results, errs
+}
+
+func TestScoreResult(t *testing.T) {
+	c := Case{
+		ID:       "apple",
+		Headline: "BREAKING: Apple stock SOARS 5%",
+		Detail:   "Apple (AAPL) rose 5% to $190.",
+		Category: "Market Movement",
+		Tickers:  []string{"AAPL"},
+	}
+
+	s := ScoreResult(c, &llm.TransformResult{
+		Headline: "Apple shares rose 5%",
+		Detail:   "Apple (AAPL) rose 5% to $190.",
+		Category: "Market Movement",
+	})
+	assert.Equal(t, 0, len(s.BannedWords))
+	assert.Equal(t, 3, s.Facts)
+	assert.Equal(t, 0, len(s.MissingFacts))
+	assert.Equal(t, true, s.CategoryCorrect)
+	assert.Equal(t, 20, s.HeadlineLength)
+
+	s = ScoreResult(c, &llm.TransformResult{
+		Headline: "Apple stock soars in CRAZY rally",
+		Detail:   "Apple rose to $190.",
+		Category: "Earnings",
+	})
+	assert.Equal(t, []string{"soars", "crazy"}, s.BannedWords)
+	assert.Equal(t, []string{"5%", "AAPL"}, s.MissingFacts)
+	assert.Equal(t, false, s.CategoryCorrect)
+}
+
+func TestShouting(t *testing.T) {
+	tickers := []string{"NVDA"}
+	for text, want := range map[string]bool{
+		"NVIDIA shares rose on NASDAQ":         false,
+		"NVDA and AMD rose as the CEO spoke":   false,
+		"Chipmaker NVIDIA STOCK SURGES":        true,
+		"Apple stock ROSE SHARPLY on earnings": true,
+		"Fed holds rates at 5.25%":             false,
+	} {
+		assert.Equal(t, want, shouting(text, tickers))
+	}
+}
+
+func TestRunGoldenDataset(t *testing.T) {
+	cases, err := LoadCases("../../eval/golden.jsonl")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, len(cases) >= 20)
+
+	base := Run("fake", &llm.FakeClient{}, cases)
+	assert.Equal(t, 0, base.Errors)
+	assert.Equal(t, 0.0, base.BannedWordRate)
+	assert.Equal(t, 1.0, base.FactRecall)
+
+	candidate := Run("echo", echoClient{}, cases)
+	assert.Equal(t, true, candidate.BannedWordRate > 0.5)
+	assert.Equal(t, 1.0, candidate.FactRecall)
+
+	regressions := Compare(base, candidate, 0.02)
+	names := make([]string, len(regressions))
+	for i, r := range regressions {
+		names[i] = r.Metric
+	}
+	assert.Equal(t, []string{"banned_word_rate", "category_accuracy"}, names)
+
+	assert.Equal(t, 0, len(Compare(base, base, 0)))
+}
@@ -0,0 +1,89 @@
+package eval
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"zennews/internal/facts"
+	"zennews/pkg/llm"
+)
+
+// MaxHeadlineLength is the longest headline that still scores as concise.
+const MaxHeadlineLength = 100
+
+// bannedWords are the urgency, emotional and judgmental words the transform
+// prompt tells the model to remove, as whole words in any inflection.
+var bannedWords = regexp.MustCompile(`(?i)\b(breaking|alert|just in|crash(es|ed|ing)?|plummet(s|ed|ing)?|tank(s|ed|ing)?|explode[sd]?|exploding|soar(s|ed|ing)?|skyrocket(s|ed|ing)?|smart|dumb|crazy|shocking|terrifying|bloodbath|shockwave|chaos)\b`)
+
+var words = regexp.MustCompile(`[A-Za-z&]+`)
+
+// acronyms are names and abbreviations written in capitals that do not count
+// as shouting, besides the case's tickers.
+var acronyms = []string{
+	"AI", "AMD", "CEO", "CFO", "CPI", "ECB", "EPS", "ETF", "EU", "FOMC", "GDP", "IBM", "IMF", "IPO",
+	"M&A", "NASDAQ", "NVIDIA", "NYSE", "OPEC", "PCE", "PPI", "S&P", "SEC", "UK", "US", "USA",
+}
+
+// Score is how one transform result did against its case.
+type Score struct {
+	BannedWords     []string
+	Facts           int
+	MissingFacts    []string
+	CategoryCorrect bool
+	HeadlineLength  int
+}
+
+// ScoreResult checks result against c: banned words left in the rewrite,
+// facts from the original that the rewrite dropped or altered, category
+// agreement with the label and headline length.
+func ScoreResult(c Case, result *llm.TransformResult) Score {
+	output := result.Headline + "\n" + result.Detail
+
+	s := Score{
+		BannedWords:     bannedIn(output, c.Tickers),
+		CategoryCorrect: c.Category == "" || result.Category == c.Category,
+		HeadlineLength:  len([]rune(result.Headline)),
+	}
+
+	check := facts.Compare(c.Headline+"\n"+c.Detail, output, c.Tickers)
+	s.Facts = len(check.Facts)
+	s.MissingFacts = facts.Texts(check.Missing)
+
+	return s
+}
+
+func bannedIn(text string, tickers []string) []string {
+	var found []string
+	for _, m := range bannedWords.FindAllString(text, -1) {
+		m = strings.ToLower(m)
+		if !slices.Contains(found, m) {
+			found = append(found, m)
+		}
+	}
+
+	if shouting(text, tickers) {
+		found = append(found, "ALL CAPS")
+	}
+	return found
+}
+
+// shouting reports whether text has two or more all-caps words in a row.
+// Tickers and known acronyms are skipped, so "NVIDIA CEO" is not shouting but
+// "NVIDIA STOCK SURGES" is.
+func shouting(text string, tickers []string) bool {
+	run := 0
+	for _, w := range words.FindAllString(text, -1) {
+		if slices.Contains(tickers, w) || slices.Contains(acronyms, w) {
+			continue
+		}
+		if len(w) > 1 && strings.ToUpper(w) == w {
+			run++
+			if run >= 2 {
+				return true
+			}
+		} else {
+			run = 0
+		}
+	}
+	return false
+}
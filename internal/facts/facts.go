@@ -0,0 +1,197 @@
+// Package facts extracts the checkable facts of a news text, numbers,
+// percentages, currency amounts, dates and tickers, so a rewrite can be
+// compared against the original.
+package facts
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type Kind string
+
+const (
+	Number   Kind = "number"
+	Percent  Kind = "percent"
+	Currency Kind = "currency"
+	Date     Kind = "date"
+	Ticker   Kind = "ticker"
+)
+
+// Fact is one fact found in a text. Value is normalized so that different
+// spellings of the same fact compare equal, e.g. "$1,200 million" and
+// "1200M"; Text is the spelling that was found.
+type Fact struct {
+	Kind  Kind
+	Value string
+	Text  string
+}
+
+// dates matches month-day dates ("Mar. 3", "March 3rd"), ISO dates and
+// fiscal quarters. A year after a month-day date is left to numbers.
+var dates = regexp.MustCompile(`\b(January|February|March|April|May|June|July|August|September|October|November|December|Jan|Feb|Mar|Apr|Jun|Jul|Aug|Sep|Sept|Oct|Nov|Dec)\.?\s+(\d{1,2})(st|nd|rd|th)?\b|\b\d{4}-\d{2}-\d{2}\b|\bQ[1-4]\b`)
+
+// numbers matches figures with an optional currency sign, thousands
+// separators, decimals and a percent or scale suffix, e.g. $124.3 billion,
+// 2,031,220, 3.1% and 5M.
+var numbers = regexp.MustCompile(`([$€£])?(\d{1,3}(?:,\d{3})+|\d+)(\.\d+)?(\s*%|\s+percent\b|\s+per cent\b|\s+(?:trillion|billion|million|thousand)\b|\s?(?:tn|bn|mn)\b|[kKMBT]\b)?`)
+
+// tickers matches symbols written with an exchange prefix or as cashtags,
+// e.g. NASDAQ:AAPL and $TSLA.
+var tickers = regexp.MustCompile(`\b(?:NYSE|NASDAQ|Nasdaq|AMEX|NYSEARCA)\s*:\s*([A-Z]{1,5}(?:\.[A-Z])?)\b|\$([A-Z]{1,5})\b`)
+
+var months = map[string]string{
+	"jan": "01", "feb": "02", "mar": "03", "apr": "04", "may": "05", "jun": "06",
+	"jul": "07", "aug": "08", "sep": "09", "oct": "10", "nov": "11", "dec": "12",
+}
+
+var scales = map[string]string{
+	"trillion": "t", "tn": "t", "t": "t",
+	"billion": "b", "bn": "b", "b": "b",
+	"million": "m", "mn": "m", "m": "m",
+	"thousand": "k", "k": "k",
+}
+
+// Extract returns the facts in text, each value once and in order of kind.
+// symbols are tickers known to belong to the article; they count as facts
+// only when they appear in text.
+func Extract(text string, symbols []string) []Fact {
+	var found []Fact
+	add := func(f Fact) {
+		for _, existing := range found {
+			if existing.Value == f.Value {
+				return
+			}
+		}
+		found = append(found, f)
+	}
+
+	// Dates are blanked out first so their day is not also read as a number.
+	text = dates.ReplaceAllStringFunc(text, func(m string) string {
+		add(Fact{Kind: Date, Value: normalizeDate(m), Text: m})
+		return strings.Repeat(" ", len(m))
+	})
+
+	for _, m := range numbers.FindAllStringSubmatch(text, -1) {
+		add(normalizeNumber(m))
+	}
+
+	for _, m := range tickers.FindAllStringSubmatch(text, -1) {
+		symbol := m[1] + m[2]
+		add(Fact{Kind: Ticker, Value: symbol, Text: symbol})
+	}
+	for _, s := range symbols {
+		if containsWord(text, s) {
+			add(Fact{Kind: Ticker, Value: s, Text: s})
+		}
+	}
+
+	return found
+}
+
+// Result is the comparison of a rewrite against its original. Missing facts
+// were dropped or altered by the rewrite; Added facts appear only in the
+// rewrite.
+type Result struct {
+	Facts   []Fact
+	Missing []Fact
+	Added   []Fact
+}
+
+// OK reports whether the rewrite kept every fact of the original.
+func (r Result) OK() bool {
+	return len(r.Missing) == 0
+}
+
+// Compare extracts the facts of original and rewritten and reports which
+// ones the rewrite lost or introduced.
+func Compare(original, rewritten string, symbols []string) Result {
+	before := Extract(original, symbols)
+
+	// A ticker found as NASDAQ:AAPL is kept by a rewrite that says (AAPL).
+	known := slices.Clone(symbols)
+	for _, f := range before {
+		if f.Kind == Ticker {
+			known = append(known, f.Value)
+		}
+	}
+	after := Extract(rewritten, known)
+
+	r := Result{Facts: before}
+	for _, f := range before {
+		if !contains(after, f) {
+			r.Missing = append(r.Missing, f)
+		}
+	}
+	for _, f := range after {
+		if !contains(before, f) {
+			r.Added = append(r.Added, f)
+		}
+	}
+	return r
+}
+
+// Texts returns the spelling of each fact.
+func Texts(facts []Fact) []string {
+	texts := make([]string, len(facts))
+	for i, f := range facts {
+		texts[i] = f.Text
+	}
+	return texts
+}
+
+// contains matches on value alone, so a currency amount rewritten without
+// its sign still counts as kept.
+func contains(facts []Fact, f Fact) bool {
+	for _, other := range facts {
+		if other.Value == f.Value {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeDate(m string) string {
+	if strings.HasPrefix(m, "Q") || m[0] >= '0' && m[0] <= '9' {
+		return m
+	}
+	parts := dates.FindStringSubmatch(m)
+	day := parts[2]
+	if len(day) == 1 {
+		day = "0" + day
+	}
+	return months[strings.ToLower(parts[1][:3])] + "-" + day
+}
+
+// normalizeNumber turns a numbers match into a fact, dropping the currency
+// sign and separators and trailing decimal zeros, and folding scale words
+// into a one-letter suffix.
+func normalizeNumber(m []string) Fact {
+	sign, integer, decimals, suffix := m[1], m[2], m[3], strings.TrimSpace(m[4])
+
+	value := strings.ReplaceAll(integer, ",", "")
+	decimals = strings.TrimRight(decimals, "0")
+	if decimals != "." {
+		value += decimals
+	}
+
+	f := Fact{Kind: Number, Text: strings.TrimSpace(m[0])}
+	switch {
+	case suffix == "%" || strings.HasPrefix(suffix, "per"):
+		f.Kind = Percent
+		value += "%"
+	case suffix != "":
+		value += scales[strings.ToLower(suffix)]
+	}
+	if sign != "" && f.Kind == Number {
+		f.Kind = Currency
+	}
+
+	f.Value = value
+	return f
+}
+
+func containsWord(text, word string) bool {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(word) + `\b`).MatchString(text)
+}
@@ -0,0 +1,72 @@
+package facts
+
+import (
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestExtract(t *testing.T) {
+	text := "Apple (AAPL) reported revenue of $124.3 billion for the quarter ended Dec. 28, 2025, " +
+		"up 4% on 2,031,220 units. Shares of NASDAQ:MSFT rose 1.50 percent in Q3."
+
+	var got []string
+	for _, f := range Extract(text, []string{"AAPL", "GOOG"}) {
+		got = append(got, string(f.Kind)+":"+f.Value)
+	}
+
+	assert.Equal(t, []string{
+		"date:12-28",
+		"date:Q3",
+		"currency:124.3b",
+		"number:2025",
+		"percent:4%",
+		"number:2031220",
+		"percent:1.5%",
+		"ticker:MSFT",
+		"ticker:AAPL",
+	}, got)
+}
+
+func TestCompare(t *testing.T) {
+	original := "Tesla ($TSLA) fell 12% to $180 on March 3 after deliveries of 386,810 vehicles."
+
+	tests := []struct {
+		name      string
+		rewritten string
+		missing   []string
+		added     []string
+	}{
+		{
+			name:      "rephrased",
+			rewritten: "Tesla (TSLA) shares declined 12 percent to 180 dollars on Mar 3 after it delivered 386810 vehicles.",
+		},
+		{
+			name:      "dropped",
+			rewritten: "Tesla shares declined 12% after weaker deliveries.",
+			missing:   []string{"March 3", "$180", "386,810", "TSLA"},
+		},
+		{
+			name:      "altered",
+			rewritten: "Tesla ($TSLA) fell 21% to $180 on March 3 after deliveries of 386,810 vehicles.",
+			missing:   []string{"12%"},
+			added:     []string{"21%"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Compare(original, tt.rewritten, nil)
+			assert.Equal(t, 5, len(r.Facts))
+			assert.Equal(t, len(tt.missing) == 0, r.OK())
+			assert.Equal(t, len(tt.missing), len(r.Missing))
+			assert.Equal(t, len(tt.added), len(r.Added))
+			if len(tt.missing) > 0 {
+				assert.Equal(t, tt.missing, Texts(r.Missing))
+			}
+			if len(tt.added) > 0 {
+				assert.Equal(t, tt.added, Texts(r.Added))
+			}
+		})
+	}
+}
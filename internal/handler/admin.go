@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdminToken guards admin routes with a static bearer token. An empty
+// token disables the admin API entirely.
+func RequireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin API disabled"})
+			return
+		}
+
+		got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireService answers 503 for routes that depend on a service, such as
+// Redis, while check fails, so the rest of the API keeps serving.
+func RequireService(name string, check func(ctx context.Context) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := check(c.Request.Context()); err != nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": name + " unavailable"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"zennews/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeadLetterStore interface {
+	GetOriginalByID(id int64) (*model.OriginalArticle, error)
+	GetErrors(articleID int64) ([]model.ProcessingError, error)
+	GetLastErrors(ids []int64) (map[int64]model.ProcessingError, error)
+	ResetForRetry(articleID int64) error
+}
+
+type DeadLetterQueue interface {
+	List(ctx context.Context, offset, limit int64) ([]string, error)
+	Len(ctx context.Context) (int64, error)
+	Contains(ctx context.Context, id string) (bool, error)
+	Remove(ctx context.Context, id string) (bool, error)
+}
+
+type TransformQueue interface {
+	Push(ctx context.Context, id string) error
+}
+
+type DeadLetterHandler struct {
+	repository  DeadLetterStore
+	deadLetters DeadLetterQueue
+	transform   TransformQueue
+}
+
+func NewDeadLetterHandler(repository DeadLetterStore, deadLetters DeadLetterQueue, transform TransformQueue) *DeadLetterHandler {
+	return &DeadLetterHandler{repository: repository, deadLetters: deadLetters, transform: transform}
+}
+
+type ProcessingErrorResponse struct {
+	Message      string `json:"message"`
+	Type         string `json:"type"`
+	AttemptCount int    `json:"attempt_count"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type DeadLetterResponse struct {
+	ArticleID int64                    `json:"article_id"`
+	Headline  string                   `json:"headline"`
+	Source    string                   `json:"source"`
+	URL       string                   `json:"url"`
+	Status    string                   `json:"status"`
+	LastError *ProcessingErrorResponse `json:"last_error"`
+}
+
+type DeadLetterListResponse struct {
+	Items  []DeadLetterResponse `json:"items"`
+	Total  int64                `json:"total"`
+	Limit  int                  `json:"limit"`
+	Offset int                  `json:"offset"`
+}
+
+type DeadLetterDetailResponse struct {
+	DeadLetterResponse
+	Detail string                    `json:"detail"`
+	Errors []ProcessingErrorResponse `json:"errors"`
+}
+
+func toProcessingErrorResponse(e model.ProcessingError) ProcessingErrorResponse {
+	return ProcessingErrorResponse{
+		Message:      e.ErrorMessage,
+		Type:         e.ErrorType,
+		AttemptCount: e.AttemptCount,
+		CreatedAt:    e.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (h *DeadLetterHandler) ListDeadLetters(c *gin.Context) {
+	limit := getQueryLimit(c)
+	offset := getQueryOffset(c)
+	ctx := c.Request.Context()
+
+	total, err := h.deadLetters.Len(ctx)
+	if err != nil {
+		slog.Error("error fetching dead-letter queue length", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Queue error"})
+		return
+	}
+
+	entries, err := h.deadLetters.List(ctx, int64(offset), int64(limit))
+	if err != nil {
+		slog.Error("error listing dead-letter queue", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Queue error"})
+		return
+	}
+
+	ids := make([]int64, 0, len(entries))
+	for _, e := range entries {
+		id, err := strconv.ParseInt(e, 10, 64)
+		if err != nil {
+			slog.Warn("invalid article id in dead-letter queue", "id", e, "error", err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	lastErrors, err := h.repository.GetLastErrors(ids)
+	if err != nil {
+		slog.Error("error fetching last processing errors", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	items := make([]DeadLetterResponse, 0, len(ids))
+	for _, id := range ids {
+		article, err := h.repository.GetOriginalByID(id)
+		if err != nil {
+			slog.Error("error fetching dead-lettered article", "error", err, "article_id", id)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			return
+		}
+
+		item := DeadLetterResponse{ArticleID: id}
+		if article != nil {
+			item.Headline = article.Headline
+			item.Source = article.Source
+			item.URL = article.URL
+			item.Status = article.Status
+		}
+		if e, ok := lastErrors[id]; ok {
+			lastErr := toProcessingErrorResponse(e)
+			item.LastError = &lastErr
+		}
+
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, DeadLetterListResponse{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+func (h *DeadLetterHandler) GetDeadLetter(c *gin.Context) {
+	articleID, ok := h.findDeadLetter(c)
+	if !ok {
+		return
+	}
+
+	article, err := h.repository.GetOriginalByID(articleID)
+	if err != nil {
+		slog.Error("error fetching dead-lettered article", "error", err, "article_id", articleID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if article == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		return
+	}
+
+	errs, err := h.repository.GetErrors(articleID)
+	if err != nil {
+		slog.Error("error fetching processing errors", "error", err, "article_id", articleID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	res := DeadLetterDetailResponse{
+		DeadLetterResponse: DeadLetterResponse{
+			ArticleID: article.ID,
+			Headline:  article.Headline,
+			Source:    article.Source,
+			URL:       article.URL,
+			Status:    article.Status,
+		},
+		Detail: article.Detail,
+		Errors: make([]ProcessingErrorResponse, 0, len(errs)),
+	}
+
+	for _, e := range errs {
+		res.Errors = append(res.Errors, toProcessingErrorResponse(e))
+	}
+
+	if len(res.Errors) > 0 {
+		res.LastError = &res.Errors[0]
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *DeadLetterHandler) RequeueDeadLetter(c *gin.Context) {
+	articleID, ok := h.findDeadLetter(c)
+	if !ok {
+		return
+	}
+
+	err := h.repository.ResetForRetry(articleID)
+	if err != nil {
+		slog.Error("error resetting dead-lettered article", "error", err, "article_id", articleID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	id := strconv.FormatInt(articleID, 10)
+	ctx := c.Request.Context()
+
+	// The article is pending again at this point, so a failed push is still
+	// recovered by the fetcher's reconciliation mode.
+	err = h.transform.Push(ctx, id)
+	if err != nil {
+		slog.Error("error requeueing dead-lettered article", "error", err, "article_id", articleID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Queue error"})
+		return
+	}
+
+	_, err = h.deadLetters.Remove(ctx, id)
+	if err != nil {
+		slog.Error("error removing article from dead-letter queue", "error", err, "article_id", articleID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Queue error"})
+		return
+	}
+
+	slog.Info("dead-lettered article requeued", "article_id", articleID)
+	c.JSON(http.StatusOK, gin.H{"article_id": articleID, "status": model.StatusPending})
+}
+
+func (h *DeadLetterHandler) DiscardDeadLetter(c *gin.Context) {
+	articleID, ok := h.findDeadLetter(c)
+	if !ok {
+		return
+	}
+
+	_, err := h.deadLetters.Remove(c.Request.Context(), strconv.FormatInt(articleID, 10))
+	if err != nil {
+		slog.Error("error removing article from dead-letter queue", "error", err, "article_id", articleID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Queue error"})
+		return
+	}
+
+	slog.Info("dead-lettered article discarded", "article_id", articleID)
+	c.Status(http.StatusNoContent)
+}
+
+// findDeadLetter parses the :id param and checks that it is on the dead-letter
+// queue, writing the error response itself when it is not.
+func (h *DeadLetterHandler) findDeadLetter(c *gin.Context) (int64, bool) {
+	id := c.Param("id")
+
+	articleID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		slog.Error("invalid article id", "id", id, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid article id"})
+		return 0, false
+	}
+
+	found, err := h.deadLetters.Contains(c.Request.Context(), strconv.FormatInt(articleID, 10))
+	if err != nil {
+		slog.Error("error checking dead-letter queue", "error", err, "article_id", articleID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Queue error"})
+		return 0, false
+	}
+
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not in dead-letter queue"})
+		return 0, false
+	}
+
+	return articleID, true
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"zennews/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+type fakeDeadLetterStore struct {
+	articles map[int64]*model.OriginalArticle
+	errs     map[int64][]model.ProcessingError
+	resetIDs []int64
+	err      error
+}
+
+func (f *fakeDeadLetterStore) GetOriginalByID(id int64) (*model.OriginalArticle, error) {
+	return f.articles[id], f.err
+}
+
+func (f *fakeDeadLetterStore) GetErrors(articleID int64) ([]model.ProcessingError, error) {
+	return f.errs[articleID], f.err
+}
+
+func (f *fakeDeadLetterStore) GetLastErrors(ids []int64) (map[int64]model.ProcessingError, error) {
+	result := make(map[int64]model.ProcessingError)
+	for _, id := range ids {
+		if errs := f.errs[id]; len(errs) > 0 {
+			result[id] = errs[0]
+		}
+	}
+	return result, f.err
+}
+
+func (f *fakeDeadLetterStore) ResetForRetry(articleID int64) error {
+	f.resetIDs = append(f.resetIDs, articleID)
+	return f.err
+}
+
+type fakeQueue struct {
+	ids    []string
+	pushed []string
+	err    error
+}
+
+func (f *fakeQueue) List(ctx context.Context, offset, limit int64) ([]string, error) {
+	if offset >= int64(len(f.ids)) {
+		return nil, f.err
+	}
+	end := offset + limit
+	if end > int64(len(f.ids)) {
+		end = int64(len(f.ids))
+	}
+	return f.ids[offset:end], f.err
+}
+
+func (f *fakeQueue) Len(ctx context.Context) (int64, error) {
+	return int64(len(f.ids)), f.err
+}
+
+func (f *fakeQueue) Contains(ctx context.Context, id string) (bool, error) {
+	for _, i := range f.ids {
+		if i == id {
+			return true, f.err
+		}
+	}
+	return false, f.err
+}
+
+func (f *fakeQueue) Remove(ctx context.Context, id string) (bool, error) {
+	var kept []string
+	for _, i := range f.ids {
+		if i != id {
+			kept = append(kept, i)
+		}
+	}
+	removed := len(kept) != len(f.ids)
+	f.ids = kept
+	return removed, f.err
+}
+
+func (f *fakeQueue) Push(ctx context.Context, id string) error {
+	f.pushed = append(f.pushed, id)
+	return f.err
+}
+
+func newTestDeadLetterRouter(store DeadLetterStore, deadLetters *fakeQueue, transform *fakeQueue) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h := NewDeadLetterHandler(store, deadLetters, transform)
+	admin := r.Group("/admin", RequireAdminToken("secret"))
+	admin.GET("/dead-letters", h.ListDeadLetters)
+	admin.GET("/dead-letters/:id", h.GetDeadLetter)
+	admin.POST("/dead-letters/:id/requeue", h.RequeueDeadLetter)
+	admin.DELETE("/dead-letters/:id", h.DiscardDeadLetter)
+	return r
+}
+
+func newAdminRequest(method, path string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	return req
+}
+
+func newDeadLetterFixture() *fakeDeadLetterStore {
+	return &fakeDeadLetterStore{
+		articles: map[int64]*model.OriginalArticle{
+			7: {ID: 7, Headline: "Stocks CRASH", Source: "FinnHub", Status: model.StatusFailed},
+		},
+		errs: map[int64][]model.ProcessingError{
+			7: {
+				{ArticleId: 7, ErrorMessage: "rate limited", ErrorType: "llm_error", AttemptCount: 3, CreatedAt: time.Now()},
+				{ArticleId: 7, ErrorMessage: "timeout", ErrorType: "llm_error", AttemptCount: 2, CreatedAt: time.Now().Add(-time.Minute)},
+			},
+		},
+	}
+}
+
+func TestListDeadLetters_ReturnsLastError(t *testing.T) {
+	store := newDeadLetterFixture()
+	r := newTestDeadLetterRouter(store, &fakeQueue{ids: []string{"7"}}, &fakeQueue{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest("GET", "/admin/dead-letters"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res DeadLetterListResponse
+	json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Equal(t, int64(1), res.Total)
+	assert.Equal(t, 1, len(res.Items))
+	assert.Equal(t, "Stocks CRASH", res.Items[0].Headline)
+	assert.Equal(t, "rate limited", res.Items[0].LastError.Message)
+}
+
+func TestListDeadLetters_QueueError(t *testing.T) {
+	store := newDeadLetterFixture()
+	r := newTestDeadLetterRouter(store, &fakeQueue{err: errors.New("redis down")}, &fakeQueue{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest("GET", "/admin/dead-letters"))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestGetDeadLetter_ReturnsErrorHistory(t *testing.T) {
+	store := newDeadLetterFixture()
+	r := newTestDeadLetterRouter(store, &fakeQueue{ids: []string{"7"}}, &fakeQueue{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest("GET", "/admin/dead-letters/7"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res DeadLetterDetailResponse
+	json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Equal(t, 2, len(res.Errors))
+	assert.Equal(t, "rate limited", res.LastError.Message)
+}
+
+func TestGetDeadLetter_NotInQueue(t *testing.T) {
+	store := newDeadLetterFixture()
+	r := newTestDeadLetterRouter(store, &fakeQueue{}, &fakeQueue{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest("GET", "/admin/dead-letters/7"))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRequeueDeadLetter(t *testing.T) {
+	store := newDeadLetterFixture()
+	deadLetters := &fakeQueue{ids: []string{"7"}}
+	transform := &fakeQueue{}
+	r := newTestDeadLetterRouter(store, deadLetters, transform)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest("POST", "/admin/dead-letters/7/requeue"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []int64{7}, store.resetIDs)
+	assert.Equal(t, []string{"7"}, transform.pushed)
+	assert.Equal(t, 0, len(deadLetters.ids))
+}
+
+func TestDiscardDeadLetter(t *testing.T) {
+	store := newDeadLetterFixture()
+	deadLetters := &fakeQueue{ids: []string{"7", "8"}}
+	transform := &fakeQueue{}
+	r := newTestDeadLetterRouter(store, deadLetters, transform)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest("DELETE", "/admin/dead-letters/7"))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, []string{"8"}, deadLetters.ids)
+	assert.Equal(t, 0, len(transform.pushed))
+	assert.Equal(t, 0, len(store.resetIDs))
+}
+
+func TestRequireAdminToken(t *testing.T) {
+	store := newDeadLetterFixture()
+	r := newTestDeadLetterRouter(store, &fakeQueue{}, &fakeQueue{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/dead-letters", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	gin.SetMode(gin.TestMode)
+	disabled := gin.New()
+	disabled.GET("/admin/dead-letters", RequireAdminToken(""), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w = httptest.NewRecorder()
+	disabled.ServeHTTP(w, newAdminRequest("GET", "/admin/dead-letters"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRequireService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/down", RequireService("Redis", func(ctx context.Context) error { return errors.New("connection refused") }),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/up", RequireService("Redis", func(ctx context.Context) error { return nil }),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/down", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/up", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
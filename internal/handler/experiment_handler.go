@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"zennews/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExperimentStore interface {
+	GetArmStats(experiment string) ([]model.ArmStats, error)
+}
+
+type ExperimentHandler struct {
+	repository ExperimentStore
+}
+
+func NewExperimentHandler(repository ExperimentStore) *ExperimentHandler {
+	return &ExperimentHandler{repository: repository}
+}
+
+type ArmStatsResponse struct {
+	Arm             string         `json:"arm"`
+	Transformed     int            `json:"transformed"`
+	Failed          int            `json:"failed"`
+	FailureRate     float64        `json:"failure_rate"`
+	AvgLatencyMs    float64        `json:"avg_latency_ms"`
+	P95LatencyMs    float64        `json:"p95_latency_ms"`
+	AvgInputTokens  float64        `json:"avg_input_tokens"`
+	AvgOutputTokens float64        `json:"avg_output_tokens"`
+	AvgSentiment    float64        `json:"avg_sentiment_score"`
+	SentimentShift  float64        `json:"sentiment_shift"`
+	Categories      map[string]int `json:"categories"`
+}
+
+type ExperimentStatsResponse struct {
+	Experiment string             `json:"experiment"`
+	Baseline   string             `json:"baseline"`
+	Arms       []ArmStatsResponse `json:"arms"`
+}
+
+// GetExperimentStats reports per-arm results. failure_rate is failed attempts
+// over all attempts, and sentiment_shift is the arm's average sentiment_score
+// minus the baseline arm's, which is ?baseline=, else "control", else the
+// first arm by name.
+func (h *ExperimentHandler) GetExperimentStats(c *gin.Context) {
+	name := c.Param("name")
+
+	stats, err := h.repository.GetArmStats(name)
+	if err != nil {
+		slog.Error("error fetching experiment stats", "error", err, "experiment", name)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if len(stats) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Experiment not found"})
+		return
+	}
+
+	baseline := stats[0]
+	want := c.DefaultQuery("baseline", "control")
+	for _, s := range stats {
+		if s.Arm == want {
+			baseline = s
+		}
+	}
+
+	res := ExperimentStatsResponse{
+		Experiment: name,
+		Baseline:   baseline.Arm,
+		Arms:       make([]ArmStatsResponse, 0, len(stats)),
+	}
+
+	for _, s := range stats {
+		arm := ArmStatsResponse{
+			Arm:             s.Arm,
+			Transformed:     s.Transformed,
+			Failed:          s.Failed,
+			AvgLatencyMs:    s.AvgLatencyMs,
+			P95LatencyMs:    s.P95LatencyMs,
+			AvgInputTokens:  s.AvgInputTokens,
+			AvgOutputTokens: s.AvgOutputTokens,
+			AvgSentiment:    s.AvgSentiment,
+			SentimentShift:  s.AvgSentiment - baseline.AvgSentiment,
+			Categories:      s.Categories,
+		}
+		if attempts := s.Transformed + s.Failed; attempts > 0 {
+			arm.FailureRate = float64(s.Failed) / float64(attempts)
+		}
+		res.Arms = append(res.Arms, arm)
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"zennews/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+type fakeExperimentStore struct {
+	stats map[string][]model.ArmStats
+}
+
+func (f *fakeExperimentStore) GetArmStats(experiment string) ([]model.ArmStats, error) {
+	return f.stats[experiment], nil
+}
+
+func newTestExperimentRouter(store ExperimentStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h := NewExperimentHandler(store)
+	admin := r.Group("/admin", RequireAdminToken("secret"))
+	admin.GET("/experiments/:name", h.GetExperimentStats)
+	return r
+}
+
+func newExperimentFixture() *fakeExperimentStore {
+	return &fakeExperimentStore{stats: map[string][]model.ArmStats{
+		"haiku-vs-4o": {
+			{Arm: "control", Transformed: 9, Failed: 1, AvgLatencyMs: 800, AvgSentiment: 4, Categories: map[string]int{"Earnings": 9}},
+			{Arm: "haiku", Transformed: 6, Failed: 2, AvgLatencyMs: 600, AvgSentiment: 5.5, Categories: map[string]int{"Earnings": 5, "Analysis": 1}},
+		},
+	}}
+}
+
+func TestGetExperimentStats(t *testing.T) {
+	r := newTestExperimentRouter(newExperimentFixture())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest("GET", "/admin/experiments/haiku-vs-4o"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res ExperimentStatsResponse
+	json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Equal(t, "control", res.Baseline)
+	assert.Equal(t, 2, len(res.Arms))
+	assert.Equal(t, 0.1, res.Arms[0].FailureRate)
+	assert.Equal(t, 0.25, res.Arms[1].FailureRate)
+	assert.Equal(t, 1.5, res.Arms[1].SentimentShift)
+	assert.Equal(t, 1, res.Arms[1].Categories["Analysis"])
+}
+
+func TestGetExperimentStats_Baseline(t *testing.T) {
+	r := newTestExperimentRouter(newExperimentFixture())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest("GET", "/admin/experiments/haiku-vs-4o?baseline=haiku"))
+
+	var res ExperimentStatsResponse
+	json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Equal(t, "haiku", res.Baseline)
+	assert.Equal(t, -1.5, res.Arms[0].SentimentShift)
+}
+
+func TestGetExperimentStats_NotFound(t *testing.T) {
+	r := newTestExperimentRouter(newExperimentFixture())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest("GET", "/admin/experiments/unknown"))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -21,14 +21,35 @@ type ArticleStore interface {
 	GetOriginalFeedTotal() (int, error)
 }
 
+// BudgetReporter reports each LLM provider's spend against its daily budget.
+type BudgetReporter interface {
+	Status() []model.BudgetStatus
+}
+
 type ArticleHandler struct {
 	repository ArticleStore
+	budget     BudgetReporter
 }
 
 func NewArticleHandler(repository ArticleStore) *ArticleHandler {
 	return &ArticleHandler{repository: repository}
 }
 
+// WithBudget adds the LLM budget state to /health.
+func (h *ArticleHandler) WithBudget(budget BudgetReporter) *ArticleHandler {
+	h.budget = budget
+	return h
+}
+
+type BudgetStatusResponse struct {
+	Provider    string  `json:"provider"`
+	Level       string  `json:"level"`
+	SpentUSD    float64 `json:"spent_usd"`
+	SpentTokens int64   `json:"spent_tokens"`
+	LimitUSD    float64 `json:"limit_usd,omitempty"`
+	LimitTokens int64   `json:"limit_tokens,omitempty"`
+}
+
 func (h *ArticleHandler) GetFeed(c *gin.Context) {
 
 	limit := getQueryLimit(c)
@@ -180,10 +201,29 @@ func (h *ArticleHandler) GetHealth(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	res := gin.H{
 		"status":   "healthy",
 		"database": "connected",
-	})
+	}
+
+	// The budget only throttles the workers, so a provider at its hard limit
+	// is reported without marking the API unhealthy.
+	if h.budget != nil {
+		budget := []BudgetStatusResponse{}
+		for _, s := range h.budget.Status() {
+			budget = append(budget, BudgetStatusResponse{
+				Provider:    s.Provider,
+				Level:       s.Level,
+				SpentUSD:    s.SpentUSD,
+				SpentTokens: s.SpentTokens,
+				LimitUSD:    s.LimitUSD,
+				LimitTokens: s.LimitTokens,
+			})
+		}
+		res["llm_budget"] = budget
+	}
+
+	c.JSON(http.StatusOK, res)
 }
 
 func (h *ArticleHandler) GetOriginalFeed(c *gin.Context) {
@@ -225,6 +225,36 @@ func TestGetHealth_Unhealthy(t *testing.T) {
 	assert.Equal(t, "unhealthy", res["status"])
 }
 
+type fakeBudget []model.BudgetStatus
+
+func (b fakeBudget) Status() []model.BudgetStatus {
+	return b
+}
+
+func TestGetHealth_Budget(t *testing.T) {
+	h := NewArticleHandler(&fakeStore{}).WithBudget(fakeBudget{
+		{Provider: "openai", Level: model.BudgetHard, SpentUSD: 10.2, SpentTokens: 900000, LimitUSD: 10},
+	})
+	r := gin.New()
+	r.GET("/health", h.GetHealth)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/health", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res struct {
+		Status    string                 `json:"status"`
+		LLMBudget []BudgetStatusResponse `json:"llm_budget"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Equal(t, "healthy", res.Status)
+	assert.Equal(t, []BudgetStatusResponse{
+		{Provider: "openai", Level: model.BudgetHard, SpentUSD: 10.2, SpentTokens: 900000, LimitUSD: 10},
+	}, res.LLMBudget)
+}
+
 func TestGetOriginalFeed_ReturnArticles(t *testing.T) {
 	store := &fakeStore{
 		originalFeed: []model.OriginalArticle{
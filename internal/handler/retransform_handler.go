@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"zennews/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RetransformStore interface {
+	GetRetransformIDs(filter model.RetransformFilter) ([]int64, error)
+	RollbackTransformed(filter model.RetransformFilter) (int64, error)
+}
+
+type RetransformHandler struct {
+	repository RetransformStore
+	queue      TransformQueue
+}
+
+func NewRetransformHandler(repository RetransformStore, queue TransformQueue) *RetransformHandler {
+	return &RetransformHandler{repository: repository, queue: queue}
+}
+
+// RetransformRequest selects articles by their active transformed version.
+// At least one field other than limit is required.
+type RetransformRequest struct {
+	IDs           []int64    `json:"ids"`
+	PromptVersion string     `json:"prompt_version"`
+	Model         string     `json:"model"`
+	Category      string     `json:"category"`
+	From          *time.Time `json:"from"`
+	To            *time.Time `json:"to"`
+	Limit         int        `json:"limit"`
+}
+
+func (r RetransformRequest) filter() model.RetransformFilter {
+	f := model.RetransformFilter{
+		IDs:           r.IDs,
+		PromptVersion: r.PromptVersion,
+		ModelUsed:     r.Model,
+		Category:      r.Category,
+		Limit:         r.Limit,
+	}
+	if r.From != nil {
+		f.From = *r.From
+	}
+	if r.To != nil {
+		f.To = *r.To
+	}
+	return f
+}
+
+func (r RetransformRequest) empty() bool {
+	return len(r.IDs) == 0 && r.PromptVersion == "" && r.Model == "" && r.Category == "" && r.From == nil && r.To == nil
+}
+
+// Retransform queues the matching articles for the retransform command.
+func (h *RetransformHandler) Retransform(c *gin.Context) {
+	req, ok := bindRetransformRequest(c)
+	if !ok {
+		return
+	}
+
+	ids, err := h.repository.GetRetransformIDs(req.filter())
+	if err != nil {
+		slog.Error("error selecting articles to retransform", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	for i, id := range ids {
+		err := h.queue.Push(ctx, strconv.FormatInt(id, 10))
+		if err != nil {
+			slog.Error("error queueing article for retransform", "error", err, "article_id", id)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Queue error", "queued": i})
+			return
+		}
+	}
+
+	slog.Info("articles queued for retransform", "count", len(ids))
+	c.JSON(http.StatusAccepted, gin.H{"queued": len(ids)})
+}
+
+// Rollback reactivates the previous version of the matching articles.
+func (h *RetransformHandler) Rollback(c *gin.Context) {
+	req, ok := bindRetransformRequest(c)
+	if !ok {
+		return
+	}
+
+	rolledBack, err := h.repository.RollbackTransformed(req.filter())
+	if err != nil {
+		slog.Error("error rolling back transformed articles", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	slog.Info("transformed articles rolled back", "count", rolledBack)
+	c.JSON(http.StatusOK, gin.H{"rolled_back": rolledBack})
+}
+
+func bindRetransformRequest(c *gin.Context) (RetransformRequest, bool) {
+	var req RetransformRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return req, false
+	}
+
+	if req.empty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one filter is required"})
+		return req, false
+	}
+
+	return req, true
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"zennews/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+type fakeRetransformStore struct {
+	ids     []int64
+	filters []model.RetransformFilter
+}
+
+func (f *fakeRetransformStore) GetRetransformIDs(filter model.RetransformFilter) ([]int64, error) {
+	f.filters = append(f.filters, filter)
+	return f.ids, nil
+}
+
+func (f *fakeRetransformStore) RollbackTransformed(filter model.RetransformFilter) (int64, error) {
+	f.filters = append(f.filters, filter)
+	return int64(len(f.ids)), nil
+}
+
+func newTestRetransformRouter(store RetransformStore, queue *fakeQueue) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h := NewRetransformHandler(store, queue)
+	admin := r.Group("/admin", RequireAdminToken("secret"))
+	admin.POST("/retransform", h.Retransform)
+	admin.POST("/retransform/rollback", h.Rollback)
+	return r
+}
+
+func newAdminJSONRequest(method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestRetransform_QueuesMatchingArticles(t *testing.T) {
+	store := &fakeRetransformStore{ids: []int64{3, 5}}
+	queue := &fakeQueue{}
+	r := newTestRetransformRouter(store, queue)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminJSONRequest("POST", "/admin/retransform",
+		`{"prompt_version": "v1", "category": "Earnings", "from": "2026-03-01T00:00:00Z", "limit": 100}`))
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, []string{"3", "5"}, queue.pushed)
+	assert.Equal(t, "v1", store.filters[0].PromptVersion)
+	assert.Equal(t, "Earnings", store.filters[0].Category)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), store.filters[0].From)
+	assert.Equal(t, true, store.filters[0].To.IsZero())
+	assert.Equal(t, 100, store.filters[0].Limit)
+}
+
+func TestRetransform_RequiresFilter(t *testing.T) {
+	store := &fakeRetransformStore{ids: []int64{3}}
+	queue := &fakeQueue{}
+	r := newTestRetransformRouter(store, queue)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminJSONRequest("POST", "/admin/retransform", `{"limit": 10}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 0, len(queue.pushed))
+}
+
+func TestRetransformRollback(t *testing.T) {
+	store := &fakeRetransformStore{ids: []int64{3, 5}}
+	r := newTestRetransformRouter(store, &fakeQueue{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminJSONRequest("POST", "/admin/retransform/rollback", `{"ids": [3, 5], "prompt_version": "v2"}`))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"rolled_back":2}`, w.Body.String())
+	assert.Equal(t, []int64{3, 5}, store.filters[0].IDs)
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+	"zennews/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultSourceWindow is how far back GET /admin/sources counts runs without
+// ?window=.
+const defaultSourceWindow = 24 * time.Hour
+
+type SourceStore interface {
+	GetSourceHealth(since time.Time) ([]model.SourceHealth, error)
+}
+
+// FetchTriggerQueue passes fetch triggers to the fetch scheduler.
+type FetchTriggerQueue interface {
+	Push(ctx context.Context, name string) error
+}
+
+type SourceHandler struct {
+	repository SourceStore
+	triggers   FetchTriggerQueue
+}
+
+func NewSourceHandler(repository SourceStore, triggers FetchTriggerQueue) *SourceHandler {
+	return &SourceHandler{repository: repository, triggers: triggers}
+}
+
+type FetchRunResponse struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Fetched    int       `json:"fetched"`
+	Saved      int       `json:"saved"`
+	Duplicated int       `json:"duplicated"`
+	Errors     int       `json:"errors"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type SourceResponse struct {
+	Source          string           `json:"source"`
+	Healthy         bool             `json:"healthy"`
+	LastRun         FetchRunResponse `json:"last_run"`
+	LastSuccessAt   *time.Time       `json:"last_success_at"`
+	Runs            int              `json:"runs"`
+	FailedRuns      int              `json:"failed_runs"`
+	LastPublishedAt *time.Time       `json:"last_published_at"`
+	Cursor          string           `json:"cursor,omitempty"`
+}
+
+type SourcesResponse struct {
+	Window  string           `json:"window"`
+	Sources []SourceResponse `json:"sources"`
+}
+
+// GetSources reports each news source's latest fetch run and how many of its
+// runs failed within ?window= (a duration such as 6h, default 24h). A source
+// is healthy when its latest run fetched without error and saved every
+// article.
+func (h *SourceHandler) GetSources(c *gin.Context) {
+	window := defaultSourceWindow
+	if s := c.Query("window"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window, want a positive duration such as 24h"})
+			return
+		}
+		window = d
+	}
+
+	health, err := h.repository.GetSourceHealth(time.Now().Add(-window))
+	if err != nil {
+		slog.Error("error fetching source health", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	res := SourcesResponse{Window: window.String(), Sources: make([]SourceResponse, 0, len(health))}
+	for _, s := range health {
+		run := s.LastRun
+		res.Sources = append(res.Sources, SourceResponse{
+			Source:  run.Source,
+			Healthy: run.Error == "" && run.Errors == 0,
+			LastRun: FetchRunResponse{
+				StartedAt:  run.StartedAt,
+				FinishedAt: run.FinishedAt,
+				DurationMs: run.FinishedAt.Sub(run.StartedAt).Milliseconds(),
+				Fetched:    run.Fetched,
+				Saved:      run.Saved,
+				Duplicated: run.Duplicated,
+				Errors:     run.Errors,
+				Error:      run.Error,
+			},
+			LastSuccessAt:   s.LastSuccessAt,
+			Runs:            s.Runs,
+			FailedRuns:      s.FailedRuns,
+			LastPublishedAt: s.LastPublishedAt,
+			Cursor:          s.Cursor,
+		})
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// TriggerFetchRequest names the sources to fetch; none means every source.
+type TriggerFetchRequest struct {
+	Sources []string `json:"sources"`
+}
+
+// TriggerFetch asks the fetcher running with -daemon to fetch the named
+// sources, or all of them, on its next tick. The body is optional.
+func (h *SourceHandler) TriggerFetch(c *gin.Context) {
+	var req TriggerFetchRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	names := req.Sources
+	if len(names) == 0 {
+		names = []string{model.AllSources}
+	}
+	for _, name := range names {
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Source names must not be empty"})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	for _, name := range names {
+		if err := h.triggers.Push(ctx, name); err != nil {
+			slog.Error("error triggering fetch", "error", err, "source", name)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Queue error"})
+			return
+		}
+	}
+
+	slog.Info("fetch triggered", "sources", names)
+	c.JSON(http.StatusAccepted, gin.H{"triggered": names})
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"zennews/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+type fakeSourceStore struct {
+	health []model.SourceHealth
+	since  time.Time
+}
+
+func (f *fakeSourceStore) GetSourceHealth(since time.Time) ([]model.SourceHealth, error) {
+	f.since = since
+	return f.health, nil
+}
+
+func newTestSourceRouter(store SourceStore, triggers *fakeQueue) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h := NewSourceHandler(store, triggers)
+	admin := r.Group("/admin", RequireAdminToken("secret"))
+	admin.GET("/sources", h.GetSources)
+	admin.POST("/fetch", h.TriggerFetch)
+	return r
+}
+
+func TestGetSources(t *testing.T) {
+	started := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	success := started.Add(-time.Hour)
+	store := &fakeSourceStore{health: []model.SourceHealth{
+		{
+			LastRun:       model.FetchRun{Source: "FinnHub", StartedAt: started, FinishedAt: started.Add(1500 * time.Millisecond), Fetched: 10, Saved: 8, Duplicated: 2},
+			LastSuccessAt: &started, Runs: 24, Cursor: "7001",
+		},
+		{
+			LastRun:       model.FetchRun{Source: "Massive", StartedAt: started, FinishedAt: started.Add(time.Minute), Error: "timed out after 1m0s: context deadline exceeded"},
+			LastSuccessAt: &success, Runs: 24, FailedRuns: 3,
+		},
+	}}
+	r := newTestSourceRouter(store, &fakeQueue{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest("GET", "/admin/sources?window=6h"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, time.Since(store.since) > 6*time.Hour-time.Minute)
+
+	var res SourcesResponse
+	json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Equal(t, "6h0m0s", res.Window)
+	assert.Equal(t, 2, len(res.Sources))
+
+	assert.Equal(t, true, res.Sources[0].Healthy)
+	assert.Equal(t, int64(1500), res.Sources[0].LastRun.DurationMs)
+	assert.Equal(t, 8, res.Sources[0].LastRun.Saved)
+	assert.Equal(t, "7001", res.Sources[0].Cursor)
+
+	assert.Equal(t, false, res.Sources[1].Healthy)
+	assert.Equal(t, 3, res.Sources[1].FailedRuns)
+	assert.Equal(t, success, *res.Sources[1].LastSuccessAt)
+}
+
+func TestGetSources_InvalidWindow(t *testing.T) {
+	r := newTestSourceRouter(&fakeSourceStore{}, &fakeQueue{})
+
+	for _, path := range []string{"/admin/sources?window=day", "/admin/sources?window=-1h"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newAdminRequest("GET", path))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestTriggerFetch(t *testing.T) {
+	triggers := &fakeQueue{}
+	r := newTestSourceRouter(&fakeSourceStore{}, triggers)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest("POST", "/admin/fetch"))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, []string{model.AllSources}, triggers.pushed)
+
+	req := newAdminRequest("POST", "/admin/fetch")
+	req.Body = io.NopCloser(strings.NewReader(`{"sources": ["FinnHub", "CNBC World"]}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, []string{model.AllSources, "FinnHub", "CNBC World"}, triggers.pushed)
+
+	req = newAdminRequest("POST", "/admin/fetch")
+	req.Body = io.NopCloser(strings.NewReader(`{"sources": [""]}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+	"zennews/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultUsageDays is how many days GET /admin/usage covers without ?from=.
+const defaultUsageDays = 7
+
+type UsageStore interface {
+	GetDailyUsage(from, to time.Time) ([]model.ApiUsage, error)
+}
+
+type UsageHandler struct {
+	repository UsageStore
+}
+
+func NewUsageHandler(repository UsageStore) *UsageHandler {
+	return &UsageHandler{repository: repository}
+}
+
+type UsageTotals struct {
+	Requests     int     `json:"requests"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+func (t *UsageTotals) add(u model.ApiUsage) {
+	t.Requests += u.RequestCount
+	t.InputTokens += u.InputTokens
+	t.OutputTokens += u.OutputTokens
+	t.CostUSD += u.CostUSD
+}
+
+type StageUsageResponse struct {
+	Stage string `json:"stage"`
+	UsageTotals
+}
+
+type DailyUsageResponse struct {
+	Date     string `json:"date"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Stage    string `json:"stage"`
+	UsageTotals
+}
+
+type UsageResponse struct {
+	From   string               `json:"from"`
+	To     string               `json:"to"`
+	Total  UsageTotals          `json:"total"`
+	Stages []StageUsageResponse `json:"stages"`
+	Daily  []DailyUsageResponse `json:"daily"`
+}
+
+// GetUsage reports LLM usage and cost per day, provider, model and pipeline
+// stage between ?from= and ?to= (YYYY-MM-DD, inclusive), defaulting to the
+// last 7 days, with totals per stage and overall.
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if s := c.Query("to"); s != "" {
+		t, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, want YYYY-MM-DD"})
+			return
+		}
+		to = t
+	}
+
+	from := to.AddDate(0, 0, -(defaultUsageDays - 1))
+	if s := c.Query("from"); s != "" {
+		t, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, want YYYY-MM-DD"})
+			return
+		}
+		from = t
+	}
+
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+
+	usage, err := h.repository.GetDailyUsage(from, to)
+	if err != nil {
+		slog.Error("error fetching usage", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	res := UsageResponse{
+		From:   from.Format(time.DateOnly),
+		To:     to.Format(time.DateOnly),
+		Stages: []StageUsageResponse{},
+		Daily:  make([]DailyUsageResponse, 0, len(usage)),
+	}
+
+	stages := map[string]*StageUsageResponse{}
+	for _, u := range usage {
+		day := DailyUsageResponse{
+			Date:     u.UsageDate.Format(time.DateOnly),
+			Provider: u.ApiName,
+			Model:    u.Model,
+			Stage:    u.Stage,
+		}
+		day.add(u)
+		res.Daily = append(res.Daily, day)
+
+		stage, ok := stages[u.Stage]
+		if !ok {
+			stage = &StageUsageResponse{Stage: u.Stage}
+			stages[u.Stage] = stage
+		}
+		stage.add(u)
+		res.Total.add(u)
+	}
+
+	for _, s := range stages {
+		res.Stages = append(res.Stages, *s)
+	}
+	sort.Slice(res.Stages, func(i, j int) bool {
+		return res.Stages[i].CostUSD > res.Stages[j].CostUSD
+	})
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"zennews/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+type fakeUsageStore struct {
+	usage    []model.ApiUsage
+	from, to time.Time
+}
+
+func (f *fakeUsageStore) GetDailyUsage(from, to time.Time) ([]model.ApiUsage, error) {
+	f.from, f.to = from, to
+	return f.usage, nil
+}
+
+func newTestUsageRouter(store UsageStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h := NewUsageHandler(store)
+	admin := r.Group("/admin", RequireAdminToken("secret"))
+	admin.GET("/usage", h.GetUsage)
+	return r
+}
+
+func TestGetUsage(t *testing.T) {
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	store := &fakeUsageStore{usage: []model.ApiUsage{
+		{ApiName: "openai", Model: "gpt-4o-mini", Stage: model.StageTransform, UsageDate: day, RequestCount: 100, InputTokens: 50000, OutputTokens: 10000, CostUSD: 0.0135},
+		{ApiName: "anthropic", Model: "claude-sonnet-4-6", Stage: model.StageSummary, UsageDate: day, RequestCount: 2, InputTokens: 20000, OutputTokens: 4000, CostUSD: 0.12},
+		{ApiName: "openai", Model: "gpt-4o-mini", Stage: model.StageTransform, UsageDate: day.AddDate(0, 0, -1), RequestCount: 40, InputTokens: 20000, OutputTokens: 4000, CostUSD: 0.0054},
+	}}
+	r := newTestUsageRouter(store)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest("GET", "/admin/usage?from=2026-03-01&to=2026-03-02"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), store.from)
+
+	var res UsageResponse
+	json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Equal(t, "2026-03-01", res.From)
+	assert.Equal(t, 3, len(res.Daily))
+	assert.Equal(t, "2026-03-02", res.Daily[0].Date)
+	assert.Equal(t, 142, res.Total.Requests)
+	assert.Equal(t, int64(90000), res.Total.InputTokens)
+
+	assert.Equal(t, 2, len(res.Stages))
+	assert.Equal(t, model.StageSummary, res.Stages[0].Stage)
+	assert.Equal(t, 140, res.Stages[1].Requests)
+}
+
+func TestGetUsage_DefaultRange(t *testing.T) {
+	store := &fakeUsageStore{}
+	r := newTestUsageRouter(store)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newAdminRequest("GET", "/admin/usage?to=2026-03-10"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), store.from)
+
+	var res UsageResponse
+	json.Unmarshal(w.Body.Bytes(), &res)
+	assert.Equal(t, 0, len(res.Daily))
+	assert.Equal(t, 0.0, res.Total.CostUSD)
+}
+
+func TestGetUsage_InvalidRange(t *testing.T) {
+	r := newTestUsageRouter(&fakeUsageStore{})
+
+	for _, path := range []string{"/admin/usage?from=March", "/admin/usage?from=2026-03-05&to=2026-03-01"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, newAdminRequest("GET", path))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
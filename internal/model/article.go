@@ -5,11 +5,19 @@ import "time"
 const (
 	StatusPending    = "pending"
 	StatusProcessing = "processing"
+	StatusBatched    = "batched"
+	StatusDeferred   = "deferred"
 	StatusCompleted  = "completed"
 	StatusFailed     = "failed"
 	OthersCategory   = "Others"
 )
 
+// Fact check outcomes stored on a transformed article.
+const (
+	ValidationPassed  = "passed"
+	ValidationFlagged = "flagged"
+)
+
 type OriginalArticle struct {
 	ID          int64
 	Headline    string
@@ -23,6 +31,13 @@ type OriginalArticle struct {
 	Status      string
 }
 
+type OutboxEntry struct {
+	ID         int64
+	ArticleID  int64
+	CreatedAt  time.Time
+	EnqueuedAt time.Time
+}
+
 type TransformedArticle struct {
 	ID             int64
 	Headline       string
@@ -31,10 +46,32 @@ type TransformedArticle struct {
 	CategoryID     int64
 	SentimentScore int
 	PromptVersion  string
+	PromptID       int64
 	ModelUsed      string
+	IsActive       bool
+	Experiment     string
+	Arm            string
+	LatencyMs      int64
+	InputTokens    int64
+	OutputTokens   int64
+	Validation     string
+	MissingFacts   []string
+	AddedFacts     []string
 	TransformedAt  time.Time
 }
 
+// RetransformFilter selects articles whose active transformed version should
+// be regenerated or rolled back. Zero-valued fields match everything.
+type RetransformFilter struct {
+	IDs           []int64
+	PromptVersion string
+	ModelUsed     string
+	Category      string
+	From          time.Time
+	To            time.Time
+	Limit         int
+}
+
 type Category struct {
 	ID   int64
 	Name string
@@ -53,15 +90,93 @@ type ProcessingError struct {
 	ErrorMessage string
 	ErrorType    string
 	AttemptCount int
+	Experiment   string
+	Arm          string
 	CreatedAt    time.Time
 }
 
+// Pipeline stages that LLM usage is attributed to.
+const (
+	StageTransform      = "transform"
+	StageTransformBatch = "transform_batch"
+	StageRetransform    = "retransform"
+	StageSummary        = "summary"
+)
+
+// ApiUsage is one day's LLM usage for a provider, model and stage. CostUSD is
+// priced from llm_price when each call was recorded.
 type ApiUsage struct {
 	ID           int64
 	ApiName      string
+	Model        string
+	Stage        string
 	UsageDate    time.Time
 	RequestCount int
+	InputTokens  int64
+	OutputTokens int64
 	TokenCount   int
+	CostUSD      float64
+}
+
+// Budget levels of an LLM provider's daily spend.
+const (
+	BudgetOK   = "ok"
+	BudgetSoft = "soft_limit"
+	BudgetHard = "hard_limit"
+)
+
+// Spend is what a provider has used today.
+type Spend struct {
+	CostUSD float64
+	Tokens  int64
+}
+
+// BudgetStatus is a provider's spend today against its daily limits. Zero
+// limits are unlimited.
+type BudgetStatus struct {
+	Provider    string
+	Level       string
+	SpentUSD    float64
+	SpentTokens int64
+	LimitUSD    float64
+	LimitTokens int64
+}
+
+// LLMUsage is the token usage of one LLM call, tied to the article or summary
+// it produced. Batch calls are billed at the batch API's discount.
+type LLMUsage struct {
+	ID           int64
+	Stage        string
+	Provider     string
+	Model        string
+	ArticleID    int64
+	SummaryID    int64
+	InputTokens  int64
+	OutputTokens int64
+	Batch        bool
+	CostUSD      float64
+	CreatedAt    time.Time
+}
+
+// Transform batch states. A batch is submitted until its results have been
+// ingested or the provider failed it.
+const (
+	BatchSubmitted = "submitted"
+	BatchIngested  = "ingested"
+	BatchFailed    = "failed"
+)
+
+// TransformBatch is a set of articles sent to a provider's batch API.
+type TransformBatch struct {
+	ID          int64
+	Provider    string
+	BatchID     string
+	Status      string
+	ArticleIDs  []int64
+	Succeeded   int
+	Failed      int
+	CreatedAt   time.Time
+	CompletedAt *time.Time
 }
 
 type FeedArticle struct {
@@ -82,3 +197,12 @@ type SingleArticle struct {
 	OriginalHeadline string
 	OriginalDetail   string
 }
+
+type Prompt struct {
+	ID        int64
+	Name      string
+	Version   string
+	Body      string
+	Active    bool
+	CreatedAt time.Time
+}
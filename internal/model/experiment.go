@@ -0,0 +1,14 @@
+package model
+
+// ArmStats aggregates the transforms of one experiment arm.
+type ArmStats struct {
+	Arm             string
+	Transformed     int
+	Failed          int
+	AvgLatencyMs    float64
+	P95LatencyMs    float64
+	AvgInputTokens  float64
+	AvgOutputTokens float64
+	AvgSentiment    float64
+	Categories      map[string]int
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// AllSources is the fetch trigger for every source.
+const AllSources = "*"
+
+// SourceState is where the last fetch from a news source left off: the
+// newest publish time seen and the source's own cursor, such as the last
+// FinnHub ID or a feed's ETag.
+type SourceState struct {
+	Source          string
+	LastPublishedAt *time.Time
+	Cursor          string
+	UpdatedAt       time.Time
+}
+
+// FetchRun is one fetch from a news source: how many articles it returned,
+// how many were new, duplicates or failed to save, and why it failed, if it
+// did.
+type FetchRun struct {
+	ID         int64
+	Source     string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Fetched    int
+	Saved      int
+	Duplicated int
+	Errors     int
+	Error      string
+}
+
+// SourceHealth is a news source's latest fetch run, how many of its runs
+// since a point in time failed, and where its fetches left off.
+type SourceHealth struct {
+	LastRun         FetchRun
+	LastSuccessAt   *time.Time
+	Runs            int
+	FailedRuns      int
+	LastPublishedAt *time.Time
+	Cursor          string
+}
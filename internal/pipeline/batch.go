@@ -0,0 +1,293 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+	"zennews/internal/model"
+	"zennews/pkg/llm"
+)
+
+// BatchStore records transform batches and picks the articles for them.
+type BatchStore interface {
+	GetPendingForBatch(limit int) ([]model.OriginalArticle, error)
+	SaveBatch(b *model.TransformBatch) error
+	GetOpenBatches() ([]model.TransformBatch, error)
+	UpdateBatch(b *model.TransformBatch) error
+	ReleaseBatched(ids []int64) error
+}
+
+// Batcher transforms pending articles through a provider's batch API, one
+// batch of up to Size articles at a time. Batched articles are skipped by the
+// queue's workers until their batch ends. Results are checked and saved by
+// Worker the same way as a synchronous transform. A failed request is
+// recorded under its error class like a failed transform: once the class's
+// retry policy is exhausted the article is dead-lettered through Worker,
+// otherwise it goes back to pending and, through the outbox, to the transform
+// queue, where the transformer retries it with the policy's backoff.
+type Batcher struct {
+	Worker   *Worker
+	Client   llm.BatchClient
+	Store    BatchStore
+	Provider string
+	Size     int
+	Poll     time.Duration
+	Daemon   bool
+
+	// unsaved is set when results could not be saved, so the articles just
+	// returned to pending are not batched again straight away.
+	unsaved bool
+}
+
+// Run polls open batches every Poll, ingesting those that ended, and submits
+// a new batch whenever none is open. Outside daemon mode it returns once
+// every batch has been ingested and no pending articles are left, or once
+// results could not be saved.
+func (b *Batcher) Run(ctx context.Context) {
+	for {
+		open := b.Check(ctx)
+		unsaved := b.unsaved
+		b.unsaved = false
+		if open == 0 && ctx.Err() == nil && (unsaved || !b.submitNext(ctx)) && !b.Daemon {
+			return
+		}
+
+		if !sleepCtx(ctx, b.Poll) {
+			slog.Info("shutdown requested, batcher stopping")
+			return
+		}
+	}
+}
+
+// submitNext submits a batch unless the budget is exhausted, reporting
+// whether one was submitted.
+func (b *Batcher) submitNext(ctx context.Context) bool {
+	if b.Worker.Throttle.Level() == model.BudgetHard {
+		slog.Warn("daily LLM budget exhausted, not submitting a batch")
+		return false
+	}
+
+	batch, err := b.Submit(ctx)
+	if err != nil {
+		slog.Error("error submitting transform batch", "error", err)
+		return false
+	}
+	if batch == nil {
+		slog.Info("no pending articles to batch")
+		return false
+	}
+	return true
+}
+
+// Submit sends up to Size pending articles as a new batch. It returns nil
+// when no article is pending.
+func (b *Batcher) Submit(ctx context.Context) (*model.TransformBatch, error) {
+	articles, err := b.Store.GetPendingForBatch(b.Size)
+	if err != nil || len(articles) == 0 {
+		return nil, err
+	}
+
+	requests := make([]llm.BatchRequest, len(articles))
+	ids := make([]int64, len(articles))
+	for i, a := range articles {
+		requests[i] = llm.BatchRequest{
+			CustomID: strconv.FormatInt(a.ID, 10),
+			Input:    llm.TransformInput{Headline: a.Headline, Detail: a.Detail},
+		}
+		ids[i] = a.ID
+	}
+
+	batchID, err := b.Client.SubmitBatch(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &model.TransformBatch{Provider: b.Provider, BatchID: batchID, Status: model.BatchSubmitted, ArticleIDs: ids}
+	if err := b.Store.SaveBatch(batch); err != nil {
+		// Without the row nothing polls the batch, so its results are lost
+		// and its articles stay pending for the next one.
+		slog.Error("error recording submitted batch, its results will not be ingested", "error", err, "batch_id", batchID)
+		return nil, err
+	}
+
+	slog.Info("transform batch submitted", "batch_id", batchID, "provider", b.Provider, "article_count", len(ids))
+	return batch, nil
+}
+
+// Check polls this provider's open batches once, ingesting those that ended,
+// and returns how many are still open.
+func (b *Batcher) Check(ctx context.Context) int {
+	batches, err := b.Store.GetOpenBatches()
+	if err != nil {
+		slog.Error("error getting open batches", "error", err)
+		return 0
+	}
+
+	open := 0
+	for i := range batches {
+		batch := &batches[i]
+		if batch.Provider != b.Provider {
+			continue
+		}
+
+		status, err := b.Client.GetBatch(ctx, batch.BatchID)
+		if err != nil {
+			slog.Error("error polling transform batch", "error", err, "batch_id", batch.BatchID)
+			open++
+			continue
+		}
+
+		switch status.State {
+		case llm.BatchInProgress:
+			slog.Info("transform batch in progress", "batch_id", batch.BatchID, "total", status.Total,
+				"succeeded", status.Succeeded, "failed", status.Failed)
+			open++
+		case llm.BatchFailed:
+			slog.Error("transform batch failed, returning its articles to pending", "batch_id", batch.BatchID)
+			b.release(batch.ArticleIDs...)
+			b.finish(batch, model.BatchFailed)
+		case llm.BatchEnded:
+			if !b.ingest(ctx, batch) {
+				open++
+			}
+		}
+	}
+	return open
+}
+
+// ingest saves the results of an ended batch. It reports false when the
+// results could not be read, leaving the batch open for the next poll.
+func (b *Batcher) ingest(ctx context.Context, batch *model.TransformBatch) bool {
+	results, err := b.Client.BatchResults(ctx, batch.BatchID)
+	if err != nil {
+		slog.Error("error reading transform batch results", "error", err, "batch_id", batch.BatchID)
+		return false
+	}
+
+	byID := make(map[string]llm.BatchResult, len(results))
+	for _, r := range results {
+		byID[r.CustomID] = r
+	}
+
+	for _, id := range batch.ArticleIDs {
+		r, ok := byID[strconv.FormatInt(id, 10)]
+		if !ok {
+			r.Err = errors.New("no result in batch")
+		}
+		switch b.save(id, r) {
+		case batchSaved:
+			batch.Succeeded++
+		case batchFailed:
+			batch.Failed++
+		}
+	}
+
+	b.finish(batch, model.BatchIngested)
+	slog.Info("transform batch ingested", "batch_id", batch.BatchID, "succeeded", batch.Succeeded, "failed", batch.Failed)
+	return true
+}
+
+// batchOutcome is what became of one article of an ingested batch.
+type batchOutcome int
+
+const (
+	batchSaved batchOutcome = iota
+	batchFailed
+	batchSkipped
+)
+
+// save validates and stores one result, or records why the article could
+// not be transformed and returns it to pending or dead-letters it. Articles
+// completed in the meantime by the transformer are skipped. Database errors
+// are not the model's fault, so they return the article to pending without
+// counting an attempt.
+func (b *Batcher) save(id int64, r llm.BatchResult) batchOutcome {
+	w := b.Worker
+	article, err := w.Store.GetOriginalByID(id)
+	if err != nil || article == nil {
+		slog.Error("error getting batched article", "error", err, "article_id", id)
+		b.release(id)
+		b.unsaved = true
+		return batchFailed
+	}
+	if article.Status == model.StatusCompleted {
+		slog.Info("batched article already transformed, skipping", "article_id", id)
+		return batchSkipped
+	}
+
+	err = r.Err
+	if usage, ok := llm.BilledUsage(err); ok {
+		recordUsage(w.Usage, model.StageTransformBatch, id, 0, usage)
+	}
+	if err == nil {
+		recordUsage(w.Usage, model.StageTransformBatch, id, 0, r.Result.Usage)
+
+		c := call{result: r.Result}
+		err = w.validate(article, &c)
+		if err == nil {
+			transformed, saveErr := w.toTransformed(id, c)
+			if saveErr == nil {
+				saveErr = w.Store.SaveTransformedAndComplete(transformed, id)
+			}
+			if saveErr != nil {
+				slog.Error("error saving batched article, returning it to pending", "error", saveErr, "article_id", id)
+				b.release(id)
+				b.unsaved = true
+				return batchFailed
+			}
+			return batchSaved
+		}
+	}
+
+	llmErr := llm.Classify(err)
+	attempt, countErr := w.Store.GetAttemptCount(id)
+	if countErr != nil {
+		slog.Error("error getting attempt count", "error", countErr, "article_id", id)
+	}
+	classAttempt, countErr := w.Store.GetClassAttemptCount(id, string(llmErr.Class))
+	if countErr != nil {
+		slog.Error("error getting attempt count", "error", countErr, "article_id", id)
+	}
+	attempt++
+	classAttempt++
+
+	slog.Error("error transforming batched article", "error", err, "error_type", llmErr.Class,
+		"attempt", attempt, "class_attempt", classAttempt, "article_id", id)
+
+	saveErr := w.Store.SaveError(&model.ProcessingError{
+		ArticleId:    id,
+		ErrorMessage: err.Error(),
+		ErrorType:    string(llmErr.Class),
+		AttemptCount: attempt,
+	})
+	if saveErr != nil {
+		slog.Error("error saving processing error", "error", saveErr, "article_id", id)
+	}
+
+	if !llm.PolicyFor(llmErr.Class).Retryable(classAttempt) {
+		w.deadLetter(id, attempt)
+		return batchFailed
+	}
+
+	slog.Info("returning batched article to the transformer", "article_id", id)
+	b.release(id)
+	return batchFailed
+}
+
+// release returns batched articles to pending and requeues them, leaving
+// those that have moved on alone.
+func (b *Batcher) release(ids ...int64) {
+	if err := b.Store.ReleaseBatched(ids); err != nil {
+		slog.Error("error returning batched articles to pending", "error", err, "article_ids", ids)
+	}
+}
+
+func (b *Batcher) finish(batch *model.TransformBatch, status string) {
+	now := time.Now()
+	batch.Status, batch.CompletedAt = status, &now
+	if err := b.Store.UpdateBatch(batch); err != nil {
+		slog.Error("error updating transform batch", "error", err, "batch_id", batch.BatchID)
+	}
+}
@@ -0,0 +1,164 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"zennews/internal/model"
+	"zennews/pkg/llm"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestBatcher(t *testing.T) {
+	store := newMemStore()
+	fetchAll(store, newsFixture())
+
+	client := &llm.FakeClient{Errors: map[string]error{
+		"Bitcoin tanks below $60,000 in crazy selloff": errors.New("request expired"),
+	}}
+	b := &Batcher{
+		Worker:   &Worker{Store: store, Usage: store},
+		Client:   client,
+		Store:    store,
+		Provider: llm.ProviderFake,
+		Size:     2,
+		Poll:     time.Millisecond,
+	}
+	b.Run(context.Background())
+
+	// Apple and Bitcoin went in the first batch, the tech rally in the
+	// second. The failed Bitcoin article is not batched again.
+	assert.Equal(t, 2, len(store.batches))
+	assert.Equal(t, []int64{1, 3}, store.batches[0].ArticleIDs)
+	assert.Equal(t, model.BatchIngested, store.batches[0].Status)
+	assert.Equal(t, 1, store.batches[0].Succeeded)
+	assert.Equal(t, 1, store.batches[0].Failed)
+	assert.Equal(t, []int64{5}, store.batches[1].ArticleIDs)
+	assert.Equal(t, model.BatchIngested, store.batches[1].Status)
+
+	assert.Equal(t, 2, len(store.transformed))
+	assert.Equal(t, "Apple stock rose on record earnings", store.transformed[1].Headline)
+	assert.Equal(t, model.StatusCompleted, store.articles[1].Status)
+	assert.Equal(t, model.StatusCompleted, store.articles[5].Status)
+
+	// The failure is recorded and the article is back with the transformer.
+	assert.Equal(t, model.StatusPending, store.articles[3].Status)
+	entries, _ := store.GetPendingOutbox(10)
+	assert.Equal(t, 4, len(entries))
+	assert.Equal(t, int64(3), entries[3].ArticleID)
+	assert.Equal(t, 1, len(store.errors))
+	assert.Equal(t, 1, store.errors[0].AttemptCount)
+
+	assert.Equal(t, 2, len(store.usage))
+	for _, u := range store.usage {
+		assert.Equal(t, model.StageTransformBatch, u.Stage)
+		assert.Equal(t, true, u.Batch)
+	}
+}
+
+func TestWorkerSkipsBatchedArticles(t *testing.T) {
+	store := newMemStore()
+	queue := newMemQueue()
+	fetchAll(store, newsFixture())
+
+	batchClient := &llm.FakeClient{Errors: map[string]error{
+		"Bitcoin tanks below $60,000 in crazy selloff": errors.New("request expired"),
+	}}
+	b := &Batcher{Worker: &Worker{Store: store, Usage: store}, Client: batchClient, Store: store,
+		Provider: llm.ProviderFake, Size: 10}
+	_, err := b.Submit(context.Background())
+	assert.Equal(t, nil, err)
+
+	// Every article is on the queue and in the batch; the workers leave them
+	// to the batch.
+	queueClient := &llm.FakeClient{}
+	w := &Worker{ID: "test-0", Store: store, Client: queueClient, Queue: queue, DeadLetters: newMemQueue(), Usage: store}
+	DrainOutbox(context.Background(), store, queue, 10)
+	w.Run(context.Background())
+	assert.Equal(t, 0, queueClient.Calls())
+	assert.Equal(t, 0, len(queue.items))
+	assert.Equal(t, 0, len(store.transformed))
+
+	// Only the article whose batch request failed comes back to the queue.
+	assert.Equal(t, 0, b.Check(context.Background()))
+	DrainOutbox(context.Background(), store, queue, 10)
+	assert.Equal(t, []string{"3"}, queue.items)
+	w.Run(context.Background())
+
+	assert.Equal(t, 1, queueClient.Calls())
+	assert.Equal(t, 3, len(store.transformed))
+	for _, id := range []int64{1, 3, 5} {
+		assert.Equal(t, model.StatusCompleted, store.articles[id].Status)
+	}
+}
+
+func TestBatcherAppliesRetryPolicy(t *testing.T) {
+	store := newMemStore()
+	deadLetters := newMemQueue()
+	fetchAll(store, newsFixture()[:1])
+
+	client := &llm.FakeClient{Errors: map[string]error{
+		"BREAKING: APPLE STOCK SOARS ON RECORD EARNINGS": &llm.Error{Class: llm.ErrorRefusal, Err: errors.New("refused")},
+		"Bitcoin tanks below $60,000 in crazy selloff":   errors.New("request expired"),
+	}}
+	b := &Batcher{Worker: &Worker{Store: store, Usage: store, DeadLetters: deadLetters}, Client: client, Store: store,
+		Provider: llm.ProviderFake, Size: 10}
+	b.Run(context.Background())
+
+	// A refusal is not retried; an unknown error goes back to the transformer.
+	assert.Equal(t, model.StatusFailed, store.articles[1].Status)
+	assert.Equal(t, []string{"1"}, deadLetters.items)
+	assert.Equal(t, model.StatusPending, store.articles[3].Status)
+	assert.Equal(t, 2, len(store.errors))
+}
+
+func TestBatcherSkipsCompletedArticles(t *testing.T) {
+	store := newMemStore()
+	fetchAll(store, newsFixture()[:1])
+
+	b := &Batcher{Worker: &Worker{Store: store, Usage: store}, Client: &llm.FakeClient{}, Store: store,
+		Provider: llm.ProviderFake, Size: 10}
+	_, err := b.Submit(context.Background())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, model.StatusBatched, store.articles[1].Status)
+
+	// The transformer finished an article while the batch was running.
+	store.SaveTransformedAndComplete(&model.TransformedArticle{OriginalID: 1, Headline: "Apple beat estimates"}, 1)
+
+	assert.Equal(t, 0, b.Check(context.Background()))
+	assert.Equal(t, "Apple beat estimates", store.transformed[1].Headline)
+	assert.Equal(t, model.StatusCompleted, store.articles[3].Status)
+	assert.Equal(t, 1, store.batches[0].Succeeded)
+	assert.Equal(t, 0, store.batches[0].Failed)
+	assert.Equal(t, 1, len(store.usage))
+}
+
+// failingSaveStore cannot save transformed articles.
+type failingSaveStore struct {
+	*memStore
+}
+
+func (failingSaveStore) SaveTransformedAndComplete(article *model.TransformedArticle, originalID int64) error {
+	return errors.New("connection reset")
+}
+
+func TestBatcherReturnsUnsavedArticles(t *testing.T) {
+	store := newMemStore()
+	fetchAll(store, newsFixture()[:1])
+
+	b := &Batcher{Worker: &Worker{Store: failingSaveStore{store}, Usage: store, DeadLetters: newMemQueue()},
+		Client: &llm.FakeClient{}, Store: store, Provider: llm.ProviderFake, Size: 10}
+	b.Run(context.Background())
+
+	// A database error is not charged as an LLM attempt; the articles go
+	// back to the transformer.
+	assert.Equal(t, 0, len(store.errors))
+	assert.Equal(t, 1, len(store.batches))
+	assert.Equal(t, 2, store.batches[0].Failed)
+	assert.Equal(t, model.StatusPending, store.articles[1].Status)
+	assert.Equal(t, model.StatusPending, store.articles[3].Status)
+	entries, _ := store.GetPendingOutbox(10)
+	assert.Equal(t, 4, len(entries))
+}
@@ -0,0 +1,247 @@
+package pipeline
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"zennews/internal/model"
+	"zennews/pkg/llm"
+)
+
+const (
+	// spendTTL is how long today's spend is cached between queries, which is
+	// also how far a burst of calls can overshoot a limit.
+	spendTTL = 30 * time.Second
+
+	defaultSoftRatio = 0.8
+)
+
+// budgetProviders are the providers BudgetFromEnv reads limits for.
+var budgetProviders = []string{llm.ProviderOpenAI, llm.ProviderAnthropic, llm.ProviderLocal, llm.ProviderFake}
+
+// SpendStore reports today's spend per provider.
+type SpendStore interface {
+	GetDailySpend() (map[string]model.Spend, error)
+}
+
+// BudgetLimit is a provider's daily cap. Zero fields are unlimited.
+type BudgetLimit struct {
+	CostUSD float64
+	Tokens  int64
+}
+
+// Budget tracks each provider's spend today against its daily limit. A
+// provider reaches the soft limit at SoftRatio of either its dollar or token
+// limit and the hard limit at the full amount.
+type Budget struct {
+	Limits    map[string]BudgetLimit
+	SoftRatio float64
+	Store     SpendStore
+
+	mu      sync.Mutex
+	spend   map[string]model.Spend
+	fetched time.Time
+	levels  map[string]string
+}
+
+// BudgetFromEnv reads LLM_BUDGET_<PROVIDER>_USD and _TOKENS for each provider
+// and LLM_BUDGET_SOFT_RATIO (default 0.8). It returns nil when no limit is
+// set.
+func BudgetFromEnv(store SpendStore) (*Budget, error) {
+	b := &Budget{Limits: map[string]BudgetLimit{}, SoftRatio: defaultSoftRatio, Store: store}
+
+	for _, provider := range budgetProviders {
+		prefix := "LLM_BUDGET_" + strings.ToUpper(provider)
+		var limit BudgetLimit
+
+		if v := os.Getenv(prefix + "_USD"); v != "" {
+			usd, err := strconv.ParseFloat(v, 64)
+			if err != nil || usd <= 0 {
+				return nil, fmt.Errorf("invalid %s_USD %q", prefix, v)
+			}
+			limit.CostUSD = usd
+		}
+
+		if v := os.Getenv(prefix + "_TOKENS"); v != "" {
+			tokens, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || tokens <= 0 {
+				return nil, fmt.Errorf("invalid %s_TOKENS %q", prefix, v)
+			}
+			limit.Tokens = tokens
+		}
+
+		if limit != (BudgetLimit{}) {
+			b.Limits[provider] = limit
+		}
+	}
+
+	if v := os.Getenv("LLM_BUDGET_SOFT_RATIO"); v != "" {
+		ratio, err := strconv.ParseFloat(v, 64)
+		if err != nil || ratio <= 0 || ratio > 1 {
+			return nil, fmt.Errorf("invalid LLM_BUDGET_SOFT_RATIO %q, want a fraction in (0, 1]", v)
+		}
+		b.SoftRatio = ratio
+	}
+
+	if len(b.Limits) == 0 {
+		return nil, nil
+	}
+	return b, nil
+}
+
+// Level returns the worst budget level among providers, since any of them
+// may serve a call in a failover chain. Providers without a limit are ok.
+func (b *Budget) Level(providers []string) string {
+	level := model.BudgetOK
+	for _, s := range b.statuses(providers) {
+		if s.Level == model.BudgetHard || s.Level == model.BudgetSoft && level == model.BudgetOK {
+			level = s.Level
+		}
+	}
+	return level
+}
+
+// Status reports every provider with a limit, ordered by name.
+func (b *Budget) Status() []model.BudgetStatus {
+	providers := make([]string, 0, len(b.Limits))
+	for p := range b.Limits {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	return b.statuses(providers)
+}
+
+func (b *Budget) statuses(providers []string) []model.BudgetStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+
+	var statuses []model.BudgetStatus
+	for _, p := range providers {
+		limit, ok := b.Limits[p]
+		if !ok {
+			continue
+		}
+
+		spent := b.spend[p]
+		s := model.BudgetStatus{
+			Provider:    p,
+			Level:       model.BudgetOK,
+			SpentUSD:    spent.CostUSD,
+			SpentTokens: spent.Tokens,
+			LimitUSD:    limit.CostUSD,
+			LimitTokens: limit.Tokens,
+		}
+
+		used := 0.0
+		if limit.CostUSD > 0 {
+			used = spent.CostUSD / limit.CostUSD
+		}
+		if limit.Tokens > 0 {
+			used = max(used, float64(spent.Tokens)/float64(limit.Tokens))
+		}
+		switch {
+		case used >= 1:
+			s.Level = model.BudgetHard
+		case used >= b.SoftRatio:
+			s.Level = model.BudgetSoft
+		}
+
+		if b.levels[p] != s.Level {
+			if b.levels == nil {
+				b.levels = map[string]string{}
+			}
+			b.levels[p] = s.Level
+			slog.Warn("LLM budget level changed", "provider", p, "level", s.Level,
+				"spent_usd", s.SpentUSD, "limit_usd", s.LimitUSD, "spent_tokens", s.SpentTokens, "limit_tokens", s.LimitTokens)
+		}
+
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
+// refresh reloads today's spend once the cached copy is older than spendTTL.
+// On error the last known spend is kept, so a database blip does not stop
+// work.
+func (b *Budget) refresh() {
+	if b.spend != nil && time.Since(b.fetched) < spendTTL {
+		return
+	}
+
+	spend, err := b.Store.GetDailySpend()
+	if err != nil {
+		slog.Error("error loading LLM spend, using last known spend", "error", err)
+		if b.spend == nil {
+			b.spend = map[string]model.Spend{}
+		}
+	} else {
+		b.spend = spend
+	}
+	b.fetched = time.Now()
+}
+
+// Throttle slows a worker down as the providers its client calls approach
+// their daily budget. Past the soft limit calls go to Cheap, when set, and
+// articles from Defer publishers wait for the next day's budget; at the hard
+// limit the worker stops taking work.
+type Throttle struct {
+	Budget    *Budget
+	Providers []string
+	Cheap     llm.Client
+	Defer     []string
+}
+
+// NewThrottle holds clients built from cfg to budget. Past the soft limit
+// calls switch to cfg's BudgetModel, when one is set. It returns nil when
+// budget is nil.
+func NewThrottle(budget *Budget, cfg llm.Config, deferPublishers []string) (*Throttle, error) {
+	if budget == nil {
+		return nil, nil
+	}
+
+	t := &Throttle{Budget: budget, Providers: cfg.Providers(), Defer: deferPublishers}
+	if cheap, ok := cfg.ForBudget(); ok {
+		client, err := llm.New(cheap)
+		if err != nil {
+			return nil, fmt.Errorf("budget model: %w", err)
+		}
+		t.Cheap = client
+	}
+	return t, nil
+}
+
+// Level is the budget level of the throttled providers.
+func (t *Throttle) Level() string {
+	if t == nil || t.Budget == nil {
+		return model.BudgetOK
+	}
+	return t.Budget.Level(t.Providers)
+}
+
+// deferred reports whether article should wait for the budget to reset.
+// Only the publisher is checked, since the category comes from the transform.
+func (t *Throttle) deferred(article *model.OriginalArticle, level string) bool {
+	if t == nil || level == model.BudgetOK {
+		return false
+	}
+	for _, p := range t.Defer {
+		if strings.EqualFold(p, article.Publisher) {
+			return true
+		}
+	}
+	return false
+}
+
+// untilBudgetReset is the time left until midnight UTC, when daily spend
+// starts again from zero.
+func untilBudgetReset(now time.Time) time.Duration {
+	now = now.UTC()
+	return now.Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+}
@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+	"zennews/internal/model"
+	"zennews/pkg/llm"
+
+	"github.com/go-playground/assert/v2"
+)
+
+type fakeSpend map[string]model.Spend
+
+func (s fakeSpend) GetDailySpend() (map[string]model.Spend, error) {
+	return s, nil
+}
+
+// budgetClient is the fake client under another model name, standing in for
+// a throttle's cheaper model.
+type budgetClient struct {
+	llm.FakeClient
+}
+
+func (c *budgetClient) Transform(input llm.TransformInput) (*llm.TransformResult, error) {
+	result, err := c.FakeClient.Transform(input)
+	if err == nil {
+		result.ModelUsed = "fake-budget"
+	}
+	return result, err
+}
+
+func TestBudgetFromEnv(t *testing.T) {
+	b, err := BudgetFromEnv(fakeSpend{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, b == nil)
+
+	t.Setenv("LLM_BUDGET_OPENAI_USD", "12.5")
+	t.Setenv("LLM_BUDGET_ANTHROPIC_TOKENS", "2000000")
+	t.Setenv("LLM_BUDGET_SOFT_RATIO", "0.9")
+	b, err = BudgetFromEnv(fakeSpend{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, map[string]BudgetLimit{
+		llm.ProviderOpenAI:    {CostUSD: 12.5},
+		llm.ProviderAnthropic: {Tokens: 2000000},
+	}, b.Limits)
+	assert.Equal(t, 0.9, b.SoftRatio)
+
+	t.Setenv("LLM_BUDGET_SOFT_RATIO", "80")
+	_, err = BudgetFromEnv(fakeSpend{})
+	assert.NotEqual(t, nil, err)
+}
+
+func TestBudgetLevel(t *testing.T) {
+	b := &Budget{
+		Limits: map[string]BudgetLimit{
+			llm.ProviderOpenAI:    {CostUSD: 10},
+			llm.ProviderAnthropic: {CostUSD: 10, Tokens: 1000},
+		},
+		SoftRatio: 0.8,
+		Store: fakeSpend{
+			llm.ProviderOpenAI:    {CostUSD: 8.5, Tokens: 100000},
+			llm.ProviderAnthropic: {CostUSD: 1, Tokens: 1000},
+		},
+	}
+
+	assert.Equal(t, model.BudgetSoft, b.Level([]string{llm.ProviderOpenAI}))
+	assert.Equal(t, model.BudgetHard, b.Level([]string{llm.ProviderOpenAI, llm.ProviderAnthropic}))
+	assert.Equal(t, model.BudgetOK, b.Level([]string{llm.ProviderLocal}))
+
+	status := b.Status()
+	assert.Equal(t, 2, len(status))
+	assert.Equal(t, llm.ProviderAnthropic, status[0].Provider)
+	assert.Equal(t, model.BudgetHard, status[0].Level)
+	assert.Equal(t, 8.5, status[1].SpentUSD)
+}
+
+func TestUntilBudgetReset(t *testing.T) {
+	now := time.Date(2026, 3, 2, 22, 30, 0, 0, time.UTC)
+	assert.Equal(t, 90*time.Minute, untilBudgetReset(now))
+}
+
+func TestWorkerThrottle(t *testing.T) {
+	run := func(spent float64) (*memStore, *memQueue) {
+		store := newMemStore()
+		queue := newMemQueue()
+		fetchAll(store, newsFixture()[:1])
+		DrainOutbox(context.Background(), store, queue, 10)
+
+		budget := &Budget{
+			Limits:    map[string]BudgetLimit{llm.ProviderFake: {CostUSD: 10}},
+			SoftRatio: 0.8,
+			Store:     fakeSpend{llm.ProviderFake: {CostUSD: spent}},
+		}
+		w := &Worker{
+			ID:          "test-0",
+			Store:       store,
+			Client:      &llm.FakeClient{},
+			Queue:       queue,
+			DeadLetters: newMemQueue(),
+			Throttle: &Throttle{
+				Budget:    budget,
+				Providers: []string{llm.ProviderFake},
+				Cheap:     &budgetClient{},
+				Defer:     []string{"coindesk"},
+			},
+		}
+		w.Run(context.Background())
+		return store, queue
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		store, queue := run(1)
+		assert.Equal(t, 2, len(store.transformed))
+		assert.Equal(t, "fake", store.transformed[1].ModelUsed)
+		assert.Equal(t, 0, len(queue.delayed))
+	})
+
+	t.Run("soft limit", func(t *testing.T) {
+		store, queue := run(8)
+		assert.Equal(t, 1, len(store.transformed))
+		assert.Equal(t, "fake-budget", store.transformed[1].ModelUsed)
+		assert.Equal(t, 1, len(queue.delayed))
+		assert.Equal(t, model.StatusDeferred, store.articles[3].Status)
+	})
+
+	t.Run("hard limit", func(t *testing.T) {
+		store, queue := run(10)
+		assert.Equal(t, 0, len(store.transformed))
+		assert.Equal(t, 2, queue.len())
+	})
+}
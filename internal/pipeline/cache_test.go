@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"zennews/pkg/llm"
+	"zennews/pkg/news"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestWorkerReusesCachedTransforms(t *testing.T) {
+	store := newMemStore()
+	queue := newMemQueue()
+	wire := []news.Article{
+		{Headline: "Fed holds rates steady", Detail: "The Fed kept rates at 5.25% on Wednesday.",
+			URL: "https://example.com/ap/fed", Source: "finnhub", Publisher: "AP", PublishedAt: publishedAt},
+		{Headline: "Fed holds rates steady", Detail: "The Fed kept rates at 5.25% on Wednesday.",
+			URL: "https://example.com/yahoo/fed", Source: "finnhub", Publisher: "Yahoo", PublishedAt: publishedAt},
+	}
+	fetchAll(store, []news.NewsClient{&fakeNewsClient{name: "finnhub", articles: wire}})
+	DrainOutbox(context.Background(), store, queue, 10)
+
+	cfg := llm.Config{Provider: llm.ProviderFake, Cache: newMemCache()}
+	client, err := llm.New(cfg)
+	assert.Equal(t, nil, err)
+
+	w := &Worker{ID: "test-0", Store: store, Client: client, Queue: queue, DeadLetters: newMemQueue(), Usage: store}
+	w.Run(context.Background())
+
+	assert.Equal(t, 2, len(store.transformed))
+	assert.Equal(t, store.transformed[1].Headline, store.transformed[3].Headline)
+	assert.Equal(t, store.transformed[1].InputTokens, store.transformed[3].InputTokens)
+
+	// Only the first article was billed.
+	assert.Equal(t, 1, len(store.usage))
+	assert.Equal(t, int64(1), store.usage[0].ArticleID)
+}
+
+func TestWorkerForgetsRejectedTransforms(t *testing.T) {
+	store := newMemStore()
+	queue := newMemQueue()
+	fetchAll(store, newsFixture()[:1])
+	DrainOutbox(context.Background(), store, queue, 10)
+
+	cfg := llm.Config{Provider: llm.ProviderFake}
+	truncating := &truncatingClient{truncate: map[string]bool{"BREAKING: APPLE STOCK SOARS ON RECORD EARNINGS": true}}
+	cache := newMemCache()
+	client := llm.NewCachedClient(truncating, cache, cfg)
+
+	w := &Worker{ID: "test-0", Store: store, Client: client, Queue: queue, DeadLetters: newMemQueue(),
+		Validation: ValidationReject, Usage: store}
+	w.Run(context.Background())
+
+	// Only the accepted rewrite stays cached, so the retry asks the model
+	// again and is billed.
+	assert.Equal(t, 1, len(cache.entries))
+	queue.promote()
+	w.Run(context.Background())
+	assert.Equal(t, 3, len(store.usage))
+	assert.Equal(t, int64(1), store.usage[2].ArticleID)
+}
@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"zennews/pkg/llm"
+)
+
+// Experiment splits transforms between arms, each with its own client.
+type Experiment struct {
+	Name string
+	Arms []Arm
+}
+
+// Arm is one variant of an experiment. Weight is its share of articles
+// relative to the other arms.
+type Arm struct {
+	Name   string
+	Weight int
+	Client llm.LLMClient
+}
+
+// ParseArms parses "name[:weight],..." into arms without clients. Weights
+// default to 1.
+func ParseArms(spec string) ([]Arm, error) {
+	var arms []Arm
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, weight, hasWeight := strings.Cut(entry, ":")
+		arm := Arm{Name: strings.TrimSpace(name), Weight: 1}
+		if hasWeight {
+			w, err := strconv.Atoi(strings.TrimSpace(weight))
+			if err != nil || w < 1 {
+				return nil, fmt.Errorf("invalid weight %q for arm %q", weight, arm.Name)
+			}
+			arm.Weight = w
+		}
+
+		if arm.Name == "" || seen[arm.Name] {
+			return nil, fmt.Errorf("invalid or duplicate arm name %q", arm.Name)
+		}
+		seen[arm.Name] = true
+		arms = append(arms, arm)
+	}
+
+	if len(arms) < 2 {
+		return nil, fmt.Errorf("an experiment needs at least two arms, got %d", len(arms))
+	}
+	return arms, nil
+}
+
+// Assign picks the arm for an article. The choice depends only on the
+// experiment name and article ID, so retries and restarts keep an article in
+// the same arm while a new experiment reshuffles articles.
+func (e *Experiment) Assign(articleID int64) *Arm {
+	total := 0
+	for _, a := range e.Arms {
+		total += a.Weight
+	}
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s:%d", e.Name, articleID)
+	n := int(h.Sum64() % uint64(total))
+
+	for i := range e.Arms {
+		if n < e.Arms[i].Weight {
+			return &e.Arms[i]
+		}
+		n -= e.Arms[i].Weight
+	}
+	return &e.Arms[len(e.Arms)-1]
+}
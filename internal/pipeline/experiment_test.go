@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"zennews/internal/model"
+	"zennews/pkg/llm"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestParseArms(t *testing.T) {
+	arms, err := ParseArms("control:3, haiku")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []Arm{{Name: "control", Weight: 3}, {Name: "haiku", Weight: 1}}, arms)
+
+	for _, spec := range []string{"", "control", "a,a", "a:0,b", "a:x,b"} {
+		_, err := ParseArms(spec)
+		assert.NotEqual(t, nil, err)
+	}
+}
+
+func TestExperimentAssign(t *testing.T) {
+	e := &Experiment{Name: "haiku-vs-4o", Arms: []Arm{{Name: "control", Weight: 3}, {Name: "haiku", Weight: 1}}}
+
+	counts := map[string]int{}
+	for id := int64(1); id <= 4000; id++ {
+		arm := e.Assign(id)
+		assert.Equal(t, arm.Name, e.Assign(id).Name)
+		counts[arm.Name]++
+	}
+
+	// Roughly 3:1, allowing for hash noise.
+	assert.Equal(t, true, counts["control"] > 2700 && counts["control"] < 3300)
+	assert.Equal(t, 4000, counts["control"]+counts["haiku"])
+
+	reshuffled := &Experiment{Name: "prompt-v2", Arms: e.Arms}
+	moved := 0
+	for id := int64(1); id <= 100; id++ {
+		if e.Assign(id).Name != reshuffled.Assign(id).Name {
+			moved++
+		}
+	}
+	assert.NotEqual(t, 0, moved)
+}
+
+func TestWorkerRecordsExperimentArm(t *testing.T) {
+	store := newMemStore()
+	queue := newMemQueue()
+
+	v2, err := llm.NewPromptSet([]llm.Prompt{{ID: 2, Name: llm.PromptTransform, Version: "v2", Body: "new prompt"}})
+	assert.Equal(t, nil, err)
+
+	control := &llm.FakeClient{}
+	treatment := &llm.FakeClient{Prompts: v2, Errors: map[string]error{
+		"Apple and Microsoft lead tech rally": &llm.Error{Class: llm.ErrorServer, Err: errors.New("overloaded")},
+	}}
+	experiment := &Experiment{Name: "prompt-v2", Arms: []Arm{
+		{Name: "control", Weight: 1, Client: control},
+		{Name: "v2", Weight: 1, Client: treatment},
+	}}
+
+	fetchAll(store, newsFixture())
+	DrainOutbox(context.Background(), store, queue, 10)
+
+	w := &Worker{ID: "test-0", Store: store, Client: &llm.FakeClient{}, Experiment: experiment, Queue: queue, DeadLetters: newMemQueue()}
+	w.Run(context.Background())
+
+	for id, a := range store.transformed {
+		arm := experiment.Assign(id)
+		assert.Equal(t, "prompt-v2", a.Experiment)
+		assert.Equal(t, arm.Name, a.Arm)
+		assert.Equal(t, map[string]string{"control": "v1", "v2": "v2"}[arm.Name], a.PromptVersion)
+		assert.Equal(t, true, a.InputTokens > 0)
+	}
+	assert.Equal(t, len(store.transformed)+len(store.errors), control.Calls()+treatment.Calls())
+
+	assert.Equal(t, 1, len(store.errors))
+	for _, e := range store.errors {
+		assert.Equal(t, model.ProcessingError{ID: e.ID, ArticleId: e.ArticleId, ErrorMessage: e.ErrorMessage,
+			ErrorType: "server_error", AttemptCount: 1, Experiment: "prompt-v2", Arm: "v2"}, e)
+	}
+}
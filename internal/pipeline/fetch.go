@@ -0,0 +1,242 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+	"zennews/internal/model"
+	"zennews/pkg/news"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// ArticleStore saves fetched articles and drains the transform outbox.
+type ArticleStore interface {
+	SaveOriginalWithSymbols(article *model.OriginalArticle, symbols []string) (bool, error)
+	GetPendingOutbox(limit int) ([]model.OutboxEntry, error)
+	MarkOutboxEnqueued(ids []int64) error
+}
+
+// Enqueuer pushes article IDs onto a queue.
+type Enqueuer interface {
+	Push(ctx context.Context, id string) error
+}
+
+// SourceStore keeps where each news source's last fetch left off and a
+// record of every fetch.
+type SourceStore interface {
+	GetSourceState(source string) (*model.SourceState, error)
+	SaveSourceState(state *model.SourceState) error
+	SaveFetchRun(run *model.FetchRun) error
+}
+
+// QuotaStore remembers which sources are out of quota until when.
+type QuotaStore interface {
+	GetQuotaReset(source string) (time.Time, error)
+	SaveQuotaReset(source string, until time.Time) error
+}
+
+// Fetcher fetches up to Limit new articles from each news source, picking up
+// where its last fetch left off, and saves them. Sources are fetched
+// concurrently, at most Parallel at a time when it is positive, and each is
+// cancelled after Timeout when it is positive. A failing source is logged and
+// recorded so the others still run. With Quotas set, a source that hit a
+// rate limit or quota is skipped, and its run recorded as failed, until the
+// limit resets.
+type Fetcher struct {
+	Store    ArticleStore
+	Sources  SourceStore
+	Quotas   QuotaStore
+	Limit    int
+	Parallel int
+	Timeout  time.Duration
+}
+
+// Run fetches from every client and returns the run of each, in order.
+func (f *Fetcher) Run(ctx context.Context, clients []news.NewsClient) []model.FetchRun {
+	runs := make([]model.FetchRun, len(clients))
+
+	var g errgroup.Group
+	if f.Parallel > 0 {
+		g.SetLimit(f.Parallel)
+	}
+	for i, client := range clients {
+		g.Go(func() error {
+			runs[i] = f.fetch(ctx, client)
+			return nil
+		})
+	}
+	g.Wait()
+
+	return runs
+}
+
+// fetch fetches and saves one source's new articles and records the run. Its
+// state only moves on once every article is saved, so articles that failed
+// to save are fetched again next time. Articles a failed fetch returned are
+// saved too, moving the watermark up to them but keeping the cursor.
+func (f *Fetcher) fetch(ctx context.Context, client news.NewsClient) (run model.FetchRun) {
+	source := client.Name()
+	run = model.FetchRun{Source: source, StartedAt: time.Now()}
+	defer f.record(&run)
+
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+
+	if until := f.quotaReset(source); until.After(run.StartedAt) {
+		slog.Warn("source out of quota, skipping", "source", source, "until", until)
+		run.Error = fmt.Sprintf("skipped: out of quota until %s", until.UTC().Format(time.RFC3339))
+		return run
+	}
+
+	state, err := f.Sources.GetSourceState(source)
+	if err != nil {
+		slog.Error("error getting source state, fetching the latest articles", "source", source, "error", err)
+	}
+	if state == nil {
+		state = &model.SourceState{Source: source}
+	}
+
+	req := news.FetchRequest{Cursor: state.Cursor, Limit: f.Limit}
+	if state.LastPublishedAt != nil {
+		req.Since = *state.LastPublishedAt
+	}
+
+	fetchedArticles, cursor, err := client.Fetch(ctx, req)
+	if err != nil {
+		cursor = req.Cursor
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s: %w", f.Timeout, err)
+		}
+		slog.Error("error fetching articles", "source", source, "error", err)
+		run.Error = err.Error()
+
+		var limitErr *news.LimitError
+		if errors.As(err, &limitErr) && f.Quotas != nil {
+			if err := f.Quotas.SaveQuotaReset(source, limitErr.Until); err != nil {
+				slog.Error("error saving quota reset", "source", source, "error", err)
+			}
+		}
+		if len(fetchedArticles) == 0 {
+			return run
+		}
+	}
+	run.Fetched = len(fetchedArticles)
+
+	newest := req.Since
+	now := time.Now()
+
+	for _, a := range fetchedArticles {
+		article := model.OriginalArticle{
+			Headline:    a.Headline,
+			Detail:      a.Detail,
+			URL:         a.URL,
+			Source:      a.Source,
+			Publisher:   a.Publisher,
+			PublishedAt: a.PublishedAt,
+			ExternalID:  a.ExternalID,
+		}
+
+		// A publish time in the future would hold back everything until then.
+		if a.PublishedAt.After(newest) && !a.PublishedAt.After(now) {
+			newest = a.PublishedAt
+		}
+
+		success, err := f.Store.SaveOriginalWithSymbols(&article, a.Symbols)
+		if err != nil {
+			slog.Error("error saving article", "source", source, "error", err)
+			run.Errors++
+			continue
+		}
+
+		if !success {
+			slog.Info("duplicate article skipped", "source", source, "url", a.URL)
+			run.Duplicated++
+			continue
+		}
+
+		run.Saved++
+	}
+
+	slog.Info("fetch complete", "source", source, "saved", run.Saved, "duplicated", run.Duplicated, "errors", run.Errors,
+		"since", req.Since, "cursor", cursor)
+
+	if run.Errors > 0 {
+		return run
+	}
+
+	state.Cursor = cursor
+	if !newest.IsZero() {
+		state.LastPublishedAt = &newest
+	}
+	if err := f.Sources.SaveSourceState(state); err != nil {
+		slog.Error("error saving source state", "source", source, "error", err)
+	}
+	return run
+}
+
+// quotaReset returns when source's quota resets, or the zero time when it
+// is not out of quota or Quotas is unset. Errors are logged and the source
+// is fetched.
+func (f *Fetcher) quotaReset(source string) time.Time {
+	if f.Quotas == nil {
+		return time.Time{}
+	}
+	until, err := f.Quotas.GetQuotaReset(source)
+	if err != nil {
+		slog.Error("error getting quota reset, fetching anyway", "source", source, "error", err)
+	}
+	return until
+}
+
+// record finishes run and saves it.
+func (f *Fetcher) record(run *model.FetchRun) {
+	run.FinishedAt = time.Now()
+	if err := f.Sources.SaveFetchRun(run); err != nil {
+		slog.Error("error saving fetch run", "source", run.Source, "error", err)
+	}
+}
+
+// DrainOutbox pushes every undrained outbox entry onto the transform queue.
+// Delivery is at-least-once: a crash between the push and the mark can enqueue
+// an article twice, which the transformer tolerates by skipping completed articles.
+func DrainOutbox(ctx context.Context, store ArticleStore, queue Enqueuer, batchSize int) (int, error) {
+	total := 0
+	for {
+		entries, err := store.GetPendingOutbox(batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		if len(entries) == 0 {
+			return total, nil
+		}
+
+		ids := make([]int64, 0, len(entries))
+		var pushErr error
+		for _, e := range entries {
+			pushErr = queue.Push(ctx, strconv.FormatInt(e.ArticleID, 10))
+			if pushErr != nil {
+				break
+			}
+			ids = append(ids, e.ID)
+		}
+
+		if len(ids) > 0 {
+			if err := store.MarkOutboxEnqueued(ids); err != nil {
+				return total, err
+			}
+			total += len(ids)
+		}
+
+		if pushErr != nil {
+			return total, pushErr
+		}
+	}
+}
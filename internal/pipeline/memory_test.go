@@ -0,0 +1,546 @@
+package pipeline
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+	"zennews/internal/model"
+)
+
+// memStore is an in-memory stand-in for the Postgres repositories.
+type memStore struct {
+	mu          sync.Mutex
+	nextID      int64
+	articles    map[int64]*model.OriginalArticle
+	byURL       map[string]int64
+	symbols     map[int64][]string
+	outbox      []model.OutboxEntry
+	transformed map[int64]model.TransformedArticle
+	inactive    []model.TransformedArticle
+	errors      []model.ProcessingError
+	categories  []model.Category
+	summaries   []model.NewsSummary
+	stories     map[int64][]model.NewsStory
+	prompts     []model.Prompt
+	usage       []model.LLMUsage
+	batches     []model.TransformBatch
+	sources     map[string]model.SourceState
+	runs        []model.FetchRun
+	quotas      map[string]time.Time
+}
+
+func newMemStore() *memStore {
+	s := &memStore{
+		articles:    map[int64]*model.OriginalArticle{},
+		byURL:       map[string]int64{},
+		symbols:     map[int64][]string{},
+		transformed: map[int64]model.TransformedArticle{},
+		stories:     map[int64][]model.NewsStory{},
+		sources:     map[string]model.SourceState{},
+		quotas:      map[string]time.Time{},
+	}
+	for i, name := range []string{"Earnings", "Market Movement", "Economy", "Crypto", "Mergers & Acquisitions",
+		"Policy & Regulation", "Company News", "Analysis", model.OthersCategory} {
+		s.categories = append(s.categories, model.Category{ID: int64(i + 1), Name: name})
+	}
+	return s
+}
+
+func (s *memStore) id() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *memStore) SaveOriginalWithSymbols(article *model.OriginalArticle, symbols []string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.byURL[article.URL]; ok {
+		return false, nil
+	}
+
+	article.ID = s.id()
+	article.Status = model.StatusPending
+	stored := *article
+	s.articles[article.ID] = &stored
+	s.byURL[article.URL] = article.ID
+	s.symbols[article.ID] = symbols
+	s.outbox = append(s.outbox, model.OutboxEntry{ID: s.id(), ArticleID: article.ID, CreatedAt: time.Now()})
+	return true, nil
+}
+
+func (s *memStore) GetPendingOutbox(limit int) ([]model.OutboxEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []model.OutboxEntry
+	for _, e := range s.outbox {
+		if e.EnqueuedAt.IsZero() && len(entries) < limit {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (s *memStore) MarkOutboxEnqueued(ids []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		for i := range s.outbox {
+			if s.outbox[i].ID == id {
+				s.outbox[i].EnqueuedAt = time.Now()
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memStore) GetOriginalByID(id int64) (*model.OriginalArticle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.articles[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *a
+	return &copied, nil
+}
+
+func (s *memStore) GetAttemptCount(id int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, e := range s.errors {
+		if e.ArticleId == id && e.AttemptCount > count {
+			count = e.AttemptCount
+		}
+	}
+	return count, nil
+}
+
+func (s *memStore) GetClassAttemptCount(id int64, errorType string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, e := range s.errors {
+		if e.ArticleId == id && e.ErrorType == errorType {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memStore) UpdateStatus(id int64, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a, ok := s.articles[id]; ok {
+		a.Status = status
+	}
+	return nil
+}
+
+func (s *memStore) GetCategoryByName(name string) (*model.Category, error) {
+	for _, c := range s.categories {
+		if c.Name == name {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memStore) SaveTransformedAndComplete(article *model.TransformedArticle, originalID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	article.ID = s.id()
+	article.IsActive = true
+	s.transformed[originalID] = *article
+	s.articles[originalID].Status = model.StatusCompleted
+	return nil
+}
+
+func (s *memStore) SaveTransformed(article *model.TransformedArticle) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, ok := s.transformed[article.OriginalID]; ok {
+		old.IsActive = false
+		s.inactive = append(s.inactive, old)
+	}
+	article.ID = s.id()
+	article.IsActive = true
+	s.transformed[article.OriginalID] = *article
+	return nil
+}
+
+func (s *memStore) GetRetransformIDs(filter model.RetransformFilter) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int64
+	for id, t := range s.transformed {
+		if filter.PromptVersion != "" && t.PromptVersion != filter.PromptVersion {
+			continue
+		}
+		if filter.ModelUsed != "" && t.ModelUsed != filter.ModelUsed {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (s *memStore) SaveError(e *model.ProcessingError) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.ID = s.id()
+	s.errors = append(s.errors, *e)
+	return nil
+}
+
+func (s *memStore) GetLastErrors(ids []int64) (map[int64]model.ProcessingError, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last := map[int64]model.ProcessingError{}
+	for _, e := range s.errors {
+		last[e.ArticleId] = e
+	}
+	return last, nil
+}
+
+func (s *memStore) GetSymbolsByOriginalIDs(ids []int64) (map[int64][]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := map[int64][]string{}
+	for _, id := range ids {
+		if symbols := s.symbols[id]; len(symbols) > 0 {
+			result[id] = symbols
+		}
+	}
+	return result, nil
+}
+
+func (s *memStore) GetSymbolsByOriginalID(id int64) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.symbols[id], nil
+}
+
+func (s *memStore) GetLastToArticleID() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var last int64
+	for _, summary := range s.summaries {
+		last = max(last, summary.ToArticleID)
+	}
+	return last, nil
+}
+
+func (s *memStore) GetArticlesForSummary(fromID int64) ([]model.OriginalArticle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var articles []model.OriginalArticle
+	for id, a := range s.articles {
+		if id > fromID {
+			articles = append(articles, *a)
+		}
+	}
+	sort.Slice(articles, func(i, j int) bool { return articles[i].ID < articles[j].ID })
+	return articles, nil
+}
+
+func (s *memStore) SaveSummary(summary *model.NewsSummary) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary.ID = s.id()
+	s.summaries = append(s.summaries, *summary)
+	return nil
+}
+
+func (s *memStore) SaveStories(summaryID int64, stories []model.NewsStory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stories[summaryID] = stories
+	return nil
+}
+
+func (s *memStore) GetActivePrompts() ([]model.Prompt, error) {
+	var active []model.Prompt
+	for _, p := range s.prompts {
+		if p.Active {
+			active = append(active, p)
+		}
+	}
+	return active, nil
+}
+
+func (s *memStore) GetPrompt(name, version string) (*model.Prompt, error) {
+	for _, p := range s.prompts {
+		if p.Name == name && p.Version == version {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memStore) SaveUsage(u *model.LLMUsage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u.ID = s.id()
+	s.usage = append(s.usage, *u)
+	return nil
+}
+
+func (s *memStore) GetPendingForBatch(limit int) ([]model.OriginalArticle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failed := map[int64]bool{}
+	for _, e := range s.errors {
+		failed[e.ArticleId] = true
+	}
+
+	var articles []model.OriginalArticle
+	for id, a := range s.articles {
+		if a.Status == model.StatusPending && !failed[id] {
+			articles = append(articles, *a)
+		}
+	}
+	sort.Slice(articles, func(i, j int) bool { return articles[i].ID < articles[j].ID })
+	if len(articles) > limit {
+		articles = articles[:limit]
+	}
+	return articles, nil
+}
+
+func (s *memStore) SaveBatch(b *model.TransformBatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b.ID = s.id()
+	b.CreatedAt = time.Now()
+	s.batches = append(s.batches, *b)
+	for _, id := range b.ArticleIDs {
+		if a := s.articles[id]; a.Status == model.StatusPending {
+			a.Status = model.StatusBatched
+		}
+	}
+	return nil
+}
+
+func (s *memStore) GetOpenBatches() ([]model.TransformBatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var open []model.TransformBatch
+	for _, b := range s.batches {
+		if b.Status == model.BatchSubmitted {
+			open = append(open, b)
+		}
+	}
+	return open, nil
+}
+
+func (s *memStore) UpdateBatch(b *model.TransformBatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.batches {
+		if s.batches[i].ID == b.ID {
+			s.batches[i] = *b
+		}
+	}
+	return nil
+}
+
+func (s *memStore) ReleaseBatched(ids []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		if a := s.articles[id]; a != nil && a.Status == model.StatusBatched {
+			a.Status = model.StatusPending
+			s.outbox = append(s.outbox, model.OutboxEntry{ID: s.id(), ArticleID: id, CreatedAt: time.Now()})
+		}
+	}
+	return nil
+}
+
+func (s *memStore) GetSourceState(source string) (*model.SourceState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.sources[source]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func (s *memStore) SaveSourceState(state *model.SourceState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state.UpdatedAt = time.Now()
+	s.sources[state.Source] = *state
+	return nil
+}
+
+func (s *memStore) SaveFetchRun(run *model.FetchRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Runs are numbered apart from articles so fetching does not shift
+	// article IDs.
+	run.ID = int64(len(s.runs) + 1)
+	s.runs = append(s.runs, *run)
+	return nil
+}
+
+func (s *memStore) GetQuotaReset(source string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.quotas[source], nil
+}
+
+func (s *memStore) SaveQuotaReset(source string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.quotas[source] = until
+	return nil
+}
+
+// memQueue is an in-memory WorkQueue. Pop never blocks, so a non-daemon
+// worker exits as soon as the queue is empty.
+type memQueue struct {
+	mu       sync.Mutex
+	items    []string
+	inFlight map[string]bool
+	delayed  map[string]time.Duration
+}
+
+func newMemQueue() *memQueue {
+	return &memQueue{inFlight: map[string]bool{}, delayed: map[string]time.Duration{}}
+}
+
+func (q *memQueue) Push(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.items = append(q.items, id)
+	return nil
+}
+
+func (q *memQueue) Pop(ctx context.Context, timeout time.Duration) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) == 0 {
+		return "", nil
+	}
+	id := q.items[0]
+	q.items = q.items[1:]
+	q.inFlight[id] = true
+	return id, nil
+}
+
+func (q *memQueue) TryPop(ctx context.Context) (string, error) {
+	return q.Pop(ctx, 0)
+}
+
+func (q *memQueue) Ack(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.inFlight, id)
+	return nil
+}
+
+func (q *memQueue) Nack(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.inFlight, id)
+	q.items = append(q.items, id)
+	return nil
+}
+
+func (q *memQueue) Retry(ctx context.Context, id string, delay time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.inFlight, id)
+	q.delayed[id] = delay
+	return nil
+}
+
+// promote moves every delayed ID back onto the queue, as if their delays had
+// all elapsed.
+func (q *memQueue) promote() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for id := range q.delayed {
+		q.items = append(q.items, id)
+		delete(q.delayed, id)
+	}
+}
+
+func (q *memQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.items)
+}
+
+// memCache is an in-memory llm.ResponseCache.
+type memCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func newMemCache() *memCache {
+	return &memCache{entries: map[string][]byte{}}
+}
+
+func (c *memCache) Get(key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, ok := c.entries[key]
+	return value, ok, nil
+}
+
+func (c *memCache) Set(key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = value
+	return nil
+}
+
+func (c *memCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+	return nil
+}
@@ -0,0 +1,471 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"zennews/internal/model"
+	"zennews/pkg/llm"
+	"zennews/pkg/news"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// fakeNewsClient returns its articles published after the request's Since
+// and records the requests it received.
+type fakeNewsClient struct {
+	name     string
+	articles []news.Article
+	err      error
+	partial  int
+	requests []news.FetchRequest
+}
+
+func (f *fakeNewsClient) Fetch(ctx context.Context, req news.FetchRequest) ([]news.Article, string, error) {
+	f.requests = append(f.requests, req)
+	if f.err != nil {
+		return f.articles[:f.partial], req.Cursor, f.err
+	}
+
+	var articles []news.Article
+	for _, a := range f.articles {
+		if a.PublishedAt.After(req.Since) && len(articles) < req.Limit {
+			articles = append(articles, a)
+		}
+	}
+	return articles, fmt.Sprintf("%s-%d", f.name, len(f.requests)), nil
+}
+
+func (f *fakeNewsClient) Name() string {
+	return f.name
+}
+
+var publishedAt = time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+
+func newsFixture() []news.NewsClient {
+	return []news.NewsClient{
+		&fakeNewsClient{name: "finnhub", articles: []news.Article{
+			{Headline: "BREAKING: APPLE STOCK SOARS ON RECORD EARNINGS", Detail: "Apple revenue rose 8% to $124 billion.",
+				URL: "https://example.com/apple", Source: "finnhub", Publisher: "Reuters", PublishedAt: publishedAt, Symbols: []string{"AAPL"}},
+			{Headline: "Bitcoin tanks below $60,000 in crazy selloff", Detail: "Bitcoin fell 9% on Monday.",
+				URL: "https://example.com/bitcoin", Source: "finnhub", Publisher: "CoinDesk", PublishedAt: publishedAt.Add(time.Hour), Symbols: []string{"BTC"}},
+		}},
+		&fakeNewsClient{name: "marketaux", err: errors.New("quota exceeded")},
+		&fakeNewsClient{name: "massive", articles: []news.Article{
+			{Headline: "Apple and Microsoft lead tech rally", Detail: "Shares of both companies will likely extend gains.",
+				URL: "https://example.com/tech", Source: "massive", Publisher: "CNBC", PublishedAt: publishedAt.Add(30 * time.Minute), Symbols: []string{"AAPL", "MSFT"}},
+			{Headline: "BREAKING: APPLE STOCK SOARS ON RECORD EARNINGS", Detail: "Duplicate of the FinnHub story.",
+				URL: "https://example.com/apple", Source: "massive", Publisher: "Reuters", PublishedAt: publishedAt, Symbols: []string{"AAPL"}},
+		}},
+	}
+}
+
+func TestPipelineEndToEnd(t *testing.T) {
+	store := newMemStore()
+	queue := newMemQueue()
+	deadLetters := newMemQueue()
+	client := &llm.FakeClient{}
+
+	fetchAll(store, newsFixture())
+	assert.Equal(t, 3, len(store.articles))
+
+	enqueued, err := DrainOutbox(context.Background(), store, queue, 2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, enqueued)
+
+	// A second drain finds nothing new.
+	enqueued, err = DrainOutbox(context.Background(), store, queue, 2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, enqueued)
+
+	w := &Worker{ID: "test-0", Store: store, Client: client, Queue: queue, DeadLetters: deadLetters, Usage: store}
+	w.Run(context.Background())
+
+	assert.Equal(t, 0, queue.len())
+	assert.Equal(t, 0, len(queue.inFlight))
+	assert.Equal(t, 3, len(store.transformed))
+
+	apple := store.transformed[1]
+	assert.Equal(t, "Apple stock rose on record earnings", apple.Headline)
+	assert.Equal(t, "fake", apple.ModelUsed)
+	assert.Equal(t, int64(1), apple.CategoryID) // Earnings
+
+	bitcoin := store.transformed[3]
+	assert.Equal(t, "Bitcoin dropped below $60,000 in selloff", bitcoin.Headline)
+	assert.Equal(t, int64(4), bitcoin.CategoryID) // Crypto
+
+	for _, a := range store.articles {
+		assert.Equal(t, model.StatusCompleted, a.Status)
+	}
+
+	assert.Equal(t, 3, len(store.usage))
+	assert.Equal(t, model.StageTransform, store.usage[0].Stage)
+	assert.Equal(t, int64(1), store.usage[0].ArticleID)
+	assert.Equal(t, "fake", store.usage[0].Model)
+	assert.Equal(t, apple.InputTokens, store.usage[0].InputTokens)
+
+	summary, stories, err := Summarize(store, store, store, client)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, summary.ArticleCount)
+	assert.Equal(t, "fake", summary.ModelUsed)
+	assert.Equal(t, 2, len(stories))
+	assert.Equal(t, []string{"AAPL", "MSFT"}, stories[0].Tickers)
+	assert.Equal(t, []string{"CNBC", "Reuters"}, stories[0].Publishers)
+	assert.Equal(t, []string{"BTC"}, stories[1].Tickers)
+
+	assert.Equal(t, 4, len(store.usage))
+	assert.Equal(t, model.StageSummary, store.usage[3].Stage)
+	assert.Equal(t, summary.ID, store.usage[3].SummaryID)
+	assert.Equal(t, true, store.usage[3].InputTokens > 0)
+
+	// Nothing new since the last summary.
+	summary, _, err = Summarize(store, store, store, client)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, summary == nil)
+}
+
+// fetchAll fetches the clients one at a time so article IDs are
+// deterministic.
+func fetchAll(store *memStore, clients []news.NewsClient) []model.FetchRun {
+	f := &Fetcher{Store: store, Sources: store, Limit: 50, Parallel: 1}
+	return f.Run(context.Background(), clients)
+}
+
+func TestFetcherIsIncremental(t *testing.T) {
+	store := newMemStore()
+	finnhub := &fakeNewsClient{name: "finnhub", articles: []news.Article{
+		{Headline: "Fed holds rates", URL: "https://example.com/fed", PublishedAt: publishedAt},
+		{Headline: "Oil rises", URL: "https://example.com/oil", PublishedAt: publishedAt.Add(time.Hour)},
+		{Headline: "Gold slips", URL: "https://example.com/gold", PublishedAt: publishedAt.Add(2 * time.Hour)},
+	}}
+	failing := &fakeNewsClient{name: "marketaux", err: errors.New("quota exceeded")}
+	clients := []news.NewsClient{finnhub, failing}
+	f := &Fetcher{Store: store, Sources: store, Limit: 2}
+
+	runs := f.Run(context.Background(), clients)
+	assert.Equal(t, 2, len(store.articles))
+	assert.Equal(t, true, finnhub.requests[0].Since.IsZero())
+	assert.Equal(t, publishedAt.Add(time.Hour), *store.sources["finnhub"].LastPublishedAt)
+	assert.Equal(t, "finnhub-1", store.sources["finnhub"].Cursor)
+
+	// A failed fetch leaves no state behind.
+	_, ok := store.sources["marketaux"]
+	assert.Equal(t, false, ok)
+
+	// Every fetch is recorded, in client order.
+	assert.Equal(t, 2, len(store.runs))
+	assert.Equal(t, "finnhub", runs[0].Source)
+	assert.Equal(t, 2, runs[0].Fetched)
+	assert.Equal(t, 2, runs[0].Saved)
+	assert.Equal(t, "", runs[0].Error)
+	assert.Equal(t, "marketaux", runs[1].Source)
+	assert.Equal(t, "quota exceeded", runs[1].Error)
+	assert.Equal(t, false, runs[1].FinishedAt.Before(runs[1].StartedAt))
+
+	// The next fetch asks only for what is newer.
+	runs = f.Run(context.Background(), clients)
+	assert.Equal(t, publishedAt.Add(time.Hour), finnhub.requests[1].Since)
+	assert.Equal(t, "finnhub-1", finnhub.requests[1].Cursor)
+	assert.Equal(t, 3, len(store.articles))
+	assert.Equal(t, publishedAt.Add(2*time.Hour), *store.sources["finnhub"].LastPublishedAt)
+	assert.Equal(t, 1, runs[0].Saved)
+	assert.Equal(t, 4, len(store.runs))
+}
+
+func TestFetcherSavesPartialFetches(t *testing.T) {
+	store := newMemStore()
+	store.sources["marketaux"] = model.SourceState{Source: "marketaux", Cursor: "page-1"}
+	marketaux := &fakeNewsClient{name: "marketaux", partial: 2, err: errors.New("rate limited on page 3"), articles: []news.Article{
+		{Headline: "Fed holds rates", URL: "https://example.com/fed", PublishedAt: publishedAt},
+		{Headline: "Oil rises", URL: "https://example.com/oil", PublishedAt: publishedAt.Add(time.Hour)},
+		{Headline: "Gold slips", URL: "https://example.com/gold", PublishedAt: publishedAt.Add(2 * time.Hour)},
+	}}
+	f := &Fetcher{Store: store, Sources: store, Limit: 50}
+
+	runs := f.Run(context.Background(), []news.NewsClient{marketaux})
+	assert.Equal(t, 2, len(store.articles))
+	assert.Equal(t, 2, runs[0].Saved)
+	assert.Equal(t, "rate limited on page 3", runs[0].Error)
+
+	// The watermark moves up to what was saved, the cursor stays.
+	assert.Equal(t, publishedAt.Add(time.Hour), *store.sources["marketaux"].LastPublishedAt)
+	assert.Equal(t, "page-1", store.sources["marketaux"].Cursor)
+}
+
+func TestFetcherSkipsSourcesOutOfQuota(t *testing.T) {
+	store := newMemStore()
+	reset := time.Now().Add(time.Hour)
+	limited := &fakeNewsClient{name: "alphavantage", err: fmt.Errorf("fetching news: %w",
+		&news.LimitError{Source: "AlphaVantage", Quota: true, Until: reset})}
+	f := &Fetcher{Store: store, Sources: store, Quotas: store, Limit: 50}
+
+	runs := f.Run(context.Background(), []news.NewsClient{limited})
+	assert.Equal(t, 1, len(limited.requests))
+	assert.Equal(t, reset, store.quotas["alphavantage"])
+	assert.NotEqual(t, "", runs[0].Error)
+
+	// Until the quota resets the source is not asked again, but the skip is
+	// recorded.
+	runs = f.Run(context.Background(), []news.NewsClient{limited})
+	assert.Equal(t, 1, len(limited.requests))
+	assert.Equal(t, true, strings.HasPrefix(runs[0].Error, "skipped: out of quota until"))
+	assert.Equal(t, 2, len(store.runs))
+
+	store.quotas["alphavantage"] = time.Now().Add(-time.Minute)
+	f.Run(context.Background(), []news.NewsClient{limited})
+	assert.Equal(t, 2, len(limited.requests))
+}
+
+// slowNewsClient blocks until its fetch is cancelled.
+type slowNewsClient struct{}
+
+func (slowNewsClient) Fetch(ctx context.Context, req news.FetchRequest) ([]news.Article, string, error) {
+	<-ctx.Done()
+	return nil, req.Cursor, ctx.Err()
+}
+
+func (slowNewsClient) Name() string {
+	return "slow"
+}
+
+func TestFetcherTimesOutSlowSources(t *testing.T) {
+	store := newMemStore()
+	clients := append([]news.NewsClient{slowNewsClient{}}, newsFixture()[:1]...)
+	f := &Fetcher{Store: store, Sources: store, Limit: 50, Parallel: 2, Timeout: 50 * time.Millisecond}
+
+	runs := f.Run(context.Background(), clients)
+	assert.Equal(t, true, strings.HasPrefix(runs[0].Error, "timed out after 50ms"))
+	_, ok := store.sources["slow"]
+	assert.Equal(t, false, ok)
+
+	// The other source is unaffected.
+	assert.Equal(t, "", runs[1].Error)
+	assert.Equal(t, 2, runs[1].Saved)
+	assert.Equal(t, 2, len(store.articles))
+}
+
+func TestWorkerRetriesAndDeadLetters(t *testing.T) {
+	store := newMemStore()
+	queue := newMemQueue()
+	deadLetters := newMemQueue()
+	client := &llm.FakeClient{Errors: map[string]error{
+		"Bitcoin tanks below $60,000 in crazy selloff": &llm.Error{Class: llm.ErrorRefusal, Err: errors.New("refused")},
+		"Apple and Microsoft lead tech rally":          &llm.Error{Class: llm.ErrorServer, Err: errors.New("503")},
+	}}
+
+	fetchAll(store, newsFixture())
+	_, err := DrainOutbox(context.Background(), store, queue, 10)
+	assert.Equal(t, nil, err)
+
+	w := &Worker{ID: "test-0", Store: store, Client: client, Queue: queue, DeadLetters: deadLetters}
+	w.Run(context.Background())
+
+	// The refusal is dead-lettered straight away.
+	assert.Equal(t, model.StatusFailed, store.articles[3].Status)
+	assert.Equal(t, []string{"3"}, deadLetters.items)
+
+	// The server error is scheduled for a retry.
+	_, scheduled := queue.delayed["5"]
+	assert.Equal(t, true, scheduled)
+	assert.Equal(t, model.StatusProcessing, store.articles[5].Status)
+
+	// Once the provider recovers the retry succeeds.
+	delete(client.Errors, "Apple and Microsoft lead tech rally")
+	queue.promote()
+	w.Run(context.Background())
+
+	assert.Equal(t, model.StatusCompleted, store.articles[5].Status)
+	assert.Equal(t, 2, len(store.transformed))
+
+	attempts, _ := store.GetAttemptCount(5)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, "server_error", store.errors[1].ErrorType)
+}
+
+func TestWorkerCountsAttemptsPerErrorClass(t *testing.T) {
+	store := newMemStore()
+	queue := newMemQueue()
+	deadLetters := newMemQueue()
+	client := &llm.FakeClient{Errors: map[string]error{
+		"BREAKING: APPLE STOCK SOARS ON RECORD EARNINGS": &llm.Error{Class: llm.ErrorParse, Err: errors.New("bad JSON")},
+	}}
+
+	fetchAll(store, newsFixture()[:1])
+	DrainOutbox(context.Background(), store, queue, 10)
+	queue.items = []string{"1"}
+	for i := range 3 {
+		store.SaveError(&model.ProcessingError{ArticleId: 1, ErrorType: string(llm.ErrorRateLimit), AttemptCount: i + 1})
+	}
+
+	w := &Worker{ID: "test-0", Store: store, Client: client, Queue: queue, DeadLetters: deadLetters}
+	w.Run(context.Background())
+
+	// The first parse error is retried although the article failed before.
+	_, scheduled := queue.delayed["1"]
+	assert.Equal(t, true, scheduled)
+	assert.Equal(t, 0, len(deadLetters.items))
+	assert.Equal(t, 4, store.errors[3].AttemptCount)
+
+	// The second one exhausts the parse error policy.
+	queue.promote()
+	w.Run(context.Background())
+	assert.Equal(t, []string{"1"}, deadLetters.items)
+	assert.Equal(t, model.StatusFailed, store.articles[1].Status)
+}
+
+func TestWorkerRecordsUsageOfFailedCalls(t *testing.T) {
+	store := newMemStore()
+	queue := newMemQueue()
+	billed := llm.Usage{Provider: "fake", Model: "fake", InputTokens: 300, OutputTokens: 80}
+	client := &llm.FakeClient{Errors: map[string]error{
+		"Bitcoin tanks below $60,000 in crazy selloff": &llm.UsageError{Usage: billed,
+			Err: &llm.Error{Class: llm.ErrorParse, Err: errors.New("invalid JSON")}},
+	}}
+
+	fetchAll(store, newsFixture()[:1])
+	DrainOutbox(context.Background(), store, queue, 10)
+
+	w := &Worker{ID: "test-0", Store: store, Client: client, Queue: queue, DeadLetters: newMemQueue(), Usage: store}
+	w.Run(context.Background())
+
+	assert.Equal(t, 2, len(store.usage))
+	assert.Equal(t, int64(3), store.usage[1].ArticleID)
+	assert.Equal(t, int64(300), store.usage[1].InputTokens)
+	assert.Equal(t, "parse_error", store.errors[0].ErrorType)
+}
+
+func TestWorkerSkipsCompletedArticles(t *testing.T) {
+	store := newMemStore()
+	queue := newMemQueue()
+	client := &llm.FakeClient{}
+
+	fetchAll(store, newsFixture()[:1])
+	DrainOutbox(context.Background(), store, queue, 10)
+	queue.Push(context.Background(), "1")
+
+	w := &Worker{ID: "test-0", Store: store, Client: client, Queue: queue, DeadLetters: newMemQueue()}
+	w.Run(context.Background())
+
+	assert.Equal(t, 2, client.Calls())
+	assert.Equal(t, 2, len(store.transformed))
+}
+
+func TestWorkerReturnsArticlePoppedAtShutdown(t *testing.T) {
+	store := newMemStore()
+	queue := newMemQueue()
+	client := &llm.FakeClient{}
+
+	fetchAll(store, newsFixture()[:1])
+	DrainOutbox(context.Background(), store, queue, 10)
+
+	// memQueue pops even with ctx cancelled, like a BLMOVE that won the race.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := &Worker{ID: "test-0", Store: store, Client: client, Queue: queue, DeadLetters: newMemQueue()}
+	w.Run(ctx)
+
+	// The popped article went to the back of the queue.
+	assert.Equal(t, 0, client.Calls())
+	assert.Equal(t, []string{"3", "1"}, queue.items)
+	assert.Equal(t, 0, len(queue.inFlight))
+}
+
+func TestWorkerPacksArticles(t *testing.T) {
+	store := newMemStore()
+	queue := newMemQueue()
+	client := &llm.FakeClient{Errors: map[string]error{
+		"Apple and Microsoft lead tech rally": &llm.Error{Class: llm.ErrorServer, Err: errors.New("503")},
+	}}
+
+	fetchAll(store, newsFixture())
+	DrainOutbox(context.Background(), store, queue, 10)
+	queue.Push(context.Background(), "1")
+
+	w := &Worker{ID: "test-0", Store: store, Client: client, Queue: queue, DeadLetters: newMemQueue(), Usage: store, Pack: 10}
+	w.Run(context.Background())
+
+	// One call for the pack and one for the article that fell back.
+	assert.Equal(t, 2, client.Calls())
+	assert.Equal(t, 2, len(store.transformed))
+	assert.Equal(t, "Bitcoin dropped below $60,000 in selloff", store.transformed[3].Headline)
+	assert.Equal(t, 2, len(store.usage))
+	assert.Equal(t, 0, len(queue.inFlight))
+
+	_, scheduled := queue.delayed["5"]
+	assert.Equal(t, true, scheduled)
+	assert.Equal(t, 1, len(store.errors))
+}
+
+func TestWorkerRecordsActivePrompt(t *testing.T) {
+	store := newMemStore()
+	store.prompts = []model.Prompt{
+		{ID: 1, Name: llm.PromptTransform, Version: "v1", Body: "old prompt"},
+		{ID: 5, Name: llm.PromptTransform, Version: "v2", Body: "new prompt", Active: true},
+	}
+	queue := newMemQueue()
+
+	prompts, err := LoadPrompts(store)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "new prompt", prompts.Transform.Body)
+	assert.Equal(t, llm.DefaultPrompts().Summary, prompts.Summary)
+
+	fetchAll(store, newsFixture()[:1])
+	DrainOutbox(context.Background(), store, queue, 10)
+
+	w := &Worker{ID: "test-0", Store: store, Client: &llm.FakeClient{Prompts: prompts}, Queue: queue, DeadLetters: newMemQueue()}
+	w.Run(context.Background())
+
+	assert.Equal(t, 2, len(store.transformed))
+	for _, a := range store.transformed {
+		assert.Equal(t, "v2", a.PromptVersion)
+		assert.Equal(t, int64(5), a.PromptID)
+	}
+
+	store.prompts = append(store.prompts, model.Prompt{ID: 6, Name: "headline", Version: "v1", Body: "x", Active: true})
+	_, err = LoadPrompts(store)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestRetransform(t *testing.T) {
+	store := newMemStore()
+	queue := newMemQueue()
+
+	fetchAll(store, newsFixture())
+	DrainOutbox(context.Background(), store, queue, 10)
+	w := &Worker{ID: "test-0", Store: store, Client: &llm.FakeClient{}, Queue: queue, DeadLetters: newMemQueue()}
+	w.Run(context.Background())
+
+	// A pending article is left to the normal transformer.
+	store.articles[1].Status = model.StatusPending
+	first := store.transformed[3]
+
+	prompts, err := llm.NewPromptSet([]llm.Prompt{{ID: 2, Name: llm.PromptTransform, Version: "v2", Body: "new prompt"}})
+	assert.Equal(t, nil, err)
+
+	retransformQueue := newMemQueue()
+	queued, err := EnqueueRetransform(context.Background(), store, retransformQueue, model.RetransformFilter{PromptVersion: "v1"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, len(store.transformed), queued)
+
+	client := &llm.FakeClient{Prompts: prompts}
+	rw := &Worker{ID: "retransform-0", Store: store, Client: client, Queue: retransformQueue, Retransform: true}
+	rw.Run(context.Background())
+
+	assert.Equal(t, queued-1, client.Calls())
+	assert.Equal(t, 0, retransformQueue.len())
+	assert.Equal(t, "v1", store.transformed[1].PromptVersion)
+	assert.Equal(t, model.StatusPending, store.articles[1].Status)
+
+	current := store.transformed[3]
+	assert.Equal(t, "v2", current.PromptVersion)
+	assert.Equal(t, int64(2), current.PromptID)
+	assert.Equal(t, true, current.IsActive)
+	assert.Equal(t, model.StatusCompleted, store.articles[3].Status)
+
+	assert.Equal(t, queued-1, len(store.inactive))
+	assert.Equal(t, first.ID, store.inactive[0].ID)
+	assert.Equal(t, false, store.inactive[0].IsActive)
+}
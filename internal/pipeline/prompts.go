@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"fmt"
+	"zennews/internal/model"
+	"zennews/pkg/llm"
+)
+
+// PromptStore loads stored prompt versions.
+type PromptStore interface {
+	GetActivePrompts() ([]model.Prompt, error)
+	GetPrompt(name, version string) (*model.Prompt, error)
+}
+
+// LoadPrompts builds the prompt set from the prompt table. Prompts with no
+// active row fall back to the built-in defaults.
+func LoadPrompts(store PromptStore) (llm.PromptSet, error) {
+	rows, err := store.GetActivePrompts()
+	if err != nil {
+		return llm.PromptSet{}, fmt.Errorf("error loading prompts: %w", err)
+	}
+
+	prompts := make([]llm.Prompt, len(rows))
+	for i, p := range rows {
+		prompts[i] = llm.Prompt{ID: p.ID, Name: p.Name, Version: p.Version, Body: p.Body}
+	}
+	return llm.NewPromptSet(prompts)
+}
+
+// WithTransformPrompt returns prompts with the transform prompt replaced by
+// the stored version, for experiment arms that pin a prompt.
+func WithTransformPrompt(store PromptStore, prompts llm.PromptSet, version string) (llm.PromptSet, error) {
+	p, err := store.GetPrompt(llm.PromptTransform, version)
+	if err != nil {
+		return prompts, fmt.Errorf("error loading transform prompt %s: %w", version, err)
+	}
+	if p == nil {
+		return prompts, fmt.Errorf("transform prompt %s not found", version)
+	}
+
+	prompts.Transform = llm.Prompt{ID: p.ID, Name: p.Name, Version: p.Version, Body: p.Body}
+	return prompts, nil
+}
@@ -0,0 +1,29 @@
+package pipeline
+
+import (
+	"context"
+	"strconv"
+	"zennews/internal/model"
+)
+
+// RetransformStore selects articles whose active version should be
+// regenerated.
+type RetransformStore interface {
+	GetRetransformIDs(filter model.RetransformFilter) ([]int64, error)
+}
+
+// EnqueueRetransform pushes every article matching filter onto queue for a
+// retransform worker and returns how many were queued.
+func EnqueueRetransform(ctx context.Context, store RetransformStore, queue Enqueuer, filter model.RetransformFilter) (int, error) {
+	ids, err := store.GetRetransformIDs(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, id := range ids {
+		if err := queue.Push(ctx, strconv.FormatInt(id, 10)); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
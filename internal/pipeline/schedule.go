@@ -0,0 +1,240 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Embedded so market hours work in images without a zoneinfo database.
+	_ "time/tzdata"
+)
+
+// Schedule says when a source is next due.
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// marketTZ is the NYSE's time zone. Cron schedules are evaluated in it too.
+var marketTZ = mustLoadLocation("America/New_York")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+const (
+	marketOpenMinute  = 9*60 + 30
+	marketCloseMinute = 16 * 60
+)
+
+// MarketOpen reports whether the NYSE regular session, 9:30 to 16:00 New
+// York time on weekdays, is open at t. Exchange holidays are not known and
+// count as trading days.
+func MarketOpen(t time.Time) bool {
+	t = t.In(marketTZ)
+	if isWeekend(t) {
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	return minute >= marketOpenMinute && minute < marketCloseMinute
+}
+
+// nextMarketOpen returns the start of the next session after t.
+func nextMarketOpen(t time.Time) time.Time {
+	t = t.In(marketTZ)
+	for day := 0; ; day++ {
+		open := time.Date(t.Year(), t.Month(), t.Day()+day, 9, 30, 0, 0, marketTZ)
+		if open.After(t) && !isWeekend(open) {
+			return open
+		}
+	}
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// Interval is due a fixed time after the last run.
+type Interval time.Duration
+
+func (d Interval) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(d))
+}
+
+// MarketSchedule is due every Session while the NYSE is open, every OffHours
+// outside the session on weekdays and every Weekend on weekends, but no later
+// than the next open, so the session starts with fresh news.
+type MarketSchedule struct {
+	Session  time.Duration
+	OffHours time.Duration
+	Weekend  time.Duration
+}
+
+func (m MarketSchedule) Next(after time.Time) time.Time {
+	if MarketOpen(after) {
+		return after.Add(m.Session)
+	}
+
+	next := after.Add(m.OffHours)
+	if isWeekend(after.In(marketTZ)) {
+		next = after.Add(m.Weekend)
+	}
+	if open := nextMarketOpen(after); open.Before(next) {
+		return open
+	}
+	return next
+}
+
+// ParseSchedule parses a source's schedule: an interval such as 15m, or a
+// five-field cron expression (minute, hour, day of month, month, day of week)
+// in New York time such as "*/5 9-16 * * 1-5". Cron fields take *, numbers,
+// ranges, steps and comma-separated lists.
+func ParseSchedule(s string) (Schedule, error) {
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(s); err == nil {
+		if d <= 0 {
+			return nil, fmt.Errorf("schedule %q: interval must be positive", s)
+		}
+		return Interval(d), nil
+	}
+
+	c, err := parseCron(s)
+	if err != nil {
+		return nil, fmt.Errorf("schedule %q: %w", s, err)
+	}
+	if c.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("schedule %q never runs", s)
+	}
+	return c, nil
+}
+
+// cronSchedule holds the allowed values of each cron field as bit sets.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// With both day fields restricted a day matching either is due, as in
+	// cron; otherwise it must match both.
+	anyDay bool
+}
+
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, errors.New("want an interval or a cron expression with 5 fields")
+	}
+
+	sets := make([]uint64, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cronFields[i].name, err)
+		}
+		sets[i] = set
+	}
+
+	// Sunday is both 0 and 7.
+	dow := sets[4]
+	if dow&(1<<7) != 0 {
+		dow |= 1
+	}
+
+	return &cronSchedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    dow &^ (1 << 7),
+		anyDay: strings.HasPrefix(fields[2], "*") || strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(loStr); err != nil {
+				return 0, fmt.Errorf("invalid value %q", loStr)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiStr); err != nil {
+					return 0, fmt.Errorf("invalid value %q", hiStr)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// Next returns the first minute after after that matches, or the zero time
+// if none does within five years.
+func (c *cronSchedule) Next(after time.Time) time.Time {
+	t := after.In(marketTZ).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !has(c.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, marketTZ)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, marketTZ)
+		case !has(c.hour, t.Hour()):
+			t = t.Add(time.Hour).Truncate(time.Hour)
+		case !has(c.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom, dow := has(c.dom, t.Day()), has(c.dow, int(t.Weekday()))
+	if c.anyDay {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<v) != 0
+}
@@ -0,0 +1,70 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// nyTime is a time on the New York wall clock. 2 March 2026 is a Monday.
+func nyTime(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2026, month, day, hour, minute, 0, 0, marketTZ)
+}
+
+func TestMarketOpen(t *testing.T) {
+	assert.Equal(t, false, MarketOpen(nyTime(time.March, 2, 9, 29)))
+	assert.Equal(t, true, MarketOpen(nyTime(time.March, 2, 9, 30)))
+	assert.Equal(t, true, MarketOpen(nyTime(time.March, 2, 15, 59).UTC()))
+	assert.Equal(t, false, MarketOpen(nyTime(time.March, 2, 16, 0)))
+	assert.Equal(t, false, MarketOpen(nyTime(time.March, 7, 12, 0)))
+}
+
+func TestMarketSchedule(t *testing.T) {
+	m := MarketSchedule{Session: 5 * time.Minute, OffHours: 30 * time.Minute, Weekend: 2 * time.Hour}
+
+	tests := []struct {
+		after, want time.Time
+	}{
+		{nyTime(time.March, 2, 10, 0), nyTime(time.March, 2, 10, 5)},
+		{nyTime(time.March, 2, 8, 30), nyTime(time.March, 2, 9, 0)},
+		{nyTime(time.March, 2, 9, 10), nyTime(time.March, 2, 9, 30)},
+		{nyTime(time.March, 6, 16, 0), nyTime(time.March, 6, 16, 30)},
+		{nyTime(time.March, 7, 10, 0), nyTime(time.March, 7, 12, 0)},
+		{nyTime(time.March, 8, 23, 0), nyTime(time.March, 9, 1, 0)},
+		{nyTime(time.March, 9, 8, 0), nyTime(time.March, 9, 8, 30)},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want.UTC(), m.Next(tt.after).UTC())
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	s, err := ParseSchedule("15m")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, Interval(15*time.Minute), s)
+
+	tests := []struct {
+		expr        string
+		after, want time.Time
+	}{
+		{"*/15 * * * *", nyTime(time.March, 2, 10, 7), nyTime(time.March, 2, 10, 15)},
+		{"*/15 * * * *", nyTime(time.March, 2, 10, 45), nyTime(time.March, 2, 11, 0)},
+		// From Friday to Monday, across the switch to daylight saving time.
+		{"30 9 * * 1-5", nyTime(time.March, 6, 10, 0), nyTime(time.March, 9, 9, 30)},
+		// Restricting both day fields matches either.
+		{"0 12 15 * 5", nyTime(time.March, 2, 13, 0), nyTime(time.March, 6, 12, 0)},
+		{"0 8 * * 7", nyTime(time.March, 2, 13, 0), nyTime(time.March, 8, 8, 0)},
+		{"0 0 1 1 *", nyTime(time.March, 2, 13, 0), time.Date(2027, time.January, 1, 0, 0, 0, 0, marketTZ)},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.expr)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, tt.want.UTC(), s.Next(tt.after).UTC())
+	}
+
+	for _, expr := range []string{"", "-5m", "* * * *", "61 * * * *", "*/0 * * * *", "5-1 * * * *", "0 9 * * MON", "0 0 31 2 *"} {
+		_, err := ParseSchedule(expr)
+		assert.NotEqual(t, nil, err)
+	}
+}
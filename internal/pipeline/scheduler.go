@@ -0,0 +1,190 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+	"zennews/internal/model"
+	"zennews/pkg/news"
+)
+
+// Leader is a lock held by one process at a time. Acquire takes or renews it
+// and reports whether this process holds it.
+type Leader interface {
+	Acquire(ctx context.Context) (bool, error)
+	Release(ctx context.Context) error
+}
+
+// TriggerQueue delivers the names of sources to fetch now, or
+// model.AllSources.
+type TriggerQueue interface {
+	TryPop(ctx context.Context) (string, error)
+	Ack(ctx context.Context, id string) error
+}
+
+// ScheduledSource is a news source and when to fetch it.
+type ScheduledSource struct {
+	Client   news.NewsClient
+	Schedule Schedule
+}
+
+// maxTriggers caps how many triggers one tick takes.
+const maxTriggers = 100
+
+// Scheduler fetches each source on its schedule for as long as it holds the
+// leader lock, so only one of several replicas fetches. Every Tick it renews
+// the lock, takes manual triggers and fetches the sources that are due, then
+// drains the transform outbox onto Queue. Each source's next run is put off
+// by a random delay of up to Jitter so sources on the same schedule do not
+// all fetch at once. A new leader fetches every source straight away.
+type Scheduler struct {
+	Fetcher     *Fetcher
+	Sources     []ScheduledSource
+	Leader      Leader
+	Triggers    TriggerQueue
+	Queue       Enqueuer
+	OutboxBatch int
+	Tick        time.Duration
+	Jitter      time.Duration
+
+	next    []time.Time
+	leading bool
+}
+
+// Run schedules fetches until ctx is cancelled, then gives up the lock.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		s.step(ctx, time.Now())
+		if !sleepCtx(ctx, s.Tick) {
+			break
+		}
+	}
+
+	slog.Info("shutdown requested, scheduler stopping")
+	if s.leading {
+		if err := s.Leader.Release(context.Background()); err != nil {
+			slog.Error("error releasing scheduler lock", "error", err)
+		}
+	}
+}
+
+// step runs one tick at now.
+func (s *Scheduler) step(ctx context.Context, now time.Time) {
+	if !s.lead(ctx) {
+		return
+	}
+	s.takeTriggers(ctx)
+
+	var due []int
+	var clients []news.NewsClient
+	for i, source := range s.Sources {
+		if !s.next[i].After(now) {
+			due = append(due, i)
+			clients = append(clients, source.Client)
+		}
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	s.fetch(ctx, clients)
+
+	for _, i := range due {
+		s.next[i] = s.Sources[i].Schedule.Next(now)
+		if s.Jitter > 0 {
+			s.next[i] = s.next[i].Add(rand.N(s.Jitter))
+		}
+		slog.Info("source fetch scheduled", "source", s.Sources[i].Client.Name(), "next", s.next[i])
+	}
+
+	enqueued, err := DrainOutbox(ctx, s.Fetcher.Store, s.Queue, s.OutboxBatch)
+	if err != nil {
+		slog.Error("error draining transform outbox", "enqueued", enqueued, "error", err)
+		return
+	}
+	slog.Info("transform outbox drained", "enqueued", enqueued)
+}
+
+// lead acquires or renews the lock, making every source due when this
+// process becomes the leader.
+func (s *Scheduler) lead(ctx context.Context) bool {
+	held, err := s.Leader.Acquire(ctx)
+	if err != nil {
+		slog.Error("error acquiring scheduler lock", "error", err)
+		held = false
+	}
+
+	switch {
+	case held && !s.leading:
+		slog.Info("scheduler became leader, fetching every source")
+		s.next = make([]time.Time, len(s.Sources))
+	case !held && s.leading:
+		slog.Warn("scheduler lost leadership, pausing fetches")
+	}
+	s.leading = held
+	return held
+}
+
+// fetch runs the fetcher while renewing the lock every Tick, cancelling the
+// fetches if the lock is lost so two replicas never fetch at once for long.
+func (s *Scheduler) fetch(ctx context.Context, clients []news.NewsClient) {
+	fetchCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.Tick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-fetchCtx.Done():
+				return
+			case <-ticker.C:
+				held, err := s.Leader.Acquire(fetchCtx)
+				if fetchCtx.Err() != nil {
+					return
+				}
+				if err != nil || !held {
+					slog.Warn("scheduler lost leadership during fetch, cancelling it", "error", err)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	s.Fetcher.Run(fetchCtx, clients)
+	cancel()
+	<-done
+}
+
+// takeTriggers makes the sources named on the trigger queue due now.
+func (s *Scheduler) takeTriggers(ctx context.Context) {
+	for range maxTriggers {
+		name, err := s.Triggers.TryPop(ctx)
+		if err != nil {
+			slog.Error("error reading fetch triggers", "error", err)
+			return
+		}
+		if name == "" {
+			return
+		}
+		if err := s.Triggers.Ack(ctx, name); err != nil {
+			slog.Error("error acking fetch trigger", "error", err, "source", name)
+		}
+
+		found := false
+		for i, source := range s.Sources {
+			if name == model.AllSources || name == source.Client.Name() {
+				s.next[i] = time.Time{}
+				found = true
+			}
+		}
+		if !found {
+			slog.Warn("fetch triggered for an unknown source", "source", name)
+			continue
+		}
+		slog.Info("fetch triggered", "source", name)
+	}
+}
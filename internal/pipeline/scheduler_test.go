@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+	"zennews/internal/model"
+
+	"github.com/go-playground/assert/v2"
+)
+
+type fakeLeader struct {
+	held     bool
+	released int
+}
+
+func (l *fakeLeader) Acquire(ctx context.Context) (bool, error) {
+	return l.held, nil
+}
+
+func (l *fakeLeader) Release(ctx context.Context) error {
+	l.held = false
+	l.released++
+	return nil
+}
+
+func TestScheduler(t *testing.T) {
+	store := newMemStore()
+	queue := newMemQueue()
+	triggers := newMemQueue()
+	leader := &fakeLeader{held: true}
+	clients := newsFixture()
+	finnhub, massive := clients[0].(*fakeNewsClient), clients[2].(*fakeNewsClient)
+
+	s := &Scheduler{
+		Fetcher: &Fetcher{Store: store, Sources: store, Limit: 50, Parallel: 1},
+		Sources: []ScheduledSource{
+			{Client: finnhub, Schedule: Interval(5 * time.Minute)},
+			{Client: massive, Schedule: Interval(time.Hour)},
+		},
+		Leader:      leader,
+		Triggers:    triggers,
+		Queue:       queue,
+		OutboxBatch: 10,
+		Tick:        time.Hour,
+	}
+	ctx := context.Background()
+	now := publishedAt.Add(2 * time.Hour)
+
+	// A new leader fetches every source and drains the outbox.
+	s.step(ctx, now)
+	assert.Equal(t, 1, len(finnhub.requests))
+	assert.Equal(t, 1, len(massive.requests))
+	assert.Equal(t, 3, queue.len())
+
+	// Each source is then fetched on its own schedule.
+	s.step(ctx, now.Add(time.Minute))
+	assert.Equal(t, 1, len(finnhub.requests))
+	s.step(ctx, now.Add(5*time.Minute))
+	assert.Equal(t, 2, len(finnhub.requests))
+	assert.Equal(t, 1, len(massive.requests))
+
+	// A trigger makes its source due at once.
+	triggers.Push(ctx, "massive")
+	triggers.Push(ctx, "bloomberg")
+	s.step(ctx, now.Add(6*time.Minute))
+	assert.Equal(t, 2, len(finnhub.requests))
+	assert.Equal(t, 2, len(massive.requests))
+	assert.Equal(t, 0, triggers.len())
+	assert.Equal(t, 0, len(triggers.inFlight))
+
+	// A follower fetches nothing, even when sources are due.
+	leader.held = false
+	s.step(ctx, now.Add(2*time.Hour))
+	assert.Equal(t, 2, len(finnhub.requests))
+	assert.Equal(t, 4, len(store.runs))
+
+	// Becoming the leader again fetches every source.
+	leader.held = true
+	s.step(ctx, now.Add(2*time.Hour+time.Minute))
+	assert.Equal(t, 3, len(finnhub.requests))
+	assert.Equal(t, 3, len(massive.requests))
+
+	triggers.Push(ctx, model.AllSources)
+	s.step(ctx, now.Add(2*time.Hour+2*time.Minute))
+	assert.Equal(t, 4, len(finnhub.requests))
+	assert.Equal(t, 4, len(massive.requests))
+	assert.Equal(t, 8, len(store.runs))
+}
+
+func TestSchedulerReleasesLockOnShutdown(t *testing.T) {
+	store := newMemStore()
+	leader := &fakeLeader{held: true}
+	s := &Scheduler{
+		Fetcher:  &Fetcher{Store: store, Sources: store, Limit: 50, Parallel: 1},
+		Sources:  []ScheduledSource{{Client: newsFixture()[0], Schedule: Interval(time.Minute)}},
+		Leader:   leader,
+		Triggers: newMemQueue(),
+		Queue:    newMemQueue(),
+		Tick:     time.Hour,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Run(ctx)
+
+	assert.Equal(t, 1, leader.released)
+}
@@ -0,0 +1,103 @@
+package pipeline
+
+import (
+	"fmt"
+	"zennews/internal/model"
+	"zennews/pkg/llm"
+)
+
+// SummaryStore loads the articles since the last summary and saves new ones.
+type SummaryStore interface {
+	GetLastToArticleID() (int64, error)
+	GetArticlesForSummary(fromID int64) ([]model.OriginalArticle, error)
+	SaveSummary(summary *model.NewsSummary) error
+	SaveStories(summaryID int64, stories []model.NewsStory) error
+}
+
+// SymbolStore batch-loads the symbols tagged on articles.
+type SymbolStore interface {
+	GetSymbolsByOriginalIDs(ids []int64) (map[int64][]string, error)
+}
+
+// Summarize clusters every article since the previous summary into stories
+// and saves them, recording the call's token usage when usage is non-nil. It
+// returns a nil summary when there is nothing new.
+func Summarize(summaries SummaryStore, symbols SymbolStore, usage UsageStore, client llm.ClusterSummarizer) (*model.NewsSummary, []model.NewsStory, error) {
+	fromID, err := summaries.GetLastToArticleID()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting last summary article id: %w", err)
+	}
+
+	articles, err := summaries.GetArticlesForSummary(fromID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching articles for summary: %w", err)
+	}
+
+	if len(articles) == 0 {
+		return nil, nil, nil
+	}
+
+	articleIDs := make([]int64, len(articles))
+	for i, a := range articles {
+		articleIDs[i] = a.ID
+	}
+	symbolsMap, err := symbols.GetSymbolsByOriginalIDs(articleIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching symbols: %w", err)
+	}
+
+	inputs := make([]llm.SummaryInput, len(articles))
+	for i, a := range articles {
+		inputs[i] = llm.SummaryInput{
+			ID:          a.ID,
+			Headline:    a.Headline,
+			Detail:      a.Detail,
+			Publisher:   a.Publisher,
+			PublishedAt: a.PublishedAt,
+			Symbols:     symbolsMap[a.ID],
+		}
+	}
+
+	result, err := client.ClusterAndSummarize(inputs)
+	if err != nil {
+		if billed, ok := llm.BilledUsage(err); ok {
+			recordUsage(usage, model.StageSummary, 0, 0, billed)
+		}
+		return nil, nil, fmt.Errorf("error generating cluster summary: %w", err)
+	}
+
+	summary := &model.NewsSummary{
+		Paragraph:     "",
+		Bullets:       []string{},
+		ArticleCount:  len(articles),
+		FromArticleID: articles[0].ID,
+		ToArticleID:   articles[len(articles)-1].ID,
+		ModelUsed:     result.ModelUsed,
+	}
+
+	err = summaries.SaveSummary(summary)
+	if err != nil {
+		recordUsage(usage, model.StageSummary, 0, 0, result.Usage)
+		return nil, nil, fmt.Errorf("error saving summary: %w", err)
+	}
+	recordUsage(usage, model.StageSummary, 0, summary.ID, result.Usage)
+
+	stories := make([]model.NewsStory, len(result.Stories))
+	for i, s := range result.Stories {
+		stories[i] = model.NewsStory{
+			Headline:   s.Headline,
+			Summary:    s.Summary,
+			Angles:     s.Angles,
+			Tickers:    s.Tickers,
+			Publishers: s.Publishers,
+			TimeRange:  s.TimeRange,
+		}
+	}
+
+	err = summaries.SaveStories(summary.ID, stories)
+	if err != nil {
+		return summary, nil, fmt.Errorf("error saving stories: %w", err)
+	}
+
+	return summary, stories, nil
+}
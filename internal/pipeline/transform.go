@@ -0,0 +1,599 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+	"zennews/internal/facts"
+	"zennews/internal/model"
+	"zennews/pkg/llm"
+)
+
+const (
+	popTimeout    = 10 * time.Second
+	errorBackoff  = 5 * time.Second
+	budgetRecheck = time.Minute
+)
+
+// ackCtx is used for queue updates about an article that was already popped,
+// which must still land after shutdown has been requested.
+var ackCtx = context.Background()
+
+// TransformStore is the article storage the transformer needs.
+type TransformStore interface {
+	GetOriginalByID(id int64) (*model.OriginalArticle, error)
+	GetAttemptCount(id int64) (int, error)
+	GetClassAttemptCount(id int64, errorType string) (int, error)
+	UpdateStatus(id int64, status string) error
+	GetCategoryByName(name string) (*model.Category, error)
+	SaveTransformed(article *model.TransformedArticle) error
+	SaveTransformedAndComplete(article *model.TransformedArticle, originalID int64) error
+	SaveError(e *model.ProcessingError) error
+	GetLastErrors(ids []int64) (map[int64]model.ProcessingError, error)
+	GetSymbolsByOriginalID(id int64) ([]string, error)
+}
+
+// WorkQueue is a reliable queue: popped IDs stay in flight until they are
+// acked, nacked back onto the queue or scheduled for a delayed retry. TryPop
+// is Pop without waiting.
+type WorkQueue interface {
+	Pop(ctx context.Context, timeout time.Duration) (string, error)
+	TryPop(ctx context.Context) (string, error)
+	Ack(ctx context.Context, id string) error
+	Nack(ctx context.Context, id string) error
+	Retry(ctx context.Context, id string, delay time.Duration) error
+}
+
+// Worker transforms articles popped from Queue with Client, or with the
+// client of the article's arm when an Experiment is running. In Retransform
+// mode it regenerates completed articles instead, storing the result as their
+// new active version. Validation controls the fact check on each rewrite,
+// Usage, when set, records the tokens of every call and Throttle, when set,
+// holds the worker to the daily LLM budget. With Pack above one, articles
+// already waiting on the queue are transformed up to Pack per call, outside
+// experiments and retransforms.
+type Worker struct {
+	ID          string
+	Store       TransformStore
+	Client      llm.LLMClient
+	Experiment  *Experiment
+	Queue       WorkQueue
+	DeadLetters Enqueuer
+	Daemon      bool
+	Retransform bool
+	Validation  ValidationMode
+	Usage       UsageStore
+	Throttle    *Throttle
+	Pack        int
+}
+
+// call is one Transform of an article, tagged with its experiment arm and,
+// once validated, the fact check of its result.
+type call struct {
+	client     llm.LLMClient
+	experiment string
+	arm        string
+	result     *llm.TransformResult
+	latency    time.Duration
+	check      *facts.Result
+}
+
+// transform runs the article through its arm's client, or Client outside an
+// experiment. Past the soft budget limit the throttle's cheaper client takes
+// over and the call is left out of the experiment.
+func (w *Worker) transform(article *model.OriginalArticle) (call, error) {
+	c := call{}
+	client := w.Client
+	if cheap := w.cheapClient(); cheap != nil {
+		client = cheap
+	} else if w.Experiment != nil {
+		arm := w.Experiment.Assign(article.ID)
+		c.experiment, c.arm, client = w.Experiment.Name, arm.Name, arm.Client
+	}
+
+	c.client = client
+	start := time.Now()
+	result, err := client.Transform(llm.TransformInput{
+		Headline: article.Headline,
+		Detail:   article.Detail,
+	})
+	c.result, c.latency = result, time.Since(start)
+	w.recordTransform(article.ID, result, err)
+	return c, err
+}
+
+// transformPack runs articles through a single TransformBatch call, returning
+// a call and an error for each. Every call reports the latency of the whole
+// pack.
+func (w *Worker) transformPack(articles []*model.OriginalArticle) ([]call, []error) {
+	client := w.Client
+	if cheap := w.cheapClient(); cheap != nil {
+		client = cheap
+	}
+
+	inputs := make([]llm.TransformInput, len(articles))
+	for i, article := range articles {
+		inputs[i] = llm.TransformInput{Headline: article.Headline, Detail: article.Detail}
+	}
+
+	start := time.Now()
+	results, errs := client.TransformBatch(inputs)
+	latency := time.Since(start)
+
+	calls := make([]call, len(articles))
+	for i, article := range articles {
+		calls[i] = call{client: client, result: results[i], latency: latency}
+		w.recordTransform(article.ID, results[i], errs[i])
+	}
+	return calls, errs
+}
+
+// cheapClient returns the throttle's cheaper client past the soft budget
+// limit, or nil.
+func (w *Worker) cheapClient() llm.LLMClient {
+	if w.Throttle != nil && w.Throttle.Cheap != nil && w.Throttle.Level() == model.BudgetSoft {
+		return w.Throttle.Cheap
+	}
+	return nil
+}
+
+// recordTransform records the usage of a transform, including a failed one
+// that was billed. A cached result was paid for when it was first made.
+func (w *Worker) recordTransform(articleID int64, result *llm.TransformResult, err error) {
+	stage := model.StageTransform
+	if w.Retransform {
+		stage = model.StageRetransform
+	}
+
+	if err != nil {
+		if usage, ok := llm.BilledUsage(err); ok {
+			recordUsage(w.Usage, stage, articleID, 0, usage)
+		}
+		return
+	}
+	if result.Cached {
+		slog.Info("reusing cached transform", "article_id", articleID, "worker", w.ID)
+		return
+	}
+	recordUsage(w.Usage, stage, articleID, 0, result.Usage)
+}
+
+// Run pops and processes articles until ctx is cancelled or, outside daemon
+// mode, until the queue stays empty for popTimeout or the daily LLM budget is
+// exhausted. Cancelling ctx only stops the worker from taking new work; an
+// article already popped is finished.
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		if w.Throttle.Level() == model.BudgetHard {
+			if !w.Daemon {
+				slog.Warn("daily LLM budget exhausted, worker exiting", "worker", w.ID)
+				return
+			}
+			if !sleepCtx(ctx, budgetRecheck) {
+				slog.Info("shutdown requested, worker stopping", "worker", w.ID)
+				return
+			}
+			continue
+		}
+
+		id, err := w.Queue.Pop(ctx, popTimeout)
+		if ctx.Err() != nil {
+			// An article popped as shutdown began goes back for another
+			// worker rather than waiting out its lease.
+			if id != "" {
+				w.Queue.Nack(ackCtx, id)
+			}
+			slog.Info("shutdown requested, worker stopping", "worker", w.ID)
+			return
+		}
+
+		if err != nil {
+			slog.Error("error popping from Redis queue", "error", err, "worker", w.ID)
+			if !w.Daemon || !sleepCtx(ctx, errorBackoff) {
+				return
+			}
+			continue
+		}
+
+		if id == "" {
+			if w.Daemon {
+				continue
+			}
+			slog.Info("Queue is empty, worker exiting", "worker", w.ID)
+			return
+		}
+
+		var pause time.Duration
+		if ids := w.fill(ctx, id); len(ids) > 1 {
+			pause = w.processPack(ids)
+		} else {
+			pause = w.Process(id)
+		}
+		if pause > 0 {
+			sleepCtx(ctx, pause)
+		}
+	}
+}
+
+// fill adds up to Pack-1 IDs that are already waiting on the queue to id, so
+// a deep queue is transformed several articles per call.
+func (w *Worker) fill(ctx context.Context, id string) []string {
+	ids := []string{id}
+	if w.Retransform || w.Experiment != nil {
+		return ids
+	}
+
+	for len(ids) < w.Pack {
+		next, err := w.Queue.TryPop(ctx)
+		if err != nil {
+			slog.Error("error popping from Redis queue", "error", err, "worker", w.ID)
+			break
+		}
+		if next == "" {
+			break
+		}
+		ids = append(ids, next)
+	}
+	return ids
+}
+
+// Process transforms a single article and acks, nacks or schedules a retry for
+// it. It returns how long the worker should pause before popping again, which
+// is non-zero only when the provider is rate limiting us.
+func (w *Worker) Process(id string) time.Duration {
+	article := w.load(id)
+	if article == nil {
+		return 0
+	}
+
+	if w.Retransform {
+		return w.retransform(id, article)
+	}
+
+	attempts, ok := w.claim(id, article)
+	if !ok {
+		return 0
+	}
+
+	c, err := w.transform(article)
+	return w.complete(id, article, attempts, c, err)
+}
+
+// processPack is Process for several articles sharing one LLM call. It
+// returns the longest pause any of them asked for.
+func (w *Worker) processPack(ids []string) time.Duration {
+	var claimed []string
+	var articles []*model.OriginalArticle
+	var attempts []int
+	seen := map[string]bool{}
+	for _, id := range ids {
+		// An article queued twice is only transformed once per pack.
+		if seen[id] {
+			w.Queue.Ack(ackCtx, id)
+			continue
+		}
+		seen[id] = true
+
+		article := w.load(id)
+		if article == nil {
+			continue
+		}
+		n, ok := w.claim(id, article)
+		if !ok {
+			continue
+		}
+		claimed = append(claimed, id)
+		articles = append(articles, article)
+		attempts = append(attempts, n)
+	}
+	if len(articles) == 0 {
+		return 0
+	}
+
+	calls, errs := w.transformPack(articles)
+
+	var pause time.Duration
+	for i, article := range articles {
+		pause = max(pause, w.complete(claimed[i], article, attempts[i], calls[i], errs[i]))
+	}
+	return pause
+}
+
+// load returns the article behind a queued ID, or nil once the ID has been
+// acked or nacked because there is nothing to transform.
+func (w *Worker) load(id string) *model.OriginalArticle {
+	articleId, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		slog.Error("invalid article id in queue", "id", id, "error", err)
+		w.Queue.Ack(ackCtx, id)
+		return nil
+	}
+
+	article, err := w.Store.GetOriginalByID(articleId)
+	if err != nil {
+		slog.Error("error getting article from DB", "error", err, "article_id", articleId)
+		w.Queue.Nack(ackCtx, id)
+		return nil
+	}
+
+	if article == nil {
+		slog.Warn("article not found in DB", "article_id", articleId)
+		w.Queue.Ack(ackCtx, id)
+		return nil
+	}
+	return article
+}
+
+// claim marks an article as processing and returns its failed attempts so
+// far. It reports false when the article was skipped or deferred instead.
+func (w *Worker) claim(id string, article *model.OriginalArticle) (int, bool) {
+	articleId := article.ID
+
+	// The outbox and reconciliation deliver at-least-once, so the same
+	// article can be queued more than once.
+	if article.Status == model.StatusCompleted {
+		slog.Info("article already transformed, skipping", "article_id", articleId)
+		w.Queue.Ack(ackCtx, id)
+		return 0, false
+	}
+
+	// The batcher requeues an article if its batch does not transform it.
+	if article.Status == model.StatusBatched {
+		slog.Info("article is in an open batch, skipping", "article_id", articleId)
+		w.Queue.Ack(ackCtx, id)
+		return 0, false
+	}
+
+	if level := w.Throttle.Level(); w.Throttle.deferred(article, level) {
+		delay := untilBudgetReset(time.Now())
+		slog.Info("LLM budget is low, deferring article", "article_id", articleId, "publisher", article.Publisher,
+			"budget", level, "delay", delay.String())
+		// Reconciliation would otherwise requeue the waiting article.
+		err := w.Store.UpdateStatus(articleId, model.StatusDeferred)
+		if err != nil {
+			slog.Error("error marking article as deferred", "error", err, "article_id", articleId)
+		}
+		err = w.Queue.Retry(ackCtx, id, delay)
+		if err != nil {
+			slog.Error("error deferring article", "error", err, "article_id", articleId)
+			w.Queue.Nack(ackCtx, id)
+		}
+		return 0, false
+	}
+
+	attempts, err := w.Store.GetAttemptCount(articleId)
+	if err != nil {
+		slog.Error("error getting attempt count", "error", err, "article_id", articleId)
+		w.Queue.Nack(ackCtx, id)
+		return 0, false
+	}
+
+	err = w.Store.UpdateStatus(articleId, model.StatusProcessing)
+	if err != nil {
+		slog.Error("error marking article as processing", "error", err, "article_id", articleId)
+	}
+	return attempts, true
+}
+
+// complete checks and saves the result of a transform, or records its error,
+// and acks, nacks or schedules a retry for the article.
+func (w *Worker) complete(id string, article *model.OriginalArticle, attempts int, c call, err error) time.Duration {
+	articleId := article.ID
+	if err == nil {
+		err = w.validate(article, &c)
+	}
+	if err != nil {
+		return w.handleTransformError(id, articleId, attempts+1, c, err)
+	}
+
+	transformedArticle, err := w.toTransformed(article.ID, c)
+	if err != nil {
+		slog.Error("error getting Others category", "error", err, "article_id", articleId)
+		w.Queue.Nack(ackCtx, id)
+		return 0
+	}
+
+	err = w.Store.SaveTransformedAndComplete(transformedArticle, article.ID)
+	if err != nil {
+		slog.Error("error saving transformed article", "error", err, "article_id", articleId)
+		w.Queue.Nack(ackCtx, id)
+		return 0
+	}
+
+	w.Queue.Ack(ackCtx, id)
+
+	slog.Info("article transformed successfully", "article_id", article.ID, "worker", w.ID)
+	return 0
+}
+
+// retransform regenerates a completed article and saves the result as its
+// active version. Failures keep the current version: rate limits put the
+// article back on the queue, anything else drops it from this run.
+func (w *Worker) retransform(id string, article *model.OriginalArticle) time.Duration {
+	if article.Status != model.StatusCompleted {
+		slog.Info("article is not completed, skipping retransform", "article_id", article.ID, "status", article.Status)
+		w.Queue.Ack(ackCtx, id)
+		return 0
+	}
+
+	c, err := w.transform(article)
+	if err == nil {
+		err = w.validate(article, &c)
+	}
+	if err != nil {
+		llmErr := llm.Classify(err)
+		slog.Error("error retransforming article", "error", err, "error_type", llmErr.Class,
+			"article_id", article.ID, "arm", c.arm, "worker", w.ID)
+
+		if llmErr.Class == llm.ErrorRateLimit {
+			w.Queue.Nack(ackCtx, id)
+			if llmErr.RetryAfter > 0 {
+				return llmErr.RetryAfter
+			}
+			return errorBackoff
+		}
+
+		w.Queue.Ack(ackCtx, id)
+		return 0
+	}
+
+	transformedArticle, err := w.toTransformed(article.ID, c)
+	if err != nil {
+		slog.Error("error getting Others category", "error", err, "article_id", article.ID)
+		w.Queue.Nack(ackCtx, id)
+		return 0
+	}
+
+	err = w.Store.SaveTransformed(transformedArticle)
+	if err != nil {
+		slog.Error("error saving retransformed article", "error", err, "article_id", article.ID)
+		w.Queue.Nack(ackCtx, id)
+		return 0
+	}
+
+	w.Queue.Ack(ackCtx, id)
+
+	slog.Info("article retransformed successfully", "article_id", article.ID, "transformed_id", transformedArticle.ID,
+		"prompt_version", transformedArticle.PromptVersion, "model_used", transformedArticle.ModelUsed, "worker", w.ID)
+	return 0
+}
+
+// toTransformed builds the row for a transform result, falling back to the
+// Others category when the model returned one we do not know.
+func (w *Worker) toTransformed(originalID int64, c call) (*model.TransformedArticle, error) {
+	result := c.result
+	category, err := w.Store.GetCategoryByName(result.Category)
+	if err != nil {
+		slog.Error("error getting category", "error", err, "category", result.Category)
+	}
+
+	if category == nil {
+		slog.Warn("LLM returned unknown category, falling back to Others", "category", result.Category, "article_id", originalID)
+		category, err = w.Store.GetCategoryByName(model.OthersCategory)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	transformed := &model.TransformedArticle{
+		Headline:       result.Headline,
+		Detail:         result.Detail,
+		OriginalID:     originalID,
+		CategoryID:     category.ID,
+		SentimentScore: result.SentimentScore,
+		PromptVersion:  result.PromptVersion,
+		PromptID:       result.PromptID,
+		ModelUsed:      result.ModelUsed,
+		Experiment:     c.experiment,
+		Arm:            c.arm,
+		LatencyMs:      c.latency.Milliseconds(),
+		InputTokens:    result.Usage.InputTokens,
+		OutputTokens:   result.Usage.OutputTokens,
+		TransformedAt:  time.Now(),
+	}
+
+	if c.check != nil {
+		transformed.Validation = model.ValidationPassed
+		if !c.check.OK() {
+			transformed.Validation = model.ValidationFlagged
+		}
+		transformed.MissingFacts = facts.Texts(c.check.Missing)
+		transformed.AddedFacts = facts.Texts(c.check.Added)
+	}
+
+	return transformed, nil
+}
+
+// handleTransformError records a failed attempt under its error class and
+// either schedules a retry with backoff or dead-letters the article once the
+// class's retry policy is exhausted. attempt counts failures of every class;
+// each policy is checked against the failures of its own class, so earlier
+// rate limits do not use up the attempts of a parse error.
+func (w *Worker) handleTransformError(id string, articleID int64, attempt int, c call, err error) time.Duration {
+	llmErr := llm.Classify(err)
+	policy := llm.PolicyFor(llmErr.Class)
+
+	classAttempt, countErr := w.Store.GetClassAttemptCount(articleID, string(llmErr.Class))
+	if countErr != nil {
+		slog.Error("error getting attempt count", "error", countErr, "article_id", articleID)
+	}
+	classAttempt++
+
+	slog.Error("error transforming article", "error", err, "error_type", llmErr.Class,
+		"attempt", attempt, "class_attempt", classAttempt, "article_id", articleID, "arm", c.arm, "worker", w.ID)
+
+	saveErr := w.Store.SaveError(&model.ProcessingError{
+		ArticleId:    articleID,
+		ErrorMessage: err.Error(),
+		ErrorType:    string(llmErr.Class),
+		AttemptCount: attempt,
+		Experiment:   c.experiment,
+		Arm:          c.arm,
+	})
+	if saveErr != nil {
+		slog.Error("error saving processing error", "error", saveErr, "article_id", articleID)
+	}
+
+	if !policy.Retryable(classAttempt) {
+		w.deadLetter(articleID, attempt)
+		w.Queue.Ack(ackCtx, id)
+		return 0
+	}
+
+	delay := policy.Backoff(classAttempt, llmErr.RetryAfter)
+	retryErr := w.Queue.Retry(ackCtx, id, delay)
+	if retryErr != nil {
+		slog.Error("error scheduling retry, requeueing article now", "error", retryErr, "article_id", articleID)
+		w.Queue.Nack(ackCtx, id)
+	} else {
+		slog.Info("article retry scheduled", "article_id", articleID, "attempt", attempt, "delay", delay.String())
+	}
+
+	// Other articles would hit the same limit, so the worker backs off too.
+	if llmErr.Class == llm.ErrorRateLimit {
+		if llmErr.RetryAfter > 0 {
+			return llmErr.RetryAfter
+		}
+		return errorBackoff
+	}
+	return 0
+}
+
+// deadLetter marks an article that exhausted its retries as failed and parks
+// it on the dead-letter queue for an operator to requeue or discard.
+func (w *Worker) deadLetter(articleID int64, attempts int) {
+	lastErrors, err := w.Store.GetLastErrors([]int64{articleID})
+	if err != nil {
+		slog.Error("error getting last processing error", "error", err, "article_id", articleID)
+	}
+	lastErr := lastErrors[articleID]
+
+	slog.Warn("article exhausted its retries, moving to dead-letter queue",
+		"article_id", articleID, "attempts", attempts,
+		"last_error", lastErr.ErrorMessage, "last_error_type", lastErr.ErrorType)
+
+	err = w.Store.UpdateStatus(articleID, model.StatusFailed)
+	if err != nil {
+		slog.Error("error marking article as failed", "error", err, "article_id", articleID)
+	}
+
+	err = w.DeadLetters.Push(ackCtx, strconv.FormatInt(articleID, 10))
+	if err != nil {
+		slog.Error("error pushing article to dead-letter queue", "error", err, "article_id", articleID)
+	}
+}
+
+// sleepCtx waits for d or until ctx is cancelled, reporting whether the full
+// duration elapsed.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
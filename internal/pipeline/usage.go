@@ -0,0 +1,34 @@
+package pipeline
+
+import (
+	"log/slog"
+	"zennews/internal/model"
+	"zennews/pkg/llm"
+)
+
+// UsageStore records the token usage of LLM calls.
+type UsageStore interface {
+	SaveUsage(u *model.LLMUsage) error
+}
+
+// recordUsage saves the usage of one call, if a store is configured. Failing
+// to record usage never fails the call itself.
+func recordUsage(store UsageStore, stage string, articleID, summaryID int64, usage llm.Usage) {
+	if store == nil {
+		return
+	}
+
+	err := store.SaveUsage(&model.LLMUsage{
+		Stage:        stage,
+		Provider:     usage.Provider,
+		Model:        usage.Model,
+		ArticleID:    articleID,
+		SummaryID:    summaryID,
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		Batch:        usage.Batch,
+	})
+	if err != nil {
+		slog.Error("error recording LLM usage", "error", err, "stage", stage, "article_id", articleID, "summary_id", summaryID)
+	}
+}
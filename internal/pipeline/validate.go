@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"zennews/internal/facts"
+	"zennews/internal/model"
+	"zennews/pkg/llm"
+)
+
+// ValidationMode is what the transformer does with a rewrite that dropped or
+// altered facts of the original.
+type ValidationMode string
+
+const (
+	// ValidationOff skips the check, as does an unset Worker.Validation.
+	ValidationOff ValidationMode = "off"
+	// ValidationFlag saves the rewrite marked as flagged.
+	ValidationFlag ValidationMode = "flag"
+	// ValidationReject fails the attempt as validation_failed, so the article
+	// is retried and dead-lettered like any other failure.
+	ValidationReject ValidationMode = "reject"
+)
+
+// forgetter is a client that caches its results, such as llm.CachedClient.
+type forgetter interface {
+	Forget(input llm.TransformInput) error
+}
+
+// ParseValidationMode reads a mode from config, defaulting to flag.
+func ParseValidationMode(s string) (ValidationMode, error) {
+	switch mode := ValidationMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return ValidationFlag, nil
+	case ValidationOff, ValidationFlag, ValidationReject:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown validation mode %q, want off, flag or reject", s)
+	}
+}
+
+// validate compares the facts of the original and the rewrite. It returns an
+// ErrorValidation error when facts are missing and the worker rejects such
+// rewrites; otherwise the result is recorded on the call.
+func (w *Worker) validate(article *model.OriginalArticle, c *call) error {
+	if w.Validation == "" || w.Validation == ValidationOff {
+		return nil
+	}
+
+	symbols, err := w.Store.GetSymbolsByOriginalID(article.ID)
+	if err != nil {
+		slog.Error("error getting article symbols, validating without them", "error", err, "article_id", article.ID)
+	}
+
+	check := facts.Compare(article.Headline+"\n"+article.Detail, c.result.Headline+"\n"+c.result.Detail, symbols)
+	c.check = &check
+	if check.OK() {
+		return nil
+	}
+
+	missing := strings.Join(facts.Texts(check.Missing), ", ")
+	if w.Validation == ValidationReject {
+		// A retry must ask the model again rather than reuse this rewrite.
+		if f, ok := c.client.(forgetter); ok {
+			err := f.Forget(llm.TransformInput{Headline: article.Headline, Detail: article.Detail})
+			if err != nil {
+				slog.Error("error dropping rejected transform from cache", "error", err, "article_id", article.ID)
+			}
+		}
+		return &llm.Error{Class: llm.ErrorValidation, Err: fmt.Errorf("transform dropped or altered facts: %s", missing)}
+	}
+
+	slog.Warn("transform dropped or altered facts, flagging", "article_id", article.ID,
+		"missing", missing, "added", strings.Join(facts.Texts(check.Added), ", "), "worker", w.ID)
+	return nil
+}
@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"zennews/internal/model"
+	"zennews/pkg/llm"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// truncatingClient drops the detail of the given headlines, losing the facts
+// it held.
+type truncatingClient struct {
+	llm.FakeClient
+	truncate map[string]bool
+}
+
+func (c *truncatingClient) Transform(input llm.TransformInput) (*llm.TransformResult, error) {
+	result, err := c.FakeClient.Transform(input)
+	if err == nil && c.truncate[input.Headline] {
+		result.Detail = "Apple reported results."
+	}
+	return result, err
+}
+
+func TestParseValidationMode(t *testing.T) {
+	mode, err := ParseValidationMode("")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, ValidationFlag, mode)
+
+	mode, err = ParseValidationMode(" Reject ")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, ValidationReject, mode)
+
+	_, err = ParseValidationMode("strict")
+	assert.NotEqual(t, nil, err)
+}
+
+func TestWorkerValidatesFacts(t *testing.T) {
+	run := func(mode ValidationMode) (*memStore, *memQueue) {
+		store := newMemStore()
+		queue := newMemQueue()
+		fetchAll(store, newsFixture()[:1])
+		DrainOutbox(context.Background(), store, queue, 10)
+
+		client := &truncatingClient{truncate: map[string]bool{"BREAKING: APPLE STOCK SOARS ON RECORD EARNINGS": true}}
+		w := &Worker{ID: "test-0", Store: store, Client: client, Queue: queue, DeadLetters: newMemQueue(), Validation: mode}
+		w.Run(context.Background())
+		return store, queue
+	}
+
+	t.Run("off", func(t *testing.T) {
+		store, _ := run(ValidationOff)
+		assert.Equal(t, 2, len(store.transformed))
+		assert.Equal(t, "", store.transformed[1].Validation)
+	})
+
+	t.Run("flag", func(t *testing.T) {
+		store, _ := run(ValidationFlag)
+		assert.Equal(t, 2, len(store.transformed))
+
+		apple := store.transformed[1]
+		assert.Equal(t, model.ValidationFlagged, apple.Validation)
+		assert.Equal(t, []string{"8%", "$124 billion"}, apple.MissingFacts)
+
+		bitcoin := store.transformed[3]
+		assert.Equal(t, model.ValidationPassed, bitcoin.Validation)
+		assert.Equal(t, 0, len(bitcoin.MissingFacts))
+		assert.Equal(t, 0, len(store.errors))
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		store, queue := run(ValidationReject)
+		assert.Equal(t, 1, len(store.transformed))
+		assert.Equal(t, model.StatusProcessing, store.articles[1].Status)
+		assert.Equal(t, model.StatusCompleted, store.articles[3].Status)
+
+		_, scheduled := queue.delayed["1"]
+		assert.Equal(t, true, scheduled)
+		assert.Equal(t, 1, len(store.errors))
+		assert.Equal(t, "validation_failed", store.errors[0].ErrorType)
+		assert.Equal(t, "transform dropped or altered facts: 8%, $124 billion", store.errors[0].ErrorMessage)
+	})
+}
@@ -2,6 +2,9 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 	"zennews/internal/model"
 
 	"github.com/lib/pq"
@@ -69,7 +72,7 @@ func (r *ArticleRepository) GetPendingArticle(limit int) ([]model.OriginalArticl
 
 func (r *ArticleRepository) UpdateStatus(id int64, status string) error {
 	_, err := r.db.Exec(`
-		UPDATE original_article SET status = $1 WHERE id = $2
+		UPDATE original_article SET status = $1, status_updated_at = NOW() WHERE id = $2
 	`, status, id)
 	return err
 }
@@ -77,10 +80,10 @@ func (r *ArticleRepository) UpdateStatus(id int64, status string) error {
 func (r *ArticleRepository) GetOriginalByID(id int64) (*model.OriginalArticle, error) {
 	var a model.OriginalArticle
 	err := r.db.QueryRow(`
-		SELECT id, headline, detail, url, source, published_at, fetched_at, external_id, status 
+		SELECT id, headline, detail, url, source, COALESCE(publisher, ''), published_at, fetched_at, external_id, status 
 		FROM original_article 
 		WHERE id = $1
-	`, id).Scan(&a.ID, &a.Headline, &a.Detail, &a.URL, &a.Source, &a.PublishedAt, &a.FetchedAt, &a.ExternalID, &a.Status)
+	`, id).Scan(&a.ID, &a.Headline, &a.Detail, &a.URL, &a.Source, &a.Publisher, &a.PublishedAt, &a.FetchedAt, &a.ExternalID, &a.Status)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -93,12 +96,21 @@ func (r *ArticleRepository) GetOriginalByID(id int64) (*model.OriginalArticle, e
 	return &a, nil
 }
 
+// SaveTransformed stores article as the active version for its original.
+// Earlier versions are kept for rollback.
 func (r *ArticleRepository) SaveTransformed(article *model.TransformedArticle) error {
-	return r.db.QueryRow(`
-		INSERT INTO transformed_article(headline, detail, original_id, category_id, sentiment_score, prompt_version, model_used)
-		VALUES($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id
-	`, article.Headline, article.Detail, article.OriginalID, article.CategoryID, article.SentimentScore, article.PromptVersion, article.ModelUsed).Scan(&article.ID)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = insertActiveTransformed(tx, article)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (r *ArticleRepository) SaveTransformedAndComplete(article *model.TransformedArticle, originalID int64) error {
@@ -108,17 +120,13 @@ func (r *ArticleRepository) SaveTransformedAndComplete(article *model.Transforme
 	}
 	defer tx.Rollback()
 
-	err = tx.QueryRow(`
-		INSERT INTO transformed_article(headline, detail, original_id, category_id, sentiment_score, prompt_version, model_used)
-		VALUES($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id
-	`, article.Headline, article.Detail, article.OriginalID, article.CategoryID, article.SentimentScore, article.PromptVersion, article.ModelUsed).Scan(&article.ID)
+	err = insertActiveTransformed(tx, article)
 	if err != nil {
 		return err
 	}
 
 	_, err = tx.Exec(`
-		UPDATE original_article SET status = $1 WHERE id = $2
+		UPDATE original_article SET status = $1, status_updated_at = NOW() WHERE id = $2
 	`, model.StatusCompleted, originalID)
 	if err != nil {
 		return err
@@ -127,10 +135,171 @@ func (r *ArticleRepository) SaveTransformedAndComplete(article *model.Transforme
 	return tx.Commit()
 }
 
+// insertActiveTransformed inserts article as the active version for its
+// original, deactivating whichever version was active before.
+func insertActiveTransformed(tx *sql.Tx, article *model.TransformedArticle) error {
+	_, err := tx.Exec(`
+		UPDATE transformed_article SET is_active = FALSE WHERE original_id = $1 AND is_active
+	`, article.OriginalID)
+	if err != nil {
+		return err
+	}
+
+	article.IsActive = true
+	return tx.QueryRow(`
+		INSERT INTO transformed_article(headline, detail, original_id, category_id, sentiment_score, prompt_version, prompt_id, model_used, is_active,
+			experiment, arm, latency_ms, input_tokens, output_tokens, validation, missing_facts, added_facts)
+		VALUES($1, $2, $3, $4, $5, $6, NULLIF($7, 0), $8, TRUE, NULLIF($9, ''), NULLIF($10, ''), $11, $12, $13, NULLIF($14, ''), $15, $16)
+		RETURNING id
+	`, article.Headline, article.Detail, article.OriginalID, article.CategoryID, article.SentimentScore, article.PromptVersion, article.PromptID, article.ModelUsed,
+		article.Experiment, article.Arm, article.LatencyMs, article.InputTokens, article.OutputTokens,
+		article.Validation, pq.Array(article.MissingFacts), pq.Array(article.AddedFacts)).Scan(&article.ID)
+}
+
+// GetRetransformIDs returns the original IDs of completed articles whose
+// active transformed version matches filter.
+func (r *ArticleRepository) GetRetransformIDs(filter model.RetransformFilter) ([]int64, error) {
+	where, args := retransformConditions(filter)
+	query := `
+		SELECT t.original_id
+		FROM transformed_article t
+		JOIN original_article o ON o.id = t.original_id
+		JOIN category c ON c.id = t.category_id
+		WHERE ` + where + `
+		ORDER BY t.original_id ASC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// RollbackTransformed reactivates the previous version of every article whose
+// active version matches filter. Articles with no earlier version keep their
+// current one. It returns how many articles were rolled back.
+func (r *ArticleRepository) RollbackTransformed(filter model.RetransformFilter) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	where, args := retransformConditions(filter)
+	rows, err := tx.Query(`
+		UPDATE transformed_article SET is_active = FALSE
+		WHERE id IN (
+			SELECT t.id
+			FROM transformed_article t
+			JOIN original_article o ON o.id = t.original_id
+			JOIN category c ON c.id = t.category_id
+			WHERE `+where+`
+				AND EXISTS (
+					SELECT 1 FROM transformed_article p
+					WHERE p.original_id = t.original_id AND p.id < t.id
+				)
+		)
+		RETURNING original_id, id
+	`, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	var originalIDs, currentIDs []int64
+	for rows.Next() {
+		var originalID, id int64
+		if err := rows.Scan(&originalID, &id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		originalIDs = append(originalIDs, originalID)
+		currentIDs = append(currentIDs, id)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	res, err := tx.Exec(`
+		UPDATE transformed_article SET is_active = TRUE
+		WHERE id IN (
+			SELECT DISTINCT ON (p.original_id) p.id
+			FROM transformed_article p
+			JOIN unnest($1::int[], $2::int[]) AS rb(original_id, current_id)
+				ON rb.original_id = p.original_id AND p.id < rb.current_id
+			ORDER BY p.original_id, p.id DESC
+		)
+	`, pq.Array(originalIDs), pq.Array(currentIDs))
+	if err != nil {
+		return 0, err
+	}
+
+	rolledBack, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return rolledBack, tx.Commit()
+}
+
+// retransformConditions builds the WHERE clause for filter over
+// transformed_article t, original_article o and category c.
+func retransformConditions(filter model.RetransformFilter) (string, []any) {
+	conditions := []string{"t.is_active"}
+	var args []any
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if len(filter.IDs) > 0 {
+		add("t.original_id = ANY($%d)", pq.Array(filter.IDs))
+	}
+	if filter.PromptVersion != "" {
+		add("t.prompt_version = $%d", filter.PromptVersion)
+	}
+	if filter.ModelUsed != "" {
+		add("t.model_used = $%d", filter.ModelUsed)
+	}
+	if filter.Category != "" {
+		add("c.name = $%d", filter.Category)
+	}
+	if !filter.From.IsZero() {
+		add("o.published_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("o.published_at < $%d", filter.To)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
 func (r *ArticleRepository) GetTransformedFeed(limit int, offset int) ([]model.TransformedArticle, error) {
 	rows, err := r.db.Query(`
 		SELECT id, headline, detail, original_id, category_id, sentiment_score, prompt_version, model_used, transformed_at 
 		FROM transformed_article 
+		WHERE is_active
 		LIMIT $1 OFFSET $2
 	`, limit, offset)
 
@@ -196,6 +365,15 @@ func (r *ArticleRepository) SaveOriginalWithSymbols(article *model.OriginalArtic
 		}
 	}
 
+	// The outbox row is written in the same transaction so every saved article
+	// is guaranteed to reach the transform queue once the outbox is drained.
+	_, err = tx.Exec(`
+		INSERT INTO transform_outbox(article_id) VALUES($1)
+	`, id)
+	if err != nil {
+		return false, err
+	}
+
 	return true, tx.Commit()
 }
 
@@ -207,13 +385,12 @@ func (r *ArticleRepository) SaveSymbols(articleID int64, symbols []string) error
 	return err
 }
 
-func (r *ArticleRepository) SaveError(articleID int64, errMsg string, errType string) error {
-	_, err := r.db.Exec(`
-		INSERT INTO processing_error(article_id, error_message, error_type) 
-		VALUES($1, $2, $3)
-	`, articleID, errMsg, errType)
-
-	return err
+func (r *ArticleRepository) SaveError(e *model.ProcessingError) error {
+	return r.db.QueryRow(`
+		INSERT INTO processing_error(article_id, error_message, error_type, attempt_count, experiment, arm) 
+		VALUES($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+		RETURNING id
+	`, e.ArticleId, e.ErrorMessage, e.ErrorType, e.AttemptCount, e.Experiment, e.Arm).Scan(&e.ID)
 }
 
 func (r *ArticleRepository) GetCategoryByName(name string) (*model.Category, error) {
@@ -274,6 +451,7 @@ func (r *ArticleRepository) GetFeed(limit int, offset int) ([]model.FeedArticle,
 		FROM transformed_article t 
 		JOIN original_article o ON o.id = t.original_id 
 		JOIN category c on c.id = t.category_id 
+		WHERE t.is_active
 		ORDER BY o.published_at DESC 
 		LIMIT $1 OFFSET $2
 	`, limit, offset)
@@ -309,7 +487,7 @@ func (r *ArticleRepository) GetFeed(limit int, offset int) ([]model.FeedArticle,
 func (r *ArticleRepository) GetFeedTotal() (int, error) {
 	var total int
 	err := r.db.QueryRow(`
-		SELECT COUNT(*) FROM transformed_article
+		SELECT COUNT(*) FROM transformed_article WHERE is_active
 	`).Scan(&total)
 	return total, err
 }
@@ -431,12 +609,180 @@ func (r *ArticleRepository) GetOriginalFeedTotal() (int, error) {
 	return total, err
 }
 
-func (r *ArticleRepository) GetErrorCount(id int64) (int, error) {
+// GetAttemptCount returns how many transform attempts have failed for an
+// article since its errors were last cleared.
+func (r *ArticleRepository) GetAttemptCount(id int64) (int, error) {
 	var count int
 	err := r.db.QueryRow(`
-		SELECT COUNT(*) FROM processing_error 
-		WHERE article_id = $1
+		SELECT COALESCE(MAX(attempt_count), 0) FROM processing_error 
+		WHERE article_id = $1 AND cleared_at IS NULL
 	`, id).Scan(&count)
 
 	return count, err
 }
+
+// GetClassAttemptCount returns how many transform attempts have failed with
+// errorType for an article since its errors were last cleared.
+func (r *ArticleRepository) GetClassAttemptCount(id int64, errorType string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM processing_error
+		WHERE article_id = $1 AND error_type = $2 AND cleared_at IS NULL
+	`, id, errorType).Scan(&count)
+
+	return count, err
+}
+
+func (r *ArticleRepository) GetPendingOutbox(limit int) ([]model.OutboxEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT id, article_id, created_at
+		FROM transform_outbox
+		WHERE enqueued_at IS NULL
+		ORDER BY id ASC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []model.OutboxEntry
+	for rows.Next() {
+		var e model.OutboxEntry
+		if err := rows.Scan(&e.ID, &e.ArticleID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (r *ArticleRepository) MarkOutboxEnqueued(ids []int64) error {
+	_, err := r.db.Exec(`
+		UPDATE transform_outbox SET enqueued_at = NOW() WHERE id = ANY($1)
+	`, pq.Array(ids))
+	return err
+}
+
+// RequeueStale resets articles that have sat in pending or processing for
+// longer than olderThan back to pending and writes a fresh outbox row for each,
+// skipping articles that already have an undrained outbox entry. Deferred
+// articles wait up to a day for the budget to reset, so they are only
+// requeued once that day has also passed.
+func (r *ArticleRepository) RequeueStale(olderThan time.Duration, limit int) (int64, error) {
+	res, err := r.db.Exec(`
+		WITH stale AS (
+			SELECT o.id FROM original_article o
+			WHERE (o.status IN ($1, $2) AND o.status_updated_at < NOW() - make_interval(secs => $3)
+				OR o.status = $5 AND o.status_updated_at < NOW() - make_interval(days => 1, secs => $3))
+				AND NOT EXISTS (
+					SELECT 1 FROM transform_outbox ob
+					WHERE ob.article_id = o.id AND ob.enqueued_at IS NULL
+				)
+			ORDER BY o.id ASC
+			LIMIT $4
+		), touched AS (
+			UPDATE original_article SET status = $1, status_updated_at = NOW()
+			WHERE id IN (SELECT id FROM stale)
+			RETURNING id
+		)
+		INSERT INTO transform_outbox(article_id)
+		SELECT id FROM touched
+	`, model.StatusPending, model.StatusProcessing, olderThan.Seconds(), limit, model.StatusDeferred)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (r *ArticleRepository) GetErrors(articleID int64) ([]model.ProcessingError, error) {
+	rows, err := r.db.Query(`
+		SELECT id, article_id, error_message, error_type, attempt_count, created_at
+		FROM processing_error
+		WHERE article_id = $1
+		ORDER BY created_at DESC
+	`, articleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanProcessingErrors(rows)
+}
+
+// GetLastErrors returns the most recent processing error for each article ID.
+func (r *ArticleRepository) GetLastErrors(ids []int64) (map[int64]model.ProcessingError, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT ON (article_id) id, article_id, error_message, error_type, attempt_count, created_at
+		FROM processing_error
+		WHERE article_id = ANY($1)
+		ORDER BY article_id, created_at DESC
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	errs, err := scanProcessingErrors(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int64]model.ProcessingError, len(errs))
+	for _, e := range errs {
+		result[e.ArticleId] = e
+	}
+	return result, nil
+}
+
+// ResetForRetry clears an article's error history for retry counting and
+// moves it back to pending. Cleared errors are kept for auditing.
+func (r *ArticleRepository) ResetForRetry(articleID int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE processing_error SET cleared_at = NOW()
+		WHERE article_id = $1 AND cleared_at IS NULL
+	`, articleID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE original_article SET status = $1, status_updated_at = NOW() WHERE id = $2
+	`, model.StatusPending, articleID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func scanProcessingErrors(rows *sql.Rows) ([]model.ProcessingError, error) {
+	var errs []model.ProcessingError
+	for rows.Next() {
+		var e model.ProcessingError
+		var errMsg, errType sql.NullString
+		err := rows.Scan(&e.ID, &e.ArticleId, &errMsg, &errType, &e.AttemptCount, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.ErrorMessage = errMsg.String
+		e.ErrorType = errType.String
+		errs = append(errs, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"database/sql"
+	"zennews/internal/model"
+
+	"github.com/lib/pq"
+)
+
+type BatchRepository struct {
+	db *sql.DB
+}
+
+func NewBatchRepository(db *sql.DB) *BatchRepository {
+	return &BatchRepository{db: db}
+}
+
+// GetPendingForBatch returns up to limit pending articles, oldest first.
+// Articles that already failed are left to the transformer's retries.
+func (r *BatchRepository) GetPendingForBatch(limit int) ([]model.OriginalArticle, error) {
+	rows, err := r.db.Query(`
+		SELECT id, headline, detail, url, source, COALESCE(publisher, ''), published_at, fetched_at, external_id, status
+		FROM original_article
+		WHERE status = $1 AND NOT EXISTS (
+			SELECT 1 FROM processing_error e WHERE e.article_id = original_article.id AND e.cleared_at IS NULL
+		)
+		ORDER BY id
+		LIMIT $2
+	`, model.StatusPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []model.OriginalArticle
+	for rows.Next() {
+		var a model.OriginalArticle
+		err := rows.Scan(&a.ID, &a.Headline, &a.Detail, &a.URL, &a.Source, &a.Publisher, &a.PublishedAt, &a.FetchedAt, &a.ExternalID, &a.Status)
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// SaveBatch records a submitted batch and marks its articles as batched so
+// neither the next batch nor the transformer picks them again.
+func (r *BatchRepository) SaveBatch(b *model.TransformBatch) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO transform_batch(provider, batch_id, status, article_ids)
+		VALUES($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, b.Provider, b.BatchID, b.Status, pq.Array(b.ArticleIDs)).Scan(&b.ID, &b.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE original_article SET status = $1, status_updated_at = NOW() WHERE id = ANY($2) AND status = $3
+	`, model.StatusBatched, pq.Array(b.ArticleIDs), model.StatusPending)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetOpenBatches returns the batches still waiting for results, oldest first.
+func (r *BatchRepository) GetOpenBatches() ([]model.TransformBatch, error) {
+	rows, err := r.db.Query(`
+		SELECT id, provider, batch_id, status, article_ids, succeeded, failed, created_at, completed_at
+		FROM transform_batch
+		WHERE status = $1
+		ORDER BY id
+	`, model.BatchSubmitted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []model.TransformBatch
+	for rows.Next() {
+		var b model.TransformBatch
+		err := rows.Scan(&b.ID, &b.Provider, &b.BatchID, &b.Status, pq.Array(&b.ArticleIDs),
+			&b.Succeeded, &b.Failed, &b.CreatedAt, &b.CompletedAt)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return batches, nil
+}
+
+// UpdateBatch saves a batch's status and result counts.
+func (r *BatchRepository) UpdateBatch(b *model.TransformBatch) error {
+	_, err := r.db.Exec(`
+		UPDATE transform_batch SET status = $1, succeeded = $2, failed = $3, completed_at = $4
+		WHERE id = $5
+	`, b.Status, b.Succeeded, b.Failed, b.CompletedAt, b.ID)
+	return err
+}
+
+// ReleaseBatched moves the given articles that are still batched back to
+// pending and writes an outbox row for each, handing them to the transformer.
+func (r *BatchRepository) ReleaseBatched(ids []int64) error {
+	_, err := r.db.Exec(`
+		WITH released AS (
+			UPDATE original_article SET status = $1, status_updated_at = NOW()
+			WHERE id = ANY($2) AND status = $3
+			RETURNING id
+		)
+		INSERT INTO transform_outbox(article_id)
+		SELECT id FROM released
+	`, model.StatusPending, pq.Array(ids), model.StatusBatched)
+	return err
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"database/sql"
+)
+
+// CacheRepository stores LLM responses in llm_response_cache. Entries do not
+// expire; prune old rows by created_at.
+type CacheRepository struct {
+	db *sql.DB
+}
+
+func NewCacheRepository(db *sql.DB) *CacheRepository {
+	return &CacheRepository{db: db}
+}
+
+func (r *CacheRepository) Get(key string) ([]byte, bool, error) {
+	var value []byte
+	err := r.db.QueryRow(`SELECT response FROM llm_response_cache WHERE cache_key = $1`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (r *CacheRepository) Set(key string, value []byte) error {
+	_, err := r.db.Exec(`
+		INSERT INTO llm_response_cache(cache_key, response) VALUES($1, $2)
+		ON CONFLICT (cache_key) DO UPDATE SET response = EXCLUDED.response, created_at = NOW()
+	`, key, value)
+	return err
+}
+
+func (r *CacheRepository) Delete(key string) error {
+	_, err := r.db.Exec(`DELETE FROM llm_response_cache WHERE cache_key = $1`, key)
+	return err
+}
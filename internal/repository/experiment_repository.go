@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"database/sql"
+	"sort"
+	"zennews/internal/model"
+)
+
+type ExperimentRepository struct {
+	db *sql.DB
+}
+
+func NewExperimentRepository(db *sql.DB) *ExperimentRepository {
+	return &ExperimentRepository{db: db}
+}
+
+// GetArmStats aggregates every transform and failed attempt recorded under
+// experiment, one entry per arm ordered by arm name.
+func (r *ExperimentRepository) GetArmStats(experiment string) ([]model.ArmStats, error) {
+	arms := make(map[string]*model.ArmStats)
+	arm := func(name string) *model.ArmStats {
+		if a, ok := arms[name]; ok {
+			return a
+		}
+		a := &model.ArmStats{Arm: name, Categories: map[string]int{}}
+		arms[name] = a
+		return a
+	}
+
+	rows, err := r.db.Query(`
+		SELECT arm, COUNT(*),
+			COALESCE(AVG(latency_ms), 0),
+			COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY latency_ms), 0),
+			COALESCE(AVG(input_tokens), 0),
+			COALESCE(AVG(output_tokens), 0),
+			COALESCE(AVG(sentiment_score), 0)
+		FROM transformed_article
+		WHERE experiment = $1
+		GROUP BY arm
+	`, experiment)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var s model.ArmStats
+		err := rows.Scan(&name, &s.Transformed, &s.AvgLatencyMs, &s.P95LatencyMs, &s.AvgInputTokens, &s.AvgOutputTokens, &s.AvgSentiment)
+		if err != nil {
+			return nil, err
+		}
+		s.Arm, s.Categories = name, map[string]int{}
+		arms[name] = &s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.Query(`
+		SELECT t.arm, c.name, COUNT(*)
+		FROM transformed_article t
+		JOIN category c ON c.id = t.category_id
+		WHERE t.experiment = $1
+		GROUP BY t.arm, c.name
+	`, experiment)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, category string
+		var count int
+		if err := rows.Scan(&name, &category, &count); err != nil {
+			return nil, err
+		}
+		arm(name).Categories[category] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.Query(`
+		SELECT arm, COUNT(*)
+		FROM processing_error
+		WHERE experiment = $1
+		GROUP BY arm
+	`, experiment)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var failed int
+		if err := rows.Scan(&name, &failed); err != nil {
+			return nil, err
+		}
+		arm(name).Failed = failed
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stats := make([]model.ArmStats, 0, len(arms))
+	for _, a := range arms {
+		stats = append(stats, *a)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Arm < stats[j].Arm })
+
+	return stats, nil
+}
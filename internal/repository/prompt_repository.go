@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"database/sql"
+	"zennews/internal/model"
+)
+
+type PromptRepository struct {
+	db *sql.DB
+}
+
+func NewPromptRepository(db *sql.DB) *PromptRepository {
+	return &PromptRepository{db: db}
+}
+
+// GetActivePrompts returns the active version of each prompt.
+func (r *PromptRepository) GetActivePrompts() ([]model.Prompt, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, version, body, active, created_at
+		FROM prompt
+		WHERE active
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prompts []model.Prompt
+	for rows.Next() {
+		var p model.Prompt
+		if err := rows.Scan(&p.ID, &p.Name, &p.Version, &p.Body, &p.Active, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prompts, nil
+}
+
+// GetPrompt returns a specific version of a prompt, active or not.
+func (r *PromptRepository) GetPrompt(name, version string) (*model.Prompt, error) {
+	var p model.Prompt
+	err := r.db.QueryRow(`
+		SELECT id, name, version, body, active, created_at
+		FROM prompt
+		WHERE name = $1 AND version = $2
+	`, name, version).Scan(&p.ID, &p.Name, &p.Version, &p.Body, &p.Active, &p.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+	"zennews/internal/model"
+)
+
+type SourceRepository struct {
+	db *sql.DB
+}
+
+func NewSourceRepository(db *sql.DB) *SourceRepository {
+	return &SourceRepository{db: db}
+}
+
+// GetSourceState returns where the last fetch from source left off, or nil
+// before its first fetch.
+func (r *SourceRepository) GetSourceState(source string) (*model.SourceState, error) {
+	var s model.SourceState
+	err := r.db.QueryRow(`
+		SELECT source, last_published_at, next_cursor, updated_at
+		FROM source_state
+		WHERE source = $1
+	`, source).Scan(&s.Source, &s.LastPublishedAt, &s.Cursor, &s.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *SourceRepository) SaveSourceState(s *model.SourceState) error {
+	return r.db.QueryRow(`
+		INSERT INTO source_state(source, last_published_at, next_cursor, updated_at)
+		VALUES($1, $2, $3, NOW())
+		ON CONFLICT (source) DO UPDATE SET last_published_at = EXCLUDED.last_published_at,
+			next_cursor = EXCLUDED.next_cursor, updated_at = EXCLUDED.updated_at
+		RETURNING updated_at
+	`, s.Source, s.LastPublishedAt, s.Cursor).Scan(&s.UpdatedAt)
+}
+
+func (r *SourceRepository) SaveFetchRun(run *model.FetchRun) error {
+	return r.db.QueryRow(`
+		INSERT INTO fetch_run(source, started_at, finished_at, fetched_count, saved_count, duplicate_count, error_count, error_message)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, run.Source, run.StartedAt, run.FinishedAt, run.Fetched, run.Saved, run.Duplicated, run.Errors, run.Error).Scan(&run.ID)
+}
+
+// GetSourceHealth returns the latest fetch run of every source with its
+// runs and failed runs since since, its last successful run ever and where
+// its fetches left off, ordered by source.
+func (r *SourceRepository) GetSourceHealth(since time.Time) ([]model.SourceHealth, error) {
+	rows, err := r.db.Query(`
+		WITH latest AS (
+			SELECT DISTINCT ON (source) id, source, started_at, finished_at, fetched_count, saved_count,
+				duplicate_count, error_count, error_message
+			FROM fetch_run
+			ORDER BY source, started_at DESC
+		), stats AS (
+			SELECT source,
+				COUNT(*) FILTER (WHERE started_at >= $1) AS runs,
+				COUNT(*) FILTER (WHERE started_at >= $1 AND (error_message <> '' OR error_count > 0)) AS failed_runs,
+				MAX(finished_at) FILTER (WHERE error_message = '' AND error_count = 0) AS last_success_at
+			FROM fetch_run
+			GROUP BY source
+		)
+		SELECT l.id, l.source, l.started_at, l.finished_at, l.fetched_count, l.saved_count, l.duplicate_count,
+			l.error_count, l.error_message, s.runs, s.failed_runs, s.last_success_at,
+			st.last_published_at, COALESCE(st.next_cursor, '')
+		FROM latest l
+		JOIN stats s ON s.source = l.source
+		LEFT JOIN source_state st ON st.source = l.source
+		ORDER BY l.source
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var health []model.SourceHealth
+	for rows.Next() {
+		var h model.SourceHealth
+		run := &h.LastRun
+		err := rows.Scan(&run.ID, &run.Source, &run.StartedAt, &run.FinishedAt, &run.Fetched, &run.Saved, &run.Duplicated,
+			&run.Errors, &run.Error, &h.Runs, &h.FailedRuns, &h.LastSuccessAt, &h.LastPublishedAt, &h.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		health = append(health, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return health, nil
+}
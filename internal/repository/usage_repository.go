@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+	"zennews/internal/model"
+)
+
+type UsageRepository struct {
+	db *sql.DB
+}
+
+func NewUsageRepository(db *sql.DB) *UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+// batchDiscount is the share of the list price that OpenAI and Anthropic
+// charge for batch requests.
+const batchDiscount = 0.5
+
+// SaveUsage records one LLM call, priced from llm_price (zero for models
+// without a price) and discounted for batch calls, and adds it to the day's
+// rollup in the same transaction.
+func (r *UsageRepository) SaveUsage(u *model.LLMUsage) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ratio := 1.0
+	if u.Batch {
+		ratio = batchDiscount
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO llm_usage(stage, provider, model, article_id, summary_id, input_tokens, output_tokens, batch, cost_usd)
+		VALUES($1, $2, $3, NULLIF($4, 0), NULLIF($5, 0), $6, $7, $8, COALESCE(
+			(SELECT ($6 * input_per_million + $7 * output_per_million) * $9 / 1000000 FROM llm_price WHERE model = $3), 0))
+		RETURNING id, cost_usd, created_at
+	`, u.Stage, u.Provider, u.Model, u.ArticleID, u.SummaryID, u.InputTokens, u.OutputTokens, u.Batch, ratio).Scan(&u.ID, &u.CostUSD, &u.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO api_usage(api_name, model, stage, usage_date, request_count, input_tokens, output_tokens, token_count, cost_usd)
+		VALUES($1, $2, $3, (NOW() AT TIME ZONE 'UTC')::date, 1, $4, $5, $4 + $5, $6)
+		ON CONFLICT (usage_date, api_name, model, stage) DO UPDATE SET
+			request_count = api_usage.request_count + 1,
+			input_tokens = api_usage.input_tokens + EXCLUDED.input_tokens,
+			output_tokens = api_usage.output_tokens + EXCLUDED.output_tokens,
+			token_count = api_usage.token_count + EXCLUDED.token_count,
+			cost_usd = api_usage.cost_usd + EXCLUDED.cost_usd
+	`, u.Provider, u.Model, u.Stage, u.InputTokens, u.OutputTokens, u.CostUSD)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetDailyUsage returns the daily rollups from from to to, both inclusive
+// dates, newest day first.
+func (r *UsageRepository) GetDailyUsage(from, to time.Time) ([]model.ApiUsage, error) {
+	rows, err := r.db.Query(`
+		SELECT id, api_name, model, stage, usage_date, request_count, input_tokens, output_tokens, token_count, cost_usd
+		FROM api_usage
+		WHERE usage_date BETWEEN $1::date AND $2::date
+		ORDER BY usage_date DESC, stage, api_name, model
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []model.ApiUsage
+	for rows.Next() {
+		var u model.ApiUsage
+		err := rows.Scan(&u.ID, &u.ApiName, &u.Model, &u.Stage, &u.UsageDate, &u.RequestCount,
+			&u.InputTokens, &u.OutputTokens, &u.TokenCount, &u.CostUSD)
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+// GetDailySpend returns today's (UTC) cost and tokens per provider.
+func (r *UsageRepository) GetDailySpend() (map[string]model.Spend, error) {
+	rows, err := r.db.Query(`
+		SELECT api_name, SUM(cost_usd), SUM(token_count)
+		FROM api_usage
+		WHERE usage_date = (NOW() AT TIME ZONE 'UTC')::date
+		GROUP BY api_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	spend := make(map[string]model.Spend)
+	for rows.Next() {
+		var provider string
+		var s model.Spend
+		if err := rows.Scan(&provider, &s.CostUSD, &s.Tokens); err != nil {
+			return nil, err
+		}
+		spend[provider] = s
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return spend, nil
+}
@@ -0,0 +1,11 @@
+ALTER TABLE original_article ADD COLUMN status_updated_at TIMESTAMP DEFAULT NOW();
+
+CREATE TABLE transform_outbox (
+    id SERIAL PRIMARY KEY,
+    article_id INTEGER NOT NULL REFERENCES original_article(id),
+    created_at TIMESTAMP DEFAULT NOW(),
+    enqueued_at TIMESTAMP
+);
+
+CREATE INDEX idx_transform_outbox_pending ON transform_outbox(id) WHERE enqueued_at IS NULL;
+CREATE INDEX idx_original_status_updated ON original_article(status, status_updated_at);
@@ -0,0 +1,3 @@
+ALTER TABLE processing_error ADD COLUMN cleared_at TIMESTAMP;
+
+CREATE INDEX idx_processing_error_article_id ON processing_error(article_id);
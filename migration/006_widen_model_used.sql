@@ -0,0 +1,2 @@
+ALTER TABLE transformed_article ALTER COLUMN model_used TYPE VARCHAR(100);
+ALTER TABLE news_summary ALTER COLUMN model_used TYPE VARCHAR(100);
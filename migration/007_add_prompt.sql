@@ -1,6 +1,56 @@
-package llm
-
-const clusterRankPrompt = `You are a financial news editor. You will receive a list of financial news articles with metadata (index, headline, summary, publisher, published time, stock symbols).
+CREATE TABLE prompt (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    version VARCHAR(20) NOT NULL,
+    body TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (name, version)
+);
+
+CREATE UNIQUE INDEX idx_prompt_active ON prompt(name) WHERE active;
+
+ALTER TABLE transformed_article ADD COLUMN prompt_id INTEGER REFERENCES prompt(id);
+
+INSERT INTO prompt (name, version, body, active) VALUES
+    ('transform', 'v1', $prompt$You are a financial news editor. Your job is to rewrite news headlines and summaries in a neutral, calm tone.
+
+Rules:
+1. Remove urgency words (BREAKING, NOW, ALERT, JUST IN)
+2. Remove ALL CAPS
+3. Replace emotional verbs:
+   - crash, plummet, tank → dropped, decreased
+   - explode, soar, skyrocket → rose, increased
+4. Remove judgmental words (smart, dumb, crazy, shocking, terrifying)
+5. Add uncertainty to predictions (will → may, could, might)
+6. Remove dramatic metaphors (bloodbath, shockwave, chaos)
+7. Keep all facts: numbers, names, dates, percentages
+
+Output as JSON only, no other text:
+{
+  "headline": "transformed headline",
+  "summary": "transformed summary",
+  "category": "one of: Earnings, Market Movement, Economy, Crypto, Mergers & Acquisitions, Policy & Regulation, Company News, Analysis",
+  "sentiment_score": 1-10 how emotional was the original (10 = very emotional)
+}$prompt$, TRUE),
+    ('summary', 'v1', $prompt$You are a financial news editor. Given a list of financial news headlines and summaries, provide an executive summary.
+
+Rules for the paragraph:
+- Single paragraph, concise and neutral
+- Summarizing the overall market mood
+
+Rules for bullets:
+- 3 to 5 bullet points
+- Each bullet covers a distinct key event or theme
+- Include company names, numbers, and percentages where relevant
+- One sentence per bullet
+
+Output as JSON only, no other text:
+{
+  "paragraph": "executive summary paragraph",
+  "bullets": ["key event 1", "key event 2", "key event 3"]
+}$prompt$, TRUE),
+    ('cluster_rank', 'v1', $prompt$You are a financial news editor. You will receive a list of financial news articles with metadata (index, headline, summary, publisher, published time, stock symbols).
 
 Your task is to cluster these articles by their PRIMARY SUBJECT and rank the clusters by importance.
 
@@ -36,9 +86,8 @@ Output JSON only, no other text:
       "importance_reason": "brief explanation of why this ranks here"
     }
   ]
-}`
-
-const synthesizePrompt = `You are a financial news editor. You will receive a cluster of related news articles about the same topic/event.
+}$prompt$, TRUE),
+    ('synthesize', 'v1', $prompt$You are a financial news editor. You will receive a cluster of related news articles about the same topic/event.
 
 Your task is to synthesize these articles into a single comprehensive story summary.
 
@@ -63,4 +112,8 @@ Output JSON only, no other text:
       "time_range": "time range of coverage"
     }
   ]
-}`
+}$prompt$, TRUE);
+
+UPDATE transformed_article t SET prompt_id = p.id
+FROM prompt p
+WHERE p.name = 'transform' AND p.version = t.prompt_version;
@@ -0,0 +1,10 @@
+ALTER TABLE transformed_article ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT TRUE;
+
+UPDATE transformed_article t SET is_active = FALSE
+WHERE EXISTS (
+    SELECT 1 FROM transformed_article n
+    WHERE n.original_id = t.original_id AND n.id > t.id
+);
+
+CREATE UNIQUE INDEX idx_transformed_active ON transformed_article(original_id) WHERE is_active;
+CREATE INDEX idx_transformed_original ON transformed_article(original_id);
@@ -0,0 +1,13 @@
+ALTER TABLE transformed_article
+    ADD COLUMN experiment VARCHAR(100),
+    ADD COLUMN arm VARCHAR(50),
+    ADD COLUMN latency_ms INTEGER,
+    ADD COLUMN input_tokens INTEGER,
+    ADD COLUMN output_tokens INTEGER;
+
+ALTER TABLE processing_error
+    ADD COLUMN experiment VARCHAR(100),
+    ADD COLUMN arm VARCHAR(50);
+
+CREATE INDEX idx_transformed_experiment ON transformed_article(experiment, arm);
+CREATE INDEX idx_processing_error_experiment ON processing_error(experiment, arm);
@@ -0,0 +1,6 @@
+ALTER TABLE transformed_article
+    ADD COLUMN validation VARCHAR(20),
+    ADD COLUMN missing_facts TEXT[],
+    ADD COLUMN added_facts TEXT[];
+
+CREATE INDEX idx_transformed_validation ON transformed_article(validation) WHERE validation = 'flagged';
@@ -0,0 +1,44 @@
+CREATE TABLE llm_price (
+    model VARCHAR(100) PRIMARY KEY,
+    input_per_million NUMERIC(10, 4) NOT NULL,
+    output_per_million NUMERIC(10, 4) NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE llm_usage (
+    id SERIAL PRIMARY KEY,
+    stage VARCHAR(20) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    model VARCHAR(100) NOT NULL,
+    article_id INTEGER REFERENCES original_article(id),
+    summary_id INTEGER REFERENCES news_summary(id),
+    input_tokens INTEGER NOT NULL,
+    output_tokens INTEGER NOT NULL,
+    cost_usd NUMERIC(12, 6) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE api_usage (
+    id SERIAL PRIMARY KEY,
+    api_name VARCHAR(50) NOT NULL,
+    model VARCHAR(100) NOT NULL,
+    stage VARCHAR(20) NOT NULL,
+    usage_date DATE NOT NULL,
+    request_count INTEGER NOT NULL DEFAULT 0,
+    input_tokens BIGINT NOT NULL DEFAULT 0,
+    output_tokens BIGINT NOT NULL DEFAULT 0,
+    token_count BIGINT NOT NULL DEFAULT 0,
+    cost_usd NUMERIC(12, 6) NOT NULL DEFAULT 0,
+    UNIQUE (usage_date, api_name, model, stage)
+);
+
+CREATE INDEX idx_llm_usage_created_at ON llm_usage(created_at);
+CREATE INDEX idx_llm_usage_article_id ON llm_usage(article_id);
+
+INSERT INTO llm_price (model, input_per_million, output_per_million) VALUES
+    ('gpt-4o-mini', 0.15, 0.60),
+    ('gpt-4o', 2.50, 10.00),
+    ('gpt-4.1-mini', 0.40, 1.60),
+    ('gpt-4.1', 2.00, 8.00),
+    ('claude-haiku-4-5', 1.00, 5.00),
+    ('claude-sonnet-4-6', 3.00, 15.00);
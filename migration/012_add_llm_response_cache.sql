@@ -0,0 +1,7 @@
+CREATE TABLE llm_response_cache (
+    cache_key CHAR(64) PRIMARY KEY,
+    response JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_llm_response_cache_created_at ON llm_response_cache(created_at);
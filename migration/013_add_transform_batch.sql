@@ -0,0 +1,15 @@
+CREATE TABLE transform_batch (
+    id SERIAL PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    batch_id VARCHAR(255) NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'submitted',
+    article_ids INTEGER[] NOT NULL,
+    succeeded INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX idx_transform_batch_submitted ON transform_batch(status) WHERE status = 'submitted';
+
+ALTER TABLE llm_usage ADD COLUMN batch BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,6 @@
+CREATE TABLE source_state (
+    source VARCHAR(100) PRIMARY KEY,
+    last_published_at TIMESTAMP,
+    next_cursor TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,13 @@
+CREATE TABLE fetch_run (
+    id BIGSERIAL PRIMARY KEY,
+    source VARCHAR(100) NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    fetched_count INT NOT NULL DEFAULT 0,
+    saved_count INT NOT NULL DEFAULT 0,
+    duplicate_count INT NOT NULL DEFAULT 0,
+    error_count INT NOT NULL DEFAULT 0,
+    error_message TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_fetch_run_source_started ON fetch_run(source, started_at DESC);
@@ -2,8 +2,8 @@ package llm
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/anthropics/anthropic-sdk-go"
@@ -11,65 +11,86 @@ import (
 )
 
 type AnthropicClient struct {
-	client    *anthropic.Client
-	model     anthropic.Model
-	modelName string
+	client       *anthropic.Client
+	model        anthropic.Model
+	modelName    string
+	clusterModel anthropic.Model
+	maxTokens    int64
+	temperature  *float64
+	prompts      PromptSet
 }
 
 func NewAnthropicClient(apiKey string) *AnthropicClient {
-	client := anthropic.NewClient(option.WithAPIKey(apiKey))
-	return &AnthropicClient{
-		client:    &client,
-		model:     anthropic.ModelClaudeHaiku4_5,
-		modelName: "claude-4.5-haiku",
-	}
+	return newAnthropicClient(Config{APIKey: apiKey})
 }
 
-func (c *AnthropicClient) Transform(input TransformInput) (*TransformResult, error) {
-	userPrompt := fmt.Sprintf("Headline: %s\nSummary: %s", input.Headline, input.Detail)
+func newAnthropicClient(cfg Config) *AnthropicClient {
+	client := anthropic.NewClient(option.WithAPIKey(cfg.APIKey))
+	c := &AnthropicClient{
+		client:       &client,
+		model:        anthropic.ModelClaudeHaiku4_5,
+		modelName:    defaultAnthropicModel,
+		clusterModel: anthropic.ModelClaudeSonnet4_6,
+		maxTokens:    cfg.MaxTokens,
+		temperature:  cfg.Temperature,
+		prompts:      cfg.Prompts.withDefaults(),
+	}
+	if cfg.Model != "" {
+		c.model, c.modelName = anthropic.Model(cfg.Model), cfg.Model
+	}
+	if cfg.ClusterModel != "" {
+		c.clusterModel = anthropic.Model(cfg.ClusterModel)
+	}
+	return c
+}
 
-	resp, err := c.client.Messages.New(context.Background(), anthropic.MessageNewParams{
-		Model:     c.model,
-		MaxTokens: 1024,
+// params builds a message request. maxTokens is the per-call default used
+// when no limit is configured, since the API requires one.
+func (c *AnthropicClient) params(model anthropic.Model, maxTokens int64, system, user string) anthropic.MessageNewParams {
+	if c.maxTokens > 0 {
+		maxTokens = c.maxTokens
+	}
+	params := anthropic.MessageNewParams{
+		Model:     model,
+		MaxTokens: maxTokens,
 		System: []anthropic.TextBlockParam{
-			{Text: systemPrompt},
+			{Text: system},
 		},
 		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(userPrompt)),
+			anthropic.NewUserMessage(anthropic.NewTextBlock(user)),
 		},
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("anthropic API error: %w", err)
 	}
-
-	if len(resp.Content) == 0 {
-		return nil, fmt.Errorf("no response from anthropic")
-	}
-
-	content := resp.Content[0].Text
-	content = cleanJSONResponse(content)
-
-	var parsed struct {
-		Headline       string `json:"headline"`
-		Summary        string `json:"summary"`
-		Category       string `json:"category"`
-		SentimentScore int    `json:"sentiment_score"`
+	if c.temperature != nil {
+		params.Temperature = anthropic.Float(*c.temperature)
 	}
+	return params
+}
 
-	err = json.Unmarshal([]byte(content), &parsed)
+func (c *AnthropicClient) Transform(input TransformInput) (*TransformResult, error) {
+	var parsed transformOutput
+	usage, err := c.completeJSON(c.transformParams(input), transformSchema, func(content string) error {
+		parsed = transformOutput{}
+		return decodeOutput(content, &parsed)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w, content: %s", err, content)
+		return nil, withUsage(usage, err)
 	}
 
-	return &TransformResult{
-		Headline:       parsed.Headline,
-		Detail:         parsed.Summary,
-		Category:       parsed.Category,
-		SentimentScore: parsed.SentimentScore,
-		PromptVersion:  promptVersion,
-		ModelUsed:      c.modelName,
-	}, nil
+	return parsed.result(c.prompts.Transform, c.modelName, usage), nil
+}
+
+func (c *AnthropicClient) TransformBatch(inputs []TransformInput) ([]*TransformResult, []error) {
+	return transformEach(inputs, c, c.prompts.Transform, c.modelName, func(system, user string, decode func(content string) error) (Usage, error) {
+		params := c.params(c.model, 1024, system, user)
+		// Every article needs room to be rewritten, whatever limit is set
+		// for a single one.
+		params.MaxTokens = max(params.MaxTokens, 1024*int64(len(inputs)))
+		return c.completeJSON(params, transformBatchSchema, decode)
+	})
+}
+
+func (c *AnthropicClient) transformParams(input TransformInput) anthropic.MessageNewParams {
+	return c.params(c.model, 1024, c.prompts.Transform.Body, transformUserPrompt(input))
 }
 
 func cleanJSONResponse(content string) string {
@@ -94,125 +115,139 @@ func (c *AnthropicClient) Summarize(articles []SummaryInput) (*SummaryResult, er
 		sb.WriteString(fmt.Sprintf("%d. Headline: %s\nSummary: %s\n\n", i+1, a.Headline, a.Detail))
 	}
 
-	resp, err := c.client.Messages.New(context.Background(), anthropic.MessageNewParams{
-		Model:     c.model,
-		MaxTokens: 2048,
-		System: []anthropic.TextBlockParam{
-			{Text: summarySystemPrompt},
-		},
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(sb.String())),
-		},
+	var parsed summaryOutput
+	usage, err := c.completeJSON(c.params(c.model, 2048, c.prompts.Summary.Body, sb.String()), summarySchema, func(content string) error {
+		parsed = summaryOutput{}
+		return decodeOutput(content, &parsed)
 	})
 	if err != nil {
-		return nil, fmt.Errorf("anthropic API error: %w", err)
-	}
-	if len(resp.Content) == 0 {
-		return nil, fmt.Errorf("no response from anthropic")
-	}
-
-	content := cleanJSONResponse(resp.Content[0].Text)
-
-	var parsed struct {
-		Paragraph string   `json:"paragraph"`
-		Bullets   []string `json:"bullets"`
-	}
-	if err := json.Unmarshal([]byte(content), &parsed); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w, content: %s", err, content)
+		return nil, withUsage(usage, err)
 	}
 
 	return &SummaryResult{
 		Paragraph: parsed.Paragraph,
 		Bullets:   parsed.Bullets,
 		ModelUsed: c.modelName,
+		Usage:     usage,
 	}, nil
 }
 
 func (c *AnthropicClient) ClusterAndSummarize(articles []SummaryInput) (*ClusterSummaryResult, error) {
-	clusterModel := anthropic.ModelClaudeSonnet4_6
-	clusterModelName := "claude-sonnet-4-6"
-
 	// Pass 1: Cluster & Rank
 	userPrompt := formatArticlesForClustering(articles)
 
-	resp, err := c.client.Messages.New(context.Background(), anthropic.MessageNewParams{
-		Model:     clusterModel,
-		MaxTokens: 4096,
-		System: []anthropic.TextBlockParam{
-			{Text: clusterRankPrompt},
-		},
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(userPrompt)),
-		},
+	var clusterResult clusterOutput
+	usage, err := c.completeJSON(c.params(c.clusterModel, 4096, c.prompts.ClusterRank.Body, userPrompt), clusterSchema, func(content string) error {
+		clusterResult = clusterOutput{articleCount: len(articles)}
+		return decodeOutput(content, &clusterResult)
 	})
 	if err != nil {
-		return nil, fmt.Errorf("anthropic cluster pass error: %w", err)
-	}
-	if len(resp.Content) == 0 {
-		return nil, fmt.Errorf("no response from anthropic (cluster pass)")
-	}
-
-	content := cleanJSONResponse(resp.Content[0].Text)
-
-	var clusterResult struct {
-		Clusters []struct {
-			Topic            string `json:"topic"`
-			ArticleIndices   []int  `json:"article_indices"`
-			ImportanceReason string `json:"importance_reason"`
-		} `json:"clusters"`
-	}
-	if err := json.Unmarshal([]byte(content), &clusterResult); err != nil {
-		return nil, fmt.Errorf("failed to parse cluster response: %w, content: %s", err, content)
+		return nil, withUsage(usage, fmt.Errorf("anthropic cluster pass error: %w", err))
 	}
 
 	// Pass 2: Synthesize each cluster
 	var stories []StorySummary
 	for _, cluster := range clusterResult.Clusters {
 		clusterArticles := gatherClusterArticles(articles, cluster.ArticleIndices)
-		story, err := c.synthesizeCluster(clusterArticles, clusterModel)
+		story, synthesisUsage, err := c.synthesizeCluster(clusterArticles)
+		usage.add(synthesisUsage)
 		if err != nil {
-			return nil, fmt.Errorf("anthropic synthesis error for cluster %q: %w", cluster.Topic, err)
+			return nil, withUsage(usage, fmt.Errorf("anthropic synthesis error for cluster %q: %w", cluster.Topic, err))
 		}
 		stories = append(stories, *story)
 	}
 
 	return &ClusterSummaryResult{
 		Stories:   stories,
-		ModelUsed: clusterModelName,
+		ModelUsed: string(c.clusterModel),
+		Usage:     usage,
 	}, nil
 }
 
-func (c *AnthropicClient) synthesizeCluster(articles []SummaryInput, model anthropic.Model) (*StorySummary, error) {
+func (c *AnthropicClient) synthesizeCluster(articles []SummaryInput) (*StorySummary, Usage, error) {
 	userPrompt := formatArticlesForSynthesis(articles)
 
-	resp, err := c.client.Messages.New(context.Background(), anthropic.MessageNewParams{
-		Model:     model,
-		MaxTokens: 2048,
-		System: []anthropic.TextBlockParam{
-			{Text: synthesizePrompt},
-		},
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(userPrompt)),
-		},
+	var parsed synthesisOutput
+	usage, err := c.completeJSON(c.params(c.clusterModel, 2048, c.prompts.Synthesize.Body, userPrompt), synthesisSchema, func(content string) error {
+		parsed = synthesisOutput{}
+		return decodeOutput(content, &parsed)
 	})
 	if err != nil {
-		return nil, fmt.Errorf("anthropic API error: %w", err)
-	}
-	if len(resp.Content) == 0 {
-		return nil, fmt.Errorf("no response from anthropic")
+		return nil, usage, err
 	}
 
-	content := cleanJSONResponse(resp.Content[0].Text)
+	return &parsed.Stories[0], usage, nil
+}
 
-	var parsed struct {
-		Stories []StorySummary `json:"stories"`
-	}
-	if err := json.Unmarshal([]byte(content), &parsed); err != nil {
-		return nil, fmt.Errorf("failed to parse synthesis response: %w, content: %s", err, content)
-	}
-	if len(parsed.Stories) == 0 {
-		return nil, fmt.Errorf("no stories in synthesis response")
+// completeJSON forces a call to a tool whose input schema is schema and hands
+// the tool input to decode. Input that fails to decode is returned to the
+// model once as an error tool result to repair before the call fails with a
+// parse error.
+func (c *AnthropicClient) completeJSON(params anthropic.MessageNewParams, schema outputSchema, decode func(content string) error) (Usage, error) {
+	params.Tools, params.ToolChoice = anthropicTool(schema)
+
+	usage := Usage{Provider: ProviderAnthropic, Model: string(params.Model)}
+	for attempt := 0; ; attempt++ {
+		resp, err := c.client.Messages.New(context.Background(), params)
+		if err != nil {
+			return usage, fmt.Errorf("anthropic API error: %w", err)
+		}
+		usage.add(Usage{InputTokens: resp.Usage.InputTokens, OutputTokens: resp.Usage.OutputTokens})
+
+		if resp.StopReason == anthropic.StopReasonRefusal {
+			return usage, newRefusalError("anthropic", string(resp.StopReason))
+		}
+		if len(resp.Content) == 0 {
+			return usage, fmt.Errorf("no response from anthropic")
+		}
+
+		content, toolUseID := anthropicContent(resp, schema)
+		err = decode(content)
+		if err == nil {
+			return usage, nil
+		}
+
+		if attempt >= maxRepairAttempts {
+			return usage, newParseError("invalid %s response: %w, content: %s", schema.name, err, content)
+		}
+
+		slog.Warn("LLM output failed validation, asking for a repair", "provider", "anthropic", "schema", schema.name, "error", err)
+		params.Messages = append(params.Messages, resp.ToParam())
+		if toolUseID != "" {
+			params.Messages = append(params.Messages, anthropic.NewUserMessage(anthropic.NewToolResultBlock(toolUseID, repairPrompt(err), true)))
+		} else {
+			params.Messages = append(params.Messages, anthropic.NewUserMessage(anthropic.NewTextBlock(repairPrompt(err))))
+		}
 	}
+}
+
+// anthropicTool forces a tool call whose input matches schema, the way to get
+// structured output from the Messages API.
+func anthropicTool(schema outputSchema) ([]anthropic.ToolUnionParam, anthropic.ToolChoiceUnionParam) {
+	tools := []anthropic.ToolUnionParam{{
+		OfTool: &anthropic.ToolParam{
+			Name:        schema.name,
+			Description: anthropic.String(schema.description),
+			InputSchema: anthropic.ToolInputSchemaParam{
+				Properties:  schema.properties,
+				Required:    schema.required(),
+				ExtraFields: map[string]any{"additionalProperties": false},
+			},
+		},
+	}}
+	return tools, anthropic.ToolChoiceParamOfTool(schema.name)
+}
 
-	return &parsed.Stories[0], nil
+// anthropicContent returns the input of the schema's tool call, or the first
+// text block when the model answered without calling it.
+func anthropicContent(resp *anthropic.Message, schema outputSchema) (content, toolUseID string) {
+	for _, block := range resp.Content {
+		if block.Type == "tool_use" && block.Name == schema.name {
+			return string(block.Input), block.ID
+		}
+		if block.Type == "text" && content == "" {
+			content = block.Text
+		}
+	}
+	return content, ""
 }
@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// SubmitBatch creates a Message Batch with one transform request each.
+func (c *AnthropicClient) SubmitBatch(ctx context.Context, requests []BatchRequest) (string, error) {
+	params := anthropic.MessageBatchNewParams{}
+	for _, r := range requests {
+		p := c.transformParams(r.Input)
+		tools, toolChoice := anthropicTool(transformSchema)
+		params.Requests = append(params.Requests, anthropic.MessageBatchNewParamsRequest{
+			CustomID: r.CustomID,
+			Params: anthropic.MessageBatchNewParamsRequestParams{
+				Model:       p.Model,
+				MaxTokens:   p.MaxTokens,
+				System:      p.System,
+				Messages:    p.Messages,
+				Temperature: p.Temperature,
+				Tools:       tools,
+				ToolChoice:  toolChoice,
+			},
+		})
+	}
+
+	batch, err := c.client.Messages.Batches.New(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("anthropic API error creating batch: %w", err)
+	}
+	return batch.ID, nil
+}
+
+func (c *AnthropicClient) GetBatch(ctx context.Context, id string) (*BatchStatus, error) {
+	batch, err := c.client.Messages.Batches.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic API error: %w", err)
+	}
+
+	counts := batch.RequestCounts
+	status := &BatchStatus{
+		ID:        batch.ID,
+		State:     BatchInProgress,
+		Succeeded: int(counts.Succeeded),
+		Failed:    int(counts.Errored + counts.Canceled + counts.Expired),
+	}
+	status.Total = status.Succeeded + status.Failed + int(counts.Processing)
+	if batch.ProcessingStatus == anthropic.MessageBatchProcessingStatusEnded {
+		status.State = BatchEnded
+	}
+	return status, nil
+}
+
+// BatchResults streams the results of an ended batch.
+func (c *AnthropicClient) BatchResults(ctx context.Context, id string) ([]BatchResult, error) {
+	stream := c.client.Messages.Batches.ResultsStreaming(ctx, id)
+	defer stream.Close()
+
+	var results []BatchResult
+	for stream.Next() {
+		results = append(results, c.batchResult(stream.Current()))
+	}
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("anthropic API error reading batch results: %w", err)
+	}
+	return results, nil
+}
+
+func (c *AnthropicClient) batchResult(resp anthropic.MessageBatchIndividualResponse) BatchResult {
+	result := BatchResult{CustomID: resp.CustomID}
+	if resp.Result.Type != "succeeded" {
+		result.Err = fmt.Errorf("anthropic batch request %s: %s", resp.Result.Type, resp.Result.Error.Error.Message)
+		return result
+	}
+
+	msg := resp.Result.Message
+	if msg.StopReason == anthropic.StopReasonRefusal {
+		result.Err = newRefusalError("anthropic", string(msg.StopReason))
+		return result
+	}
+
+	content, _ := anthropicContent(&msg, transformSchema)
+	usage := Usage{Provider: ProviderAnthropic, Model: string(c.model), InputTokens: msg.Usage.InputTokens, OutputTokens: msg.Usage.OutputTokens}
+	result.Result, result.Err = decodeBatchTransform(content, c.prompts.Transform, c.modelName, usage)
+	return result
+}
@@ -1,6 +1,14 @@
 package llm
 
-import "testing"
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
 
 func TestCleanJSONResponse(t *testing.T) {
 	tests := []struct {
@@ -49,3 +57,103 @@ func TestCleanJSONResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestAnthropicToolUseWithRepair(t *testing.T) {
+	type request struct {
+		Tools []struct {
+			Name        string         `json:"name"`
+			InputSchema map[string]any `json:"input_schema"`
+		} `json:"tools"`
+		ToolChoice struct {
+			Type string `json:"type"`
+			Name string `json:"name"`
+		} `json:"tool_choice"`
+		Messages []json.RawMessage `json:"messages"`
+	}
+	var requests []request
+
+	inputs := []string{
+		`{"headline": "Oil rose", "summary": "Crude rose 2%.", "category": "Energy", "sentiment_score": 3}`,
+		`{"headline": "Oil rose", "summary": "Crude rose 2%.", "category": "Economy", "sentiment_score": 3}`,
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		input := inputs[len(requests)]
+		requests = append(requests, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":          "msg_1",
+			"type":        "message",
+			"role":        "assistant",
+			"model":       "claude-haiku-4-5",
+			"stop_reason": "tool_use",
+			"content": []map[string]any{{
+				"type":  "tool_use",
+				"id":    "toolu_1",
+				"name":  "transform_article",
+				"input": json.RawMessage(input),
+			}},
+			"usage": map[string]any{"input_tokens": 10, "output_tokens": 10},
+		})
+	}))
+	defer srv.Close()
+
+	c := newAnthropicClient(Config{})
+	client := anthropic.NewClient(option.WithBaseURL(srv.URL), option.WithAPIKey("test"), option.WithMaxRetries(0))
+	c.client = &client
+
+	result, err := c.Transform(TransformInput{Headline: "OIL EXPLODES HIGHER"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Category != "Economy" {
+		t.Errorf("category: got %q", result.Category)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected one repair request, got %d requests", len(requests))
+	}
+	first := requests[0]
+	if len(first.Tools) != 1 || first.Tools[0].Name != "transform_article" || first.ToolChoice.Type != "tool" || first.ToolChoice.Name != "transform_article" {
+		t.Errorf("expected a forced transform_article tool call, got %+v", first)
+	}
+	if first.Tools[0].InputSchema["additionalProperties"] != false {
+		t.Errorf("input schema should forbid extra properties: %v", first.Tools[0].InputSchema)
+	}
+	// user prompt, assistant tool call, user tool_result with the validation error
+	if len(requests[1].Messages) != 3 {
+		t.Errorf("repair request should have 3 messages, got %d", len(requests[1].Messages))
+	}
+}
+
+func TestAnthropicTransformBatchMaxTokens(t *testing.T) {
+	var maxTokens []int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			MaxTokens int64 `json:"max_tokens"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		maxTokens = append(maxTokens, req.MaxTokens)
+		http.Error(w, `{"type": "error", "error": {"type": "invalid_request_error", "message": "bad request"}}`, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	// LLM_MAX_TOKENS sizes a single rewrite; a pack of three needs more.
+	c := newAnthropicClient(Config{MaxTokens: 500})
+	client := anthropic.NewClient(option.WithBaseURL(srv.URL), option.WithAPIKey("test"), option.WithMaxRetries(0))
+	c.client = &client
+
+	c.TransformBatch([]TransformInput{{Headline: "Oil rose"}, {Headline: "Gold fell"}, {Headline: "Apple rose"}})
+	if len(maxTokens) == 0 || maxTokens[0] != 3*1024 {
+		t.Fatalf("pack max_tokens: got %v", maxTokens)
+	}
+	if maxTokens[1] != 500 {
+		t.Errorf("single max_tokens: got %d", maxTokens[1])
+	}
+}
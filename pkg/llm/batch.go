@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// Batch states, shared by every provider's batch API.
+const (
+	// BatchInProgress batches are still being processed.
+	BatchInProgress = "in_progress"
+	// BatchEnded batches have results for the requests that completed; the
+	// rest expired, were cancelled or failed individually.
+	BatchEnded = "ended"
+	// BatchFailed batches were rejected as a whole and have no results.
+	BatchFailed = "failed"
+)
+
+// BatchRequest is one transform in a batch. CustomID matches it to its
+// result.
+type BatchRequest struct {
+	CustomID string
+	Input    TransformInput
+}
+
+// BatchResult is the outcome of one request. Exactly one of Result and Err
+// is set.
+type BatchResult struct {
+	CustomID string
+	Result   *TransformResult
+	Err      error
+}
+
+// BatchStatus reports a batch's progress. Failed counts requests that
+// errored, expired or were cancelled.
+type BatchStatus struct {
+	ID        string
+	State     string
+	Total     int
+	Succeeded int
+	Failed    int
+}
+
+// BatchClient submits transforms to a provider's asynchronous batch API,
+// which trades latency of up to a day for about half the price. There is no
+// repair re-ask, so output that fails validation fails its request.
+type BatchClient interface {
+	SubmitBatch(ctx context.Context, requests []BatchRequest) (string, error)
+	GetBatch(ctx context.Context, id string) (*BatchStatus, error)
+	BatchResults(ctx context.Context, id string) ([]BatchResult, error)
+}
+
+// NewBatchClient returns the batch client for cfg.Provider. Fallbacks are
+// ignored, since a batch cannot fail over once submitted.
+func NewBatchClient(cfg Config) (BatchClient, error) {
+	switch cfg.Provider {
+	case ProviderOpenAI, "":
+		return newOpenAIClient(cfg), nil
+	case ProviderAnthropic:
+		return newAnthropicClient(cfg), nil
+	case ProviderFake:
+		return &FakeClient{Prompts: cfg.Prompts}, nil
+	default:
+		return nil, fmt.Errorf("LLM provider %q has no batch API", cfg.Provider)
+	}
+}
+
+// decodeBatchTransform decodes one batch response without the repair re-ask
+// a synchronous call would get. A response that fails still carries its
+// usage.
+func decodeBatchTransform(content string, prompt Prompt, model string, usage Usage) (*TransformResult, error) {
+	usage.Batch = true
+	var parsed transformOutput
+	if err := decodeOutput(content, &parsed); err != nil {
+		return nil, withUsage(usage, newParseError("invalid %s response: %w, content: %s", transformSchema.name, err, content))
+	}
+	return parsed.result(prompt, model, usage), nil
+}
@@ -0,0 +1,264 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	anthropicoption "github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+var batchRequests = []BatchRequest{
+	{CustomID: "1", Input: TransformInput{Headline: "OIL EXPLODES HIGHER", Detail: "Crude rose 4%."}},
+	{CustomID: "2", Input: TransformInput{Headline: "Fed holds rates", Detail: "Rates stay at 5.25%."}},
+	{CustomID: "3", Input: TransformInput{Headline: "Bitcoin tanks", Detail: "Bitcoin fell 9%."}},
+}
+
+const batchOutput = `{"headline":"Oil rose","summary":"Crude rose 4%.","category":"Economy","sentiment_score":7}`
+
+// uploadedLine is the part of a batch input line the tests check.
+type uploadedLine struct {
+	CustomID string `json:"custom_id"`
+	URL      string `json:"url"`
+	Body     struct {
+		Model          string `json:"model"`
+		ResponseFormat struct {
+			Type string `json:"type"`
+		} `json:"response_format"`
+	} `json:"body"`
+}
+
+// fakeOpenAIBatchServer implements the file upload, batch and file content
+// endpoints. Request 1 succeeds, 2 returns invalid output and 3 is in the
+// error file.
+func fakeOpenAIBatchServer(t *testing.T) (*httptest.Server, *[]uploadedLine) {
+	var mu sync.Mutex
+	var uploaded []uploadedLine
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/files", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("purpose") != "batch" {
+			t.Errorf("purpose: got %q", r.FormValue("purpose"))
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("no file in upload: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var line uploadedLine
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("invalid upload line: %v", err)
+			}
+			uploaded = append(uploaded, line)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"id": "file-in", "object": "file", "purpose": "batch"})
+	})
+	mux.HandleFunc("POST /v1/batches", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["input_file_id"] != "file-in" || body["endpoint"] != "/v1/chat/completions" {
+			t.Errorf("unexpected batch request: %v", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"id": "batch_1", "status": "validating"})
+	})
+	mux.HandleFunc("GET /v1/batches/batch_1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id": "batch_1", "status": "completed", "output_file_id": "file-out", "error_file_id": "file-err",
+			"request_counts": map[string]any{"total": 3, "completed": 2, "failed": 1},
+		})
+	})
+	mux.HandleFunc("GET /v1/files/file-out/content", func(w http.ResponseWriter, r *http.Request) {
+		for id, content := range map[string]string{"1": batchOutput, "2": `{"headline":""}`} {
+			json.NewEncoder(w).Encode(map[string]any{
+				"custom_id": id,
+				"response": map[string]any{"status_code": 200, "body": map[string]any{
+					"id": "chatcmpl-" + id, "model": "gpt-4o-mini-2024-07-18",
+					"choices": []map[string]any{{"index": 0, "finish_reason": "stop", "message": map[string]any{"role": "assistant", "content": content}}},
+					"usage":   map[string]any{"prompt_tokens": 100, "completion_tokens": 20},
+				}},
+			})
+		}
+	})
+	mux.HandleFunc("GET /v1/files/file-err/content", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"custom_id":"3","response":null,"error":{"code":"batch_expired","message":"not completed in time"}}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &uploaded
+}
+
+func checkBatchResults(t *testing.T, results []BatchResult, provider string) {
+	t.Helper()
+
+	byID := map[string]BatchResult{}
+	for _, r := range results {
+		byID[r.CustomID] = r
+	}
+	if len(byID) != 3 {
+		t.Fatalf("expected 3 results, got %+v", results)
+	}
+
+	ok := byID["1"]
+	if ok.Err != nil || ok.Result.Headline != "Oil rose" || ok.Result.Category != "Economy" {
+		t.Errorf("request 1: got %+v", ok)
+	}
+	if ok.Result != nil && (!ok.Result.Usage.Batch || ok.Result.Usage.Provider != provider || ok.Result.Usage.InputTokens != 100) {
+		t.Errorf("request 1 usage: got %+v", ok.Result.Usage)
+	}
+	if Classify(byID["2"].Err).Class != ErrorParse {
+		t.Errorf("request 2: expected a parse error, got %v", byID["2"].Err)
+	}
+	if byID["3"].Err == nil {
+		t.Errorf("request 3: expected an error")
+	}
+}
+
+func TestOpenAIBatch(t *testing.T) {
+	srv, uploaded := fakeOpenAIBatchServer(t)
+
+	c := newOpenAIClient(Config{})
+	client := openai.NewClient(option.WithBaseURL(srv.URL+"/v1/"), option.WithAPIKey("test"), option.WithMaxRetries(0))
+	c.client = &client
+	ctx := context.Background()
+
+	id, err := c.SubmitBatch(ctx, batchRequests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != "batch_1" {
+		t.Errorf("batch id: got %q", id)
+	}
+	if len(*uploaded) != 3 {
+		t.Fatalf("expected 3 uploaded requests, got %d", len(*uploaded))
+	}
+	first := (*uploaded)[0]
+	if first.CustomID != "1" || first.URL != "/v1/chat/completions" || first.Body.Model != "gpt-4o-mini" || first.Body.ResponseFormat.Type != "json_schema" {
+		t.Errorf("expected a structured-output chat completion, got %+v", first)
+	}
+
+	status, err := c.GetBatch(ctx, id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *status != (BatchStatus{ID: "batch_1", State: BatchEnded, Total: 3, Succeeded: 2, Failed: 1}) {
+		t.Errorf("status: got %+v", status)
+	}
+
+	results, err := c.BatchResults(ctx, id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkBatchResults(t, results, ProviderOpenAI)
+	if results[0].Result != nil && results[0].Result.Usage.Model != "gpt-4o-mini" {
+		t.Errorf("usage should name the configured model, got %q", results[0].Result.Usage.Model)
+	}
+}
+
+// fakeAnthropicBatchServer implements the Message Batches endpoints with the
+// same outcomes as fakeOpenAIBatchServer.
+func fakeAnthropicBatchServer(t *testing.T) (*httptest.Server, *anthropic.MessageBatchNewParams) {
+	var submitted anthropic.MessageBatchNewParams
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/messages/batches", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &submitted); err != nil {
+			t.Fatalf("invalid batch request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"id": "msgbatch_1", "type": "message_batch", "processing_status": "in_progress"})
+	})
+	mux.HandleFunc("GET /v1/messages/batches/msgbatch_1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id": "msgbatch_1", "type": "message_batch", "processing_status": "ended",
+			"request_counts": map[string]any{"processing": 0, "succeeded": 2, "errored": 0, "canceled": 0, "expired": 1},
+		})
+	})
+	mux.HandleFunc("GET /v1/messages/batches/msgbatch_1/results", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-jsonl")
+		for id, input := range map[string]string{"1": batchOutput, "2": `{"headline":""}`} {
+			json.NewEncoder(w).Encode(map[string]any{
+				"custom_id": id,
+				"result": map[string]any{"type": "succeeded", "message": map[string]any{
+					"id": "msg_" + id, "type": "message", "role": "assistant", "model": "claude-haiku-4-5", "stop_reason": "tool_use",
+					"content": []map[string]any{{"type": "tool_use", "id": "toolu_" + id, "name": "transform_article", "input": json.RawMessage(input)}},
+					"usage":   map[string]any{"input_tokens": 100, "output_tokens": 20},
+				}},
+			})
+		}
+		fmt.Fprintln(w, `{"custom_id":"3","result":{"type":"expired"}}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &submitted
+}
+
+func TestAnthropicBatch(t *testing.T) {
+	srv, submitted := fakeAnthropicBatchServer(t)
+
+	c := newAnthropicClient(Config{})
+	client := anthropic.NewClient(anthropicoption.WithBaseURL(srv.URL), anthropicoption.WithAPIKey("test"), anthropicoption.WithMaxRetries(0))
+	c.client = &client
+	ctx := context.Background()
+
+	id, err := c.SubmitBatch(ctx, batchRequests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(submitted.Requests) != 3 || submitted.Requests[1].CustomID != "2" {
+		t.Fatalf("expected 3 requests, got %+v", submitted.Requests)
+	}
+	first := submitted.Requests[0].Params
+	if len(first.Tools) != 1 || first.ToolChoice.OfTool == nil || !strings.Contains(first.Messages[0].Content[0].OfText.Text, "OIL EXPLODES HIGHER") {
+		t.Errorf("expected a forced tool call over the article, got %+v", first)
+	}
+
+	status, err := c.GetBatch(ctx, id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *status != (BatchStatus{ID: "msgbatch_1", State: BatchEnded, Total: 3, Succeeded: 2, Failed: 1}) {
+		t.Errorf("status: got %+v", status)
+	}
+
+	results, err := c.BatchResults(ctx, id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkBatchResults(t, results, ProviderAnthropic)
+}
+
+func TestNewBatchClient(t *testing.T) {
+	if _, err := NewBatchClient(Config{Provider: ProviderLocal}); err == nil {
+		t.Errorf("expected an error for a provider without a batch API")
+	}
+
+	client, err := NewBatchClient(Config{Provider: ProviderFake})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	id, _ := client.SubmitBatch(context.Background(), batchRequests[:2])
+	status, err := client.GetBatch(context.Background(), id)
+	if err != nil || status.State != BatchEnded || status.Succeeded != 2 {
+		t.Errorf("unexpected status %+v, %v", status, err)
+	}
+}
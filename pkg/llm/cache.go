@@ -0,0 +1,195 @@
+package llm
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+)
+
+// ResponseCache stores encoded responses by key. Get reports a miss as false
+// with a nil error.
+type ResponseCache interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte) error
+	Delete(key string) error
+}
+
+// CacheKey identifies a transform by everything that determines its output:
+// the prompt, the provider and model, and the article text.
+func CacheKey(prompt Prompt, model string, input TransformInput) string {
+	h := sha256.New()
+	for _, part := range []string{prompt.Version, prompt.Body, model, input.Headline, input.Detail} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CachedClient reuses the transform of identical text, such as the same wire
+// story fetched from several sources or an article retried after its result
+// failed to save. Summaries and clusters are not cached. Cache errors are
+// logged and the call goes to the client, so a cache outage only costs
+// tokens. Results a fallback provider produced are not cached, since they are
+// looked up under cfg's provider and model. The client may be a
+// FailoverClient with cfg first in its chain.
+type CachedClient struct {
+	Client
+	cache     ResponseCache
+	prompt    Prompt
+	chainName string
+	provider  string
+	modelName string
+}
+
+// NewCachedClient caches the transforms client makes under cfg's transform
+// prompt, provider and model.
+func NewCachedClient(client Client, cache ResponseCache, cfg Config) *CachedClient {
+	return &CachedClient{
+		Client:    client,
+		cache:     cache,
+		prompt:    cfg.Prompts.withDefaults().Transform,
+		chainName: cfg.Provider,
+		provider:  cmp.Or(cfg.Provider, ProviderOpenAI),
+		modelName: modelName(cfg),
+	}
+}
+
+// Transform returns the cached result for input, marked Cached, or calls the
+// client and caches a successful result.
+func (c *CachedClient) Transform(input TransformInput) (*TransformResult, error) {
+	key := c.key(input)
+	if result := c.get(key); result != nil {
+		return result, nil
+	}
+
+	result, err := c.Client.Transform(input)
+	if err != nil {
+		return nil, err
+	}
+	c.set(key, result)
+	return result, nil
+}
+
+// TransformBatch serves the inputs it has cached and sends the rest to the
+// client together.
+func (c *CachedClient) TransformBatch(inputs []TransformInput) ([]*TransformResult, []error) {
+	results := make([]*TransformResult, len(inputs))
+	errs := make([]error, len(inputs))
+
+	var misses []int
+	var missInputs []TransformInput
+	for i, input := range inputs {
+		if results[i] = c.get(c.key(input)); results[i] == nil {
+			misses = append(misses, i)
+			missInputs = append(missInputs, input)
+		}
+	}
+	if len(misses) == 0 {
+		return results, errs
+	}
+
+	missResults, missErrs := c.Client.TransformBatch(missInputs)
+	for j, i := range misses {
+		results[i], errs[i] = missResults[j], missErrs[j]
+		if errs[i] == nil {
+			c.set(c.key(inputs[i]), results[i])
+		}
+	}
+	return results, errs
+}
+
+// get returns the cached result under key, marked Cached, or nil.
+func (c *CachedClient) get(key string) *TransformResult {
+	value, ok, err := c.cache.Get(key)
+	if err != nil {
+		slog.Warn("error reading LLM response cache", "error", err)
+	}
+	if !ok {
+		return nil
+	}
+
+	var result TransformResult
+	if err := json.Unmarshal(value, &result); err != nil {
+		slog.Warn("discarding unreadable LLM response cache entry", "error", err)
+		return nil
+	}
+	result.Cached = true
+	return &result
+}
+
+func (c *CachedClient) set(key string, result *TransformResult) {
+	if !c.fromPrimary(result) {
+		return
+	}
+
+	value, err := json.Marshal(result)
+	if err == nil {
+		err = c.cache.Set(key, value)
+	}
+	if err != nil {
+		slog.Warn("error writing LLM response cache", "error", err)
+	}
+}
+
+// fromPrimary reports whether result came from cfg's provider and model,
+// which a FailoverClient names "provider/model".
+func (c *CachedClient) fromPrimary(result *TransformResult) bool {
+	return result.Usage.Provider == c.provider &&
+		strings.TrimPrefix(result.ModelUsed, c.chainName+"/") == c.modelName
+}
+
+// Forget drops the cached result for input, so the next call asks the model
+// again.
+func (c *CachedClient) Forget(input TransformInput) error {
+	return c.cache.Delete(c.key(input))
+}
+
+func (c *CachedClient) key(input TransformInput) string {
+	return CacheKey(c.prompt, c.provider+":"+c.modelName, input)
+}
+
+// TieredCache looks entries up in each cache in order, copying a hit into the
+// faster caches before it, and writes to all of them.
+type TieredCache []ResponseCache
+
+func (t TieredCache) Get(key string) ([]byte, bool, error) {
+	var errs []error
+	for i, cache := range t {
+		value, ok, err := cache.Get(key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		for _, faster := range t[:i] {
+			if err := faster.Set(key, value); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return value, true, errors.Join(errs...)
+	}
+	return nil, false, errors.Join(errs...)
+}
+
+func (t TieredCache) Set(key string, value []byte) error {
+	var errs []error
+	for _, cache := range t {
+		errs = append(errs, cache.Set(key, value))
+	}
+	return errors.Join(errs...)
+}
+
+func (t TieredCache) Delete(key string) error {
+	var errs []error
+	for _, cache := range t {
+		errs = append(errs, cache.Delete(key))
+	}
+	return errors.Join(errs...)
+}
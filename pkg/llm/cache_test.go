@@ -0,0 +1,221 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+)
+
+type mapCache map[string][]byte
+
+func (m mapCache) Get(key string) ([]byte, bool, error) {
+	value, ok := m[key]
+	return value, ok, nil
+}
+
+func (m mapCache) Set(key string, value []byte) error {
+	m[key] = value
+	return nil
+}
+
+func (m mapCache) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+type brokenCache struct{}
+
+func (brokenCache) Get(key string) ([]byte, bool, error) {
+	return nil, false, errors.New("connection refused")
+}
+func (brokenCache) Set(key string, value []byte) error { return errors.New("connection refused") }
+func (brokenCache) Delete(key string) error            { return errors.New("connection refused") }
+
+func TestCacheKey(t *testing.T) {
+	input := TransformInput{Headline: "Fed holds rates", Detail: "The Fed held rates at 5.25%."}
+	prompt := Prompt{Name: PromptTransform, Version: "v1", Body: "Rewrite calmly."}
+	key := CacheKey(prompt, "openai:gpt-4o-mini", input)
+
+	if key != CacheKey(prompt, "openai:gpt-4o-mini", input) {
+		t.Errorf("key is not stable")
+	}
+	if key == CacheKey(Prompt{Name: PromptTransform, Version: "v2", Body: prompt.Body}, "openai:gpt-4o-mini", input) {
+		t.Errorf("key ignores the prompt version")
+	}
+	if key == CacheKey(Prompt{Name: PromptTransform, Version: "v1", Body: "Rewrite neutrally."}, "openai:gpt-4o-mini", input) {
+		t.Errorf("key ignores the prompt body")
+	}
+	if key == CacheKey(prompt, "anthropic:claude-4.5-haiku", input) {
+		t.Errorf("key ignores the model")
+	}
+	if key == CacheKey(prompt, "openai:gpt-4o-mini", TransformInput{Headline: input.Headline + input.Detail}) {
+		t.Errorf("key does not separate headline and detail")
+	}
+}
+
+func TestCachedClientResolvesDefaultModel(t *testing.T) {
+	input := TransformInput{Headline: "Fed holds rates"}
+	implicit := NewCachedClient(&FakeClient{}, mapCache{}, Config{Provider: ProviderOpenAI})
+	explicit := NewCachedClient(&FakeClient{}, mapCache{}, Config{Provider: ProviderOpenAI, Model: "gpt-4o-mini"})
+	other := NewCachedClient(&FakeClient{}, mapCache{}, Config{Provider: ProviderOpenAI, Model: "gpt-4.1"})
+
+	if implicit.key(input) != explicit.key(input) {
+		t.Errorf("default model is not keyed by name")
+	}
+	if implicit.key(input) == other.key(input) {
+		t.Errorf("different models share a key")
+	}
+}
+
+// fallbackClient answers as the next provider in a failover chain would.
+type fallbackClient struct {
+	FakeClient
+}
+
+func (c *fallbackClient) Transform(input TransformInput) (*TransformResult, error) {
+	result, err := c.FakeClient.Transform(input)
+	if err == nil {
+		result.ModelUsed = defaultAnthropicModel
+		result.Usage.Provider, result.Usage.Model = ProviderAnthropic, "claude-haiku-4-5"
+	}
+	return result, err
+}
+
+func TestCachedClientSkipsFallbackResults(t *testing.T) {
+	cache := mapCache{}
+	c := NewCachedClient(&fallbackClient{}, cache, Config{Provider: ProviderFake})
+
+	if _, err := c.Transform(TransformInput{Headline: "Fed holds rates"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cache) != 0 {
+		t.Errorf("fallback result was cached under the primary model")
+	}
+}
+
+func TestCachedClient(t *testing.T) {
+	inner := &FakeClient{}
+	cache := mapCache{}
+	c := NewCachedClient(inner, cache, Config{Provider: ProviderFake})
+	input := TransformInput{Headline: "BREAKING: TESLA STOCK CRASHES AFTER SHOCKING RECALL", Detail: "Shares fell 12%."}
+
+	first, err := c.Transform(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.Cached {
+		t.Errorf("first call should not be cached")
+	}
+
+	second, err := c.Transform(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !second.Cached || inner.Calls() != 1 {
+		t.Errorf("expected a cache hit, got cached=%v after %d calls", second.Cached, inner.Calls())
+	}
+	if second.Headline != first.Headline || second.Usage != first.Usage {
+		t.Errorf("cached result differs: %+v vs %+v", second, first)
+	}
+
+	if err := c.Forget(input); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.Transform(input)
+	if inner.Calls() != 2 {
+		t.Errorf("expected a call after Forget, got %d calls", inner.Calls())
+	}
+}
+
+func TestCachedClientSkipsFailures(t *testing.T) {
+	inner := &FakeClient{Errors: map[string]error{"Fed holds rates": errors.New("timeout")}}
+	cache := mapCache{}
+	c := NewCachedClient(inner, cache, Config{Provider: ProviderFake})
+
+	if _, err := c.Transform(TransformInput{Headline: "Fed holds rates"}); err == nil {
+		t.Fatalf("expected the scripted error")
+	}
+	if len(cache) != 0 {
+		t.Errorf("failed call was cached")
+	}
+
+	// A broken cache only costs a call.
+	c = NewCachedClient(inner, brokenCache{}, Config{Provider: ProviderFake})
+	if _, err := c.Transform(TransformInput{Headline: "Oil rises"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCachedClientTransformBatch(t *testing.T) {
+	inner := &FakeClient{}
+	c := NewCachedClient(inner, mapCache{}, Config{Provider: ProviderFake})
+	apple := TransformInput{Headline: "APPLE SOARS", Detail: "Shares rose 3%."}
+	oil := TransformInput{Headline: "OIL EXPLODES", Detail: "Crude rose 4%."}
+
+	c.Transform(apple)
+	results, errs := c.TransformBatch([]TransformInput{apple, oil})
+	if errs[0] != nil || errs[1] != nil {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if !results[0].Cached || results[1].Cached {
+		t.Errorf("expected only the first input to be cached, got %v %v", results[0].Cached, results[1].Cached)
+	}
+
+	results, _ = c.TransformBatch([]TransformInput{apple, oil})
+	if !results[1].Cached || inner.Calls() != 2 {
+		t.Errorf("expected both inputs cached after %d calls", inner.Calls())
+	}
+}
+
+func TestTieredCache(t *testing.T) {
+	fast, slow := mapCache{}, mapCache{}
+	slow["k"] = []byte("v")
+	tiers := TieredCache{brokenCache{}, fast, slow}
+
+	value, ok, err := tiers.Get("k")
+	if !ok || string(value) != "v" {
+		t.Fatalf("expected a hit from the slow tier, got %q %v", value, ok)
+	}
+	if err == nil {
+		t.Errorf("expected the broken tier's error")
+	}
+	if string(fast["k"]) != "v" {
+		t.Errorf("hit was not copied to the fast tier")
+	}
+
+	tiers.Delete("k")
+	if len(fast) != 0 || len(slow) != 0 {
+		t.Errorf("delete left entries: %v %v", fast, slow)
+	}
+}
+
+func TestNewWithCache(t *testing.T) {
+	client, err := New(Config{Provider: ProviderFake, Cache: mapCache{}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := client.(*CachedClient); !ok {
+		t.Errorf("expected a CachedClient, got %T", client)
+	}
+}
+
+func TestNewWithCacheAndFallbacks(t *testing.T) {
+	cache := mapCache{}
+	client, err := New(Config{Provider: ProviderFake, Fallbacks: []Config{{Provider: ProviderFake}}, Cache: cache})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	input := TransformInput{Headline: "Fed holds rates"}
+	first, err := client.Transform(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.ModelUsed != "fake/fake" || len(cache) != 1 {
+		t.Fatalf("primary result was not cached: model %q, %d entries", first.ModelUsed, len(cache))
+	}
+
+	second, _ := client.Transform(input)
+	if !second.Cached {
+		t.Errorf("expected a cache hit")
+	}
+}
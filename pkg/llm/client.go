@@ -11,9 +11,38 @@ type TransformResult struct {
 	Category       string
 	SentimentScore int
 	PromptVersion  string
+	PromptID       int64
 	ModelUsed      string
+	Usage          Usage
+	// Cached is set when the result came from a ResponseCache; Usage is then
+	// what the original call consumed.
+	Cached bool `json:"-"`
 }
 
+// Usage counts the tokens a call consumed, including any repair re-asks and,
+// for cluster summaries, every pass. Provider and Model name the API model
+// that was billed, e.g. "openai" and "gpt-4o-mini"; Batch is set for tokens
+// billed at the batch API's discount.
+type Usage struct {
+	Provider     string
+	Model        string
+	InputTokens  int64
+	OutputTokens int64
+	Batch        bool
+}
+
+func (u *Usage) add(other Usage) {
+	if u.Model == "" {
+		u.Provider, u.Model = other.Provider, other.Model
+	}
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+}
+
+// LLMClient transforms articles. TransformBatch rewrites several articles
+// in one call where it can, returning a result or an error for each input at
+// the same index.
 type LLMClient interface {
 	Transform(input TransformInput) (*TransformResult, error)
+	TransformBatch(inputs []TransformInput) ([]*TransformResult, []error)
 }
@@ -0,0 +1,247 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+	ProviderLocal     = "local"
+	ProviderFake      = "fake"
+)
+
+// Transform models used when none is configured.
+const (
+	defaultOpenAIModel    = "gpt-4o-mini"
+	defaultAnthropicModel = "claude-4.5-haiku"
+)
+
+// Config selects the provider and model a command talks to. Zero values fall
+// back to each provider's defaults and the built-in prompts. Fallbacks are
+// tried in order when the provider is unavailable and share its prompts.
+// BudgetModel is the cheaper model to switch to when spend runs high. Cache,
+// when set, holds transform results for reuse.
+type Config struct {
+	Provider     string
+	APIKey       string
+	Model        string
+	ClusterModel string
+	BudgetModel  string
+	MaxTokens    int64
+	Temperature  *float64
+	BaseURL      string
+	Prompts      PromptSet
+	Fallbacks    []Config
+	Cache        ResponseCache
+}
+
+// modelName is the model cfg's client reports in ModelUsed.
+func modelName(cfg Config) string {
+	switch {
+	case cfg.Provider == ProviderFake:
+		return fakeModelName
+	case cfg.Model != "":
+		return cfg.Model
+	case cfg.Provider == ProviderOpenAI, cfg.Provider == "":
+		return defaultOpenAIModel
+	case cfg.Provider == ProviderAnthropic:
+		return defaultAnthropicModel
+	default:
+		return ""
+	}
+}
+
+// Client is implemented by every provider and covers all the LLM calls the
+// commands make.
+type Client interface {
+	LLMClient
+	SummaryClient
+	ClusterSummarizer
+}
+
+// ConfigFromEnv reads <PREFIX>_LLM_PROVIDER, _MODEL, _CLUSTER_MODEL,
+// _BUDGET_MODEL, _MAX_TOKENS, _TEMPERATURE, _API_KEY and _BASE_URL, trying
+// each prefix in order and then the unprefixed LLM_* variables so one .env
+// can configure every command. Without an explicit key the provider's usual
+// OPENAI_API_KEY or ANTHROPIC_API_KEY is used.
+//
+// PROVIDER may be a comma-separated failover chain such as
+// "openai,anthropic:claude-haiku-4-5", where each entry can pin a model. The
+// model, cluster model, budget model and API key variables apply to the first
+// entry only; BASE_URL applies to every "local" entry.
+func ConfigFromEnv(prefixes ...string) (Config, error) {
+	// lookup returns the first variable set and its level: the index of its
+	// prefix, or len(prefixes) for the unprefixed one.
+	lookup := func(name string) (string, int) {
+		for i, prefix := range prefixes {
+			if prefix == "" {
+				continue
+			}
+			if v := os.Getenv(prefix + "_LLM_" + name); v != "" {
+				return v, i
+			}
+		}
+		return os.Getenv("LLM_" + name), len(prefixes)
+	}
+	get := func(name string) string {
+		v, _ := lookup(name)
+		return v
+	}
+
+	provider, providerLevel := lookup("PROVIDER")
+	chain := parseProviderChain(provider)
+	if len(chain) == 0 {
+		chain = []Config{{Provider: ProviderOpenAI}}
+	}
+
+	// Model names belong to a provider, so they are not inherited from a
+	// level less specific than the provider's: a command that picks its own
+	// provider keeps that provider's default model rather than LLM_MODEL.
+	getModel := func(name string) string {
+		v, level := lookup(name)
+		if level > providerLevel {
+			return ""
+		}
+		return v
+	}
+
+	cfg := chain[0]
+	cfg.APIKey = get("API_KEY")
+	cfg.ClusterModel = getModel("CLUSTER_MODEL")
+	cfg.BudgetModel = getModel("BUDGET_MODEL")
+	if cfg.Model == "" {
+		cfg.Model = getModel("MODEL")
+	}
+
+	if v := get("MAX_TOKENS"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return Config{}, fmt.Errorf("invalid LLM max tokens %q", v)
+		}
+		cfg.MaxTokens = n
+	}
+
+	if v := get("TEMPERATURE"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil || t < 0 || t > 2 {
+			return Config{}, fmt.Errorf("invalid LLM temperature %q", v)
+		}
+		cfg.Temperature = &t
+	}
+
+	if cfg.APIKey == "" {
+		cfg.APIKey = defaultAPIKey(cfg.Provider)
+	}
+
+	baseURL := get("BASE_URL")
+	if cfg.Provider == ProviderLocal {
+		cfg.BaseURL = baseURL
+	}
+
+	for _, fallback := range chain[1:] {
+		fallback.APIKey = defaultAPIKey(fallback.Provider)
+		fallback.MaxTokens = cfg.MaxTokens
+		fallback.Temperature = cfg.Temperature
+		if fallback.Provider == ProviderLocal {
+			fallback.BaseURL = baseURL
+		}
+		cfg.Fallbacks = append(cfg.Fallbacks, fallback)
+	}
+
+	return cfg, nil
+}
+
+// Providers lists the providers cfg may call, in failover order.
+func (c Config) Providers() []string {
+	providers := []string{c.Provider}
+	for _, f := range c.Fallbacks {
+		providers = append(providers, f.Provider)
+	}
+	return providers
+}
+
+// ForBudget returns cfg with its first provider switched to BudgetModel for
+// every call, or false when no budget model is configured.
+func (c Config) ForBudget() (Config, bool) {
+	if c.BudgetModel == "" {
+		return c, false
+	}
+	c.Model, c.ClusterModel = c.BudgetModel, c.BudgetModel
+	return c, true
+}
+
+// parseProviderChain splits "provider[:model],..." into one Config per entry.
+func parseProviderChain(v string) []Config {
+	var chain []Config
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		provider, model, _ := strings.Cut(entry, ":")
+		chain = append(chain, Config{
+			Provider: strings.ToLower(strings.TrimSpace(provider)),
+			Model:    strings.TrimSpace(model),
+		})
+	}
+	return chain
+}
+
+func defaultAPIKey(provider string) string {
+	switch provider {
+	case ProviderOpenAI:
+		return os.Getenv("OPENAI_API_KEY")
+	case ProviderAnthropic:
+		return os.Getenv("ANTHROPIC_API_KEY")
+	default:
+		return ""
+	}
+}
+
+// New returns the client for cfg.Provider, wrapped in a FailoverClient when
+// cfg has fallbacks and in a CachedClient when cfg has a cache.
+func New(cfg Config) (Client, error) {
+	if cfg.Cache != nil {
+		uncached := cfg
+		uncached.Cache = nil
+		client, err := New(uncached)
+		if err != nil {
+			return nil, err
+		}
+		return NewCachedClient(client, cfg.Cache, cfg), nil
+	}
+
+	if len(cfg.Fallbacks) == 0 {
+		return newProvider(cfg)
+	}
+
+	providers := make([]Provider, 0, len(cfg.Fallbacks)+1)
+	for _, c := range append([]Config{cfg}, cfg.Fallbacks...) {
+		c.Prompts = cfg.Prompts
+		client, err := newProvider(c)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, Provider{Name: c.Provider, Client: client})
+	}
+	return NewFailoverClient(providers...), nil
+}
+
+func newProvider(cfg Config) (Client, error) {
+	switch cfg.Provider {
+	case ProviderOpenAI, "":
+		return newOpenAIClient(cfg), nil
+	case ProviderAnthropic:
+		return newAnthropicClient(cfg), nil
+	case ProviderLocal:
+		return newLocalClient(cfg)
+	case ProviderFake:
+		return &FakeClient{Prompts: cfg.Prompts}, nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.Provider)
+	}
+}
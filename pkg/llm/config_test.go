@@ -0,0 +1,112 @@
+package llm
+
+import "testing"
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "openai")
+	t.Setenv("LLM_MODEL", "gpt-4o-mini")
+	t.Setenv("SUMMARIZER_LLM_PROVIDER", "Anthropic")
+	t.Setenv("SUMMARIZER_LLM_TEMPERATURE", "0.2")
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant")
+	t.Setenv("OPENAI_API_KEY", "sk-openai")
+
+	cfg, err := ConfigFromEnv("SUMMARIZER")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Provider != ProviderAnthropic {
+		t.Errorf("provider: got %q, want %q", cfg.Provider, ProviderAnthropic)
+	}
+	if cfg.Model != "" {
+		t.Errorf("an OpenAI LLM_MODEL should not apply to the Anthropic provider, got %q", cfg.Model)
+	}
+	if cfg.APIKey != "sk-ant" {
+		t.Errorf("api key: got %q, want the Anthropic key", cfg.APIKey)
+	}
+	if cfg.Temperature == nil || *cfg.Temperature != 0.2 {
+		t.Errorf("temperature: got %v, want 0.2", cfg.Temperature)
+	}
+
+	cfg, err = ConfigFromEnv("TRANSFORMER")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Provider != ProviderOpenAI || cfg.Model != "gpt-4o-mini" || cfg.APIKey != "sk-openai" || cfg.Temperature != nil {
+		t.Errorf("unexpected transformer config: %+v", cfg)
+	}
+
+	t.Setenv("SUMMARIZER_ARM_B_LLM_MODEL", "claude-haiku-4-5")
+	cfg, err = ConfigFromEnv("SUMMARIZER_ARM_B", "SUMMARIZER")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Provider != ProviderAnthropic || cfg.Model != "claude-haiku-4-5" || cfg.Temperature == nil {
+		t.Errorf("prefixes should be tried in order, got %+v", cfg)
+	}
+}
+
+func TestConfigFromEnvInvalid(t *testing.T) {
+	t.Setenv("LLM_MAX_TOKENS", "lots")
+	if _, err := ConfigFromEnv(""); err == nil {
+		t.Error("expected an error for a non-numeric max tokens")
+	}
+}
+
+func TestNewUnknownProvider(t *testing.T) {
+	if _, err := New(Config{Provider: "gemini"}); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
+
+func TestConfigFromEnvProviderChain(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "openai, Anthropic:claude-sonnet-4-6")
+	t.Setenv("LLM_MODEL", "gpt-4.1-mini")
+	t.Setenv("LLM_MAX_TOKENS", "800")
+	t.Setenv("OPENAI_API_KEY", "sk-openai")
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant")
+
+	cfg, err := ConfigFromEnv("TRANSFORMER")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Provider != ProviderOpenAI || cfg.Model != "gpt-4.1-mini" || cfg.APIKey != "sk-openai" {
+		t.Errorf("unexpected primary config: %+v", cfg)
+	}
+	if len(cfg.Fallbacks) != 1 {
+		t.Fatalf("expected one fallback, got %d", len(cfg.Fallbacks))
+	}
+
+	fallback := cfg.Fallbacks[0]
+	if fallback.Provider != ProviderAnthropic || fallback.Model != "claude-sonnet-4-6" ||
+		fallback.APIKey != "sk-ant" || fallback.MaxTokens != 800 {
+		t.Errorf("unexpected fallback config: %+v", fallback)
+	}
+
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := client.(*FailoverClient); !ok {
+		t.Errorf("expected a FailoverClient, got %T", client)
+	}
+
+	if providers := cfg.Providers(); len(providers) != 2 || providers[1] != ProviderAnthropic {
+		t.Errorf("providers: got %v", providers)
+	}
+	if _, ok := cfg.ForBudget(); ok {
+		t.Errorf("no budget model is configured")
+	}
+
+	t.Setenv("TRANSFORMER_LLM_BUDGET_MODEL", "gpt-4.1-nano")
+	cfg, err = ConfigFromEnv("TRANSFORMER")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cheap, ok := cfg.ForBudget()
+	if !ok || cheap.Model != "gpt-4.1-nano" || cheap.ClusterModel != "gpt-4.1-nano" || len(cheap.Fallbacks) != 1 {
+		t.Errorf("unexpected budget config: %+v", cheap)
+	}
+	if cfg.Model != "gpt-4.1-mini" {
+		t.Errorf("ForBudget should not change the original config, got model %q", cfg.Model)
+	}
+}
@@ -0,0 +1,215 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go"
+)
+
+type ErrorClass string
+
+const (
+	ErrorRateLimit ErrorClass = "rate_limit"
+	ErrorServer    ErrorClass = "server_error"
+	ErrorTimeout   ErrorClass = "timeout"
+	ErrorParse     ErrorClass = "parse_error"
+	ErrorRefusal   ErrorClass = "refusal"
+	ErrorUnknown   ErrorClass = "llm_error"
+
+	// ErrorValidation is a well-formed response that failed a check on its
+	// content, such as dropping facts from the original.
+	ErrorValidation ErrorClass = "validation_failed"
+)
+
+// Error is an LLM failure tagged with its class and, for rate limits, how
+// long the provider asked us to wait.
+type Error struct {
+	Class      ErrorClass
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// UsageError is a failed call that was still billed, such as output that
+// could not be parsed or repaired, or a refusal. Usage is what it cost.
+type UsageError struct {
+	Usage Usage
+	Err   error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// withUsage attaches the usage of a failed call to err, adding to any usage
+// err already carries. Calls that used no tokens leave err unchanged.
+func withUsage(usage Usage, err error) error {
+	if err == nil || (usage.InputTokens == 0 && usage.OutputTokens == 0) {
+		return err
+	}
+	if usageErr, ok := err.(*UsageError); ok {
+		usage.add(usageErr.Usage)
+		return &UsageError{Usage: usage, Err: usageErr.Err}
+	}
+	return &UsageError{Usage: usage, Err: err}
+}
+
+// BilledUsage returns the usage of a failed call and whether it was billed.
+func BilledUsage(err error) (Usage, bool) {
+	var usageErr *UsageError
+	if errors.As(err, &usageErr) {
+		return usageErr.Usage, true
+	}
+	return Usage{}, false
+}
+
+// Classify inspects err and returns it as an *Error. Errors that are already
+// classified are returned unchanged.
+func Classify(err error) *Error {
+	var llmErr *Error
+	if errors.As(err, &llmErr) {
+		return llmErr
+	}
+
+	var openaiErr *openai.Error
+	if errors.As(err, &openaiErr) {
+		return classifyStatus(err, openaiErr.StatusCode, openaiErr.Response)
+	}
+
+	var anthropicErr *anthropic.Error
+	if errors.As(err, &anthropicErr) {
+		return classifyStatus(err, anthropicErr.StatusCode, anthropicErr.Response)
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &Error{Class: ErrorTimeout, Err: err}
+	}
+
+	return &Error{Class: ErrorUnknown, Err: err}
+}
+
+func classifyStatus(err error, status int, resp *http.Response) *Error {
+	switch {
+	case status == http.StatusTooManyRequests:
+		e := &Error{Class: ErrorRateLimit, Err: err}
+		if resp != nil {
+			e.RetryAfter = parseRetryAfter(resp.Header)
+		}
+		return e
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return &Error{Class: ErrorTimeout, Err: err}
+	case status >= 500:
+		return &Error{Class: ErrorServer, Err: err}
+	default:
+		return &Error{Class: ErrorUnknown, Err: err}
+	}
+}
+
+// parseRetryAfter reads retry-after-ms (OpenAI) or the standard Retry-After
+// header in either its seconds or HTTP-date form.
+func parseRetryAfter(h http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(h.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+
+	if at, err := http.ParseTime(v); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+func newParseError(format string, args ...any) *Error {
+	return &Error{Class: ErrorParse, Err: fmt.Errorf(format, args...)}
+}
+
+func newRefusalError(provider, reason string) *Error {
+	return &Error{Class: ErrorRefusal, Err: fmt.Errorf("%s refused the request: %s", provider, reason)}
+}
+
+// RetryPolicy controls how often and how quickly a class of failure is retried.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var retryPolicies = map[ErrorClass]RetryPolicy{
+	ErrorRateLimit:  {MaxAttempts: 8, BaseDelay: 10 * time.Second, MaxDelay: 10 * time.Minute},
+	ErrorServer:     {MaxAttempts: 5, BaseDelay: 5 * time.Second, MaxDelay: 5 * time.Minute},
+	ErrorTimeout:    {MaxAttempts: 4, BaseDelay: 5 * time.Second, MaxDelay: 2 * time.Minute},
+	ErrorParse:      {MaxAttempts: 2, BaseDelay: time.Second, MaxDelay: 10 * time.Second},
+	ErrorRefusal:    {MaxAttempts: 1},
+	ErrorUnknown:    {MaxAttempts: 3, BaseDelay: 5 * time.Second, MaxDelay: time.Minute},
+	ErrorValidation: {MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 30 * time.Second},
+}
+
+func PolicyFor(class ErrorClass) RetryPolicy {
+	if p, ok := retryPolicies[class]; ok {
+		return p
+	}
+	return retryPolicies[ErrorUnknown]
+}
+
+// Retryable reports whether another attempt is allowed after attempt failures.
+func (p RetryPolicy) Retryable(attempt int) bool {
+	return attempt < p.MaxAttempts
+}
+
+// Backoff returns the delay before retrying after the given attempt (1-based):
+// exponential growth capped at MaxDelay with equal jitter, never shorter than
+// the provider's Retry-After.
+func (p RetryPolicy) Backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	if d > 0 {
+		half := d / 2
+		d = half + rand.N(half+1)
+	}
+
+	if retryAfter > d {
+		return retryAfter
+	}
+	return d
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go"
+)
+
+func TestClassify(t *testing.T) {
+	rateLimited := &http.Response{Header: http.Header{"Retry-After": []string{"12"}}}
+
+	tests := []struct {
+		name       string
+		err        error
+		want       ErrorClass
+		retryAfter time.Duration
+	}{
+		{
+			name:       "openai 429 with Retry-After",
+			err:        fmt.Errorf("openai API error: %w", &openai.Error{StatusCode: 429, Response: rateLimited}),
+			want:       ErrorRateLimit,
+			retryAfter: 12 * time.Second,
+		},
+		{
+			name: "anthropic 529 overloaded",
+			err:  fmt.Errorf("anthropic API error: %w", &anthropic.Error{StatusCode: 529}),
+			want: ErrorServer,
+		},
+		{
+			name: "openai 400 is not retryable",
+			err:  &openai.Error{StatusCode: 400},
+			want: ErrorUnknown,
+		},
+		{
+			name: "context deadline",
+			err:  fmt.Errorf("openai API error: %w", context.DeadlineExceeded),
+			want: ErrorTimeout,
+		},
+		{
+			name: "parse failure",
+			err:  newParseError("failed to parse response: %w, content: %s", errors.New("bad json"), "{"),
+			want: ErrorParse,
+		},
+		{
+			name: "refusal",
+			err:  newRefusalError("anthropic", "refusal"),
+			want: ErrorRefusal,
+		},
+		{
+			name: "plain error",
+			err:  errors.New("no response from openai"),
+			want: ErrorUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.err)
+			if got.Class != tt.want {
+				t.Errorf("class: got %q, want %q", got.Class, tt.want)
+			}
+			if got.RetryAfter != tt.retryAfter {
+				t.Errorf("retry after: got %v, want %v", got.RetryAfter, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter(http.Header{"Retry-After-Ms": []string{"1500"}}); got != 1500*time.Millisecond {
+		t.Errorf("retry-after-ms: got %v", got)
+	}
+
+	future := time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(http.Header{"Retry-After": []string{future}}); got <= 0 || got > 30*time.Second {
+		t.Errorf("http date: got %v", got)
+	}
+
+	if got := parseRetryAfter(http.Header{}); got != 0 {
+		t.Errorf("missing header: got %v", got)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 8 * time.Second}
+
+	for attempt, max := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 6: 8 * time.Second} {
+		for i := 0; i < 50; i++ {
+			d := p.Backoff(attempt, 0)
+			if d < max/2 || d > max {
+				t.Fatalf("attempt %d: backoff %v outside [%v, %v]", attempt, d, max/2, max)
+			}
+		}
+	}
+
+	if d := p.Backoff(1, time.Minute); d != time.Minute {
+		t.Errorf("Retry-After not honored: got %v", d)
+	}
+}
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	if PolicyFor(ErrorRefusal).Retryable(1) {
+		t.Error("refusals should not be retried")
+	}
+	if !PolicyFor(ErrorRateLimit).Retryable(3) {
+		t.Error("rate limits should be retried on attempt 3")
+	}
+	if PolicyFor(ErrorUnknown).Retryable(3) {
+		t.Error("unknown errors should stop after 3 attempts")
+	}
+}
+
+func TestFailedCallsKeepTheirUsage(t *testing.T) {
+	srv, requests := newFakeChatServer(t, func(system, user string) string {
+		return `{"headline": "Apple rose"}`
+	})
+	client, err := NewLocalClient(srv.URL+"/v1", "llama3.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = client.Transform(TransformInput{Headline: "APPLE SOARS", Detail: "Shares skyrocket 3%"})
+	if Classify(err).Class != ErrorParse {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+
+	// The call and its repair were both billed.
+	usage, ok := BilledUsage(err)
+	if !ok || len(*requests) != 2 || usage != (Usage{Provider: ProviderLocal, Model: "llama3.1", InputTokens: 200, OutputTokens: 40}) {
+		t.Errorf("billed usage: got %+v, %v after %d requests", usage, ok, len(*requests))
+	}
+
+	if _, ok := BilledUsage(errors.New("connection refused")); ok {
+		t.Error("an error without usage should not be billed")
+	}
+}
@@ -0,0 +1,163 @@
+package llm
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+const (
+	breakerThreshold = 5
+	breakerCooldown  = time.Minute
+)
+
+// Provider is one named entry in a failover chain.
+type Provider struct {
+	Name   string
+	Client Client
+}
+
+type failoverProvider struct {
+	Provider
+	breaker *breaker
+}
+
+// FailoverClient tries its providers in order, moving on when a provider is
+// rate limiting, erroring or timing out. Each provider has its own circuit
+// breaker so a provider that keeps failing is skipped until it recovers.
+// Results record the provider that produced them as "provider/model".
+type FailoverClient struct {
+	providers []*failoverProvider
+}
+
+func NewFailoverClient(providers ...Provider) *FailoverClient {
+	c := &FailoverClient{}
+	for _, p := range providers {
+		c.providers = append(c.providers, &failoverProvider{
+			Provider: p,
+			breaker:  newBreaker(breakerThreshold, breakerCooldown),
+		})
+	}
+	return c
+}
+
+func (c *FailoverClient) Transform(input TransformInput) (*TransformResult, error) {
+	result, name, err := callWithFailover(c, "transform", func(client Client) (*TransformResult, error) {
+		return client.Transform(input)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.ModelUsed = name + "/" + result.ModelUsed
+	return result, nil
+}
+
+// TransformBatch moves on to the next provider only when every input failed,
+// so one article's bad output does not fail over the rest.
+func (c *FailoverClient) TransformBatch(inputs []TransformInput) ([]*TransformResult, []error) {
+	var errs []error
+	results, name, err := callWithFailover(c, "transform", func(client Client) ([]*TransformResult, error) {
+		var results []*TransformResult
+		results, errs = client.TransformBatch(inputs)
+		return results, allFailed(errs)
+	})
+	if err != nil {
+		if len(errs) != len(inputs) {
+			errs = make([]error, len(inputs))
+			for i := range errs {
+				errs[i] = err
+			}
+		}
+		return make([]*TransformResult, len(inputs)), errs
+	}
+
+	for _, result := range results {
+		if result != nil {
+			result.ModelUsed = name + "/" + result.ModelUsed
+		}
+	}
+	return results, errs
+}
+
+// allFailed returns the first error when every entry of errs is set.
+func allFailed(errs []error) error {
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs[0]
+}
+
+func (c *FailoverClient) Summarize(articles []SummaryInput) (*SummaryResult, error) {
+	result, name, err := callWithFailover(c, "summarize", func(client Client) (*SummaryResult, error) {
+		return client.Summarize(articles)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.ModelUsed = name + "/" + result.ModelUsed
+	return result, nil
+}
+
+func (c *FailoverClient) ClusterAndSummarize(articles []SummaryInput) (*ClusterSummaryResult, error) {
+	result, name, err := callWithFailover(c, "cluster", func(client Client) (*ClusterSummaryResult, error) {
+		return client.ClusterAndSummarize(articles)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.ModelUsed = name + "/" + result.ModelUsed
+	return result, nil
+}
+
+// callWithFailover runs fn against each available provider in turn and
+// returns the first success along with the provider's name. Errors that are
+// about the request rather than the provider, such as refusals or unparseable
+// output, are returned without trying the next provider.
+func callWithFailover[T any](c *FailoverClient, op string, fn func(Client) (T, error)) (T, string, error) {
+	var zero T
+	var lastErr error
+
+	for _, p := range c.providers {
+		if !p.breaker.allow() {
+			continue
+		}
+
+		result, err := fn(p.Client)
+		if err == nil {
+			p.breaker.success()
+			return result, p.Name, nil
+		}
+
+		llmErr := Classify(err)
+		if !failsOver(llmErr.Class) {
+			p.breaker.success()
+			return zero, p.Name, err
+		}
+
+		if p.breaker.failure() {
+			slog.Warn("LLM provider circuit opened", "provider", p.Name, "cooldown", breakerCooldown.String())
+		}
+		slog.Warn("LLM provider failed, trying next provider", "provider", p.Name, "op", op,
+			"error", err, "error_type", llmErr.Class)
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		lastErr = &Error{Class: ErrorServer, Err: errors.New("all LLM providers are unavailable")}
+	}
+	return zero, "", lastErr
+}
+
+func failsOver(class ErrorClass) bool {
+	switch class {
+	case ErrorRateLimit, ErrorServer, ErrorTimeout:
+		return true
+	default:
+		return false
+	}
+}
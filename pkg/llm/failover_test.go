@@ -0,0 +1,162 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubClient struct {
+	model string
+	err   error
+	calls int
+}
+
+func (s *stubClient) Transform(input TransformInput) (*TransformResult, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &TransformResult{Headline: input.Headline, ModelUsed: s.model}, nil
+}
+
+func (s *stubClient) TransformBatch(inputs []TransformInput) ([]*TransformResult, []error) {
+	s.calls++
+	results := make([]*TransformResult, len(inputs))
+	errs := make([]error, len(inputs))
+	for i, input := range inputs {
+		if errs[i] = s.err; s.err == nil {
+			results[i] = &TransformResult{Headline: input.Headline, ModelUsed: s.model}
+		}
+	}
+	return results, errs
+}
+
+func (s *stubClient) Summarize(articles []SummaryInput) (*SummaryResult, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &SummaryResult{ModelUsed: s.model}, nil
+}
+
+func (s *stubClient) ClusterAndSummarize(articles []SummaryInput) (*ClusterSummaryResult, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &ClusterSummaryResult{ModelUsed: s.model}, nil
+}
+
+func serverError() error {
+	return &Error{Class: ErrorServer, Err: errors.New("503 Service Unavailable")}
+}
+
+func TestFailoverOnProviderError(t *testing.T) {
+	primary := &stubClient{model: "gpt-4o-mini", err: serverError()}
+	secondary := &stubClient{model: "claude-4.5-haiku"}
+	c := NewFailoverClient(Provider{"openai", primary}, Provider{"anthropic", secondary})
+
+	result, err := c.Transform(TransformInput{Headline: "Stocks rose"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ModelUsed != "anthropic/claude-4.5-haiku" {
+		t.Errorf("model used: got %q", result.ModelUsed)
+	}
+}
+
+func TestFailoverStopsOnRequestError(t *testing.T) {
+	primary := &stubClient{model: "gpt-4o-mini", err: newRefusalError("openai", "content filtered")}
+	secondary := &stubClient{model: "claude-4.5-haiku"}
+	c := NewFailoverClient(Provider{"openai", primary}, Provider{"anthropic", secondary})
+
+	_, err := c.Transform(TransformInput{})
+	if Classify(err).Class != ErrorRefusal {
+		t.Errorf("expected the refusal to be returned, got %v", err)
+	}
+	if secondary.calls != 0 {
+		t.Errorf("secondary should not be called for a refusal, got %d calls", secondary.calls)
+	}
+}
+
+func TestFailoverAllProvidersDown(t *testing.T) {
+	primary := &stubClient{err: serverError()}
+	secondary := &stubClient{err: &Error{Class: ErrorRateLimit, Err: errors.New("429 Too Many Requests")}}
+	c := NewFailoverClient(Provider{"openai", primary}, Provider{"local", secondary})
+
+	_, err := c.Summarize(nil)
+	if Classify(err).Class != ErrorRateLimit {
+		t.Errorf("expected the last provider's error, got %v", err)
+	}
+}
+
+func TestFailoverCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	primary := &stubClient{model: "gpt-4o-mini", err: serverError()}
+	secondary := &stubClient{model: "claude-4.5-haiku"}
+	c := NewFailoverClient(Provider{"openai", primary}, Provider{"anthropic", secondary})
+	c.providers[0].breaker.now = func() time.Time { return now }
+
+	for i := 0; i < breakerThreshold+3; i++ {
+		if _, err := c.Transform(TransformInput{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if primary.calls != breakerThreshold {
+		t.Errorf("open breaker should skip the primary: got %d calls, want %d", primary.calls, breakerThreshold)
+	}
+
+	now = now.Add(breakerCooldown + time.Second)
+	primary.err = nil
+	result, err := c.ClusterAndSummarize(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ModelUsed != "openai/gpt-4o-mini" {
+		t.Errorf("primary should be probed after cooldown, got %q", result.ModelUsed)
+	}
+}
+
+func TestBreakerSingleProbe(t *testing.T) {
+	now := time.Now()
+	b := newBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	if b.allow() {
+		t.Fatal("breaker should be open")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !b.allow() {
+		t.Fatal("breaker should allow a probe after cooldown")
+	}
+	if b.allow() {
+		t.Error("only one probe should be in flight")
+	}
+
+	if !b.failure() {
+		t.Error("failed probe should re-open the breaker")
+	}
+}
+
+func TestFailoverTransformBatch(t *testing.T) {
+	primary := &stubClient{model: "gpt-4o-mini", err: serverError()}
+	secondary := &stubClient{model: "claude-4.5-haiku"}
+	c := NewFailoverClient(Provider{"openai", primary}, Provider{"anthropic", secondary})
+
+	results, errs := c.TransformBatch([]TransformInput{{Headline: "Stocks rose"}, {Headline: "Oil fell"}})
+	if errs[0] != nil || errs[1] != nil {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if results[1].Headline != "Oil fell" || results[1].ModelUsed != "anthropic/claude-4.5-haiku" {
+		t.Errorf("unexpected result: %+v", results[1])
+	}
+
+	secondary.err = serverError()
+	results, errs = c.TransformBatch([]TransformInput{{Headline: "Stocks rose"}, {Headline: "Oil fell"}})
+	if len(results) != 2 || Classify(errs[1]).Class != ErrorServer {
+		t.Errorf("expected an error per input when every provider is down, got %v", errs)
+	}
+}
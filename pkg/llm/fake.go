@@ -0,0 +1,354 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const fakeModelName = "fake"
+
+var (
+	fakeUrgency   = regexp.MustCompile(`(?i)\b(breaking|alert|just in)\b:?\s*|\bNOW\b:?\s*`)
+	fakeDropVerbs = regexp.MustCompile(`(?i)\b(crash(es|ed|ing)?|plummet(s|ed|ing)?|tank(s|ed|ing)?)\b`)
+	fakeRiseVerbs = regexp.MustCompile(`(?i)\b(explode[sd]?|exploding|soar(s|ed|ing)?|skyrocket(s|ed|ing)?)\b`)
+	fakeJudgement = regexp.MustCompile(`(?i)\b(smart|dumb|crazy|shocking|terrifying|bloodbath|shockwave|chaos)\b`)
+	fakeWill      = regexp.MustCompile(`\bwill\b`)
+	fakeSpaces    = regexp.MustCompile(`\s+`)
+)
+
+// fakeCategories maps keywords to categories; the first match wins.
+var fakeCategories = []struct {
+	category string
+	keywords []string
+}{
+	{"Earnings", []string{"earnings", "revenue", "quarterly", "eps", "profit"}},
+	{"Crypto", []string{"bitcoin", "crypto", "ether", "blockchain"}},
+	{"Mergers & Acquisitions", []string{"merger", "acquire", "acquisition", "buyout", "takeover"}},
+	{"Policy & Regulation", []string{"sec ", "regulat", "tariff", "lawmakers", "antitrust"}},
+	{"Economy", []string{"fed ", "inflation", "gdp", "jobs report", "interest rate", "economy"}},
+	{"Market Movement", []string{"stock", "s&p", "dow", "nasdaq", "index", "market"}},
+}
+
+// FakeClient is a deterministic, offline Client for tests and local runs. It
+// rewrites text with the same rules the transform prompt gives the model,
+// picks categories by keyword and clusters articles that share a symbol.
+// Errors, keyed by headline, scripts failures for specific articles.
+// Prompts only sets the prompt version reported on results.
+type FakeClient struct {
+	Errors  map[string]error
+	Prompts PromptSet
+
+	mu      sync.Mutex
+	calls   int
+	batches map[string][]BatchResult
+}
+
+// Calls returns how many LLM calls the client has answered.
+func (f *FakeClient) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func (f *FakeClient) call(headline string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.Errors[headline]
+}
+
+func (f *FakeClient) Transform(input TransformInput) (*TransformResult, error) {
+	if err := f.call(input.Headline); err != nil {
+		return nil, err
+	}
+	return f.transform(input), nil
+}
+
+// TransformBatch answers all inputs in one call. Inputs with a scripted
+// error fall back to Transform, which returns it.
+func (f *FakeClient) TransformBatch(inputs []TransformInput) ([]*TransformResult, []error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+
+	results := make([]*TransformResult, len(inputs))
+	errs := make([]error, len(inputs))
+	for i, input := range inputs {
+		f.mu.Lock()
+		scripted := f.Errors[input.Headline] != nil
+		f.mu.Unlock()
+
+		if scripted {
+			results[i], errs[i] = f.Transform(input)
+		} else {
+			results[i] = f.transform(input)
+		}
+	}
+	return results, errs
+}
+
+func (f *FakeClient) transform(input TransformInput) *TransformResult {
+	prompt := f.Prompts.withDefaults().Transform
+	headline, headlineChanges := fakeNeutralize(input.Headline)
+	detail, detailChanges := fakeNeutralize(input.Detail)
+
+	return &TransformResult{
+		Headline:       headline,
+		Detail:         detail,
+		Category:       fakeCategory(input.Headline + " " + input.Detail),
+		SentimentScore: min(1+headlineChanges+detailChanges, 10),
+		PromptVersion:  prompt.Version,
+		PromptID:       prompt.ID,
+		ModelUsed:      fakeModelName,
+		Usage:          fakeUsage(prompt.Body+input.Headline+input.Detail, headline+detail),
+	}
+}
+
+func (f *FakeClient) Summarize(articles []SummaryInput) (*SummaryResult, error) {
+	if err := f.call(""); err != nil {
+		return nil, err
+	}
+
+	var bullets []string
+	for _, a := range articles {
+		if len(bullets) == 5 {
+			break
+		}
+		headline, _ := fakeNeutralize(a.Headline)
+		bullets = append(bullets, headline)
+	}
+
+	paragraph := fmt.Sprintf("%d articles were published in this period.", len(articles))
+	return &SummaryResult{
+		Paragraph: paragraph,
+		Bullets:   bullets,
+		ModelUsed: fakeModelName,
+		Usage:     fakeUsage(f.Prompts.withDefaults().Summary.Body+fakeArticlesText(articles), paragraph+strings.Join(bullets, "")),
+	}, nil
+}
+
+// ClusterAndSummarize groups articles that share at least one symbol,
+// directly or through another article, and returns one story per group,
+// largest first. Articles without symbols form their own story.
+func (f *FakeClient) ClusterAndSummarize(articles []SummaryInput) (*ClusterSummaryResult, error) {
+	if err := f.call(""); err != nil {
+		return nil, err
+	}
+
+	parent := make([]int, len(articles))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	bySymbol := map[string]int{}
+	for i, a := range articles {
+		for _, s := range a.Symbols {
+			if j, ok := bySymbol[s]; ok {
+				parent[find(i)] = find(j)
+			} else {
+				bySymbol[s] = i
+			}
+		}
+	}
+
+	groups := map[int][]int{}
+	var roots []int
+	for i := range articles {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], i)
+	}
+	sort.SliceStable(roots, func(a, b int) bool {
+		return len(groups[roots[a]]) > len(groups[roots[b]])
+	})
+
+	stories := make([]StorySummary, 0, len(roots))
+	var output strings.Builder
+	for _, root := range roots {
+		story := fakeStory(articles, groups[root])
+		stories = append(stories, story)
+		output.WriteString(story.Headline + story.Summary)
+	}
+
+	return &ClusterSummaryResult{
+		Stories:   stories,
+		ModelUsed: fakeModelName,
+		Usage:     fakeUsage(f.Prompts.withDefaults().ClusterRank.Body+fakeArticlesText(articles), output.String()),
+	}, nil
+}
+
+func fakeStory(articles []SummaryInput, indices []int) StorySummary {
+	first := articles[indices[0]]
+	headline, _ := fakeNeutralize(first.Headline)
+	summary, _ := fakeNeutralize(first.Detail)
+
+	story := StorySummary{Headline: headline, Summary: summary}
+
+	tickers := map[string]bool{}
+	publishers := map[string]bool{}
+	start, end := first.PublishedAt, first.PublishedAt
+	for n, i := range indices {
+		a := articles[i]
+		if n > 0 {
+			angle, _ := fakeNeutralize(a.Headline)
+			story.Angles = append(story.Angles, angle)
+		}
+		for _, s := range a.Symbols {
+			if !tickers[s] {
+				tickers[s] = true
+				story.Tickers = append(story.Tickers, s)
+			}
+		}
+		if a.Publisher != "" && !publishers[a.Publisher] {
+			publishers[a.Publisher] = true
+			story.Publishers = append(story.Publishers, a.Publisher)
+		}
+		if a.PublishedAt.Before(start) {
+			start = a.PublishedAt
+		}
+		if a.PublishedAt.After(end) {
+			end = a.PublishedAt
+		}
+	}
+	sort.Strings(story.Tickers)
+	sort.Strings(story.Publishers)
+
+	if !start.IsZero() {
+		story.TimeRange = start.UTC().Format("Jan 2 15:04") + " - " + end.UTC().Format("Jan 2 15:04") + " UTC"
+	}
+	return story
+}
+
+// fakeNeutralize applies the transform prompt's rewriting rules and reports
+// how many edits it made.
+func fakeNeutralize(s string) (string, int) {
+	changes := 0
+	replace := func(re *regexp.Regexp, with string) {
+		s = re.ReplaceAllStringFunc(s, func(string) string {
+			changes++
+			return with
+		})
+	}
+
+	replace(fakeUrgency, "")
+	replace(fakeDropVerbs, "dropped")
+	replace(fakeRiseVerbs, "rose")
+	replace(fakeJudgement, "")
+	replace(fakeWill, "may")
+
+	if isMostlyUpper(s) {
+		s = strings.ToLower(s)
+		changes++
+	}
+
+	s = strings.TrimSpace(fakeSpaces.ReplaceAllString(s, " "))
+	if r := []rune(s); len(r) > 0 && unicode.IsLower(r[0]) {
+		r[0] = unicode.ToUpper(r[0])
+		s = string(r)
+	}
+	return s, changes
+}
+
+func isMostlyUpper(s string) bool {
+	var upper, letters int
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters > 0 && upper*2 > letters
+}
+
+func fakeCategory(text string) string {
+	text = strings.ToLower(text) + " "
+	for _, c := range fakeCategories {
+		for _, k := range c.keywords {
+			if strings.Contains(text, k) {
+				return c.category
+			}
+		}
+	}
+	return "Company News"
+}
+
+func fakeArticlesText(articles []SummaryInput) string {
+	var sb strings.Builder
+	for _, a := range articles {
+		sb.WriteString(a.Headline + a.Detail)
+	}
+	return sb.String()
+}
+
+// fakeUsage estimates tokens at four characters each.
+func fakeUsage(input, output string) Usage {
+	return Usage{Provider: ProviderFake, Model: fakeModelName, InputTokens: int64(len(input) / 4), OutputTokens: int64(len(output) / 4)}
+}
+
+// SubmitBatch transforms every request at once; the batch has ended by the
+// time it is polled.
+func (f *FakeClient) SubmitBatch(ctx context.Context, requests []BatchRequest) (string, error) {
+	var results []BatchResult
+	for _, r := range requests {
+		result, err := f.Transform(r.Input)
+		if result != nil {
+			result.Usage.Batch = true
+		}
+		results = append(results, BatchResult{CustomID: r.CustomID, Result: result, Err: err})
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.batches == nil {
+		f.batches = map[string][]BatchResult{}
+	}
+	id := fmt.Sprintf("fake-batch-%d", len(f.batches)+1)
+	f.batches[id] = results
+	return id, nil
+}
+
+func (f *FakeClient) GetBatch(ctx context.Context, id string) (*BatchStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	results, ok := f.batches[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown batch %q", id)
+	}
+	status := &BatchStatus{ID: id, State: BatchEnded, Total: len(results)}
+	for _, r := range results {
+		if r.Err != nil {
+			status.Failed++
+		} else {
+			status.Succeeded++
+		}
+	}
+	return status, nil
+}
+
+func (f *FakeClient) BatchResults(ctx context.Context, id string) ([]BatchResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	results, ok := f.batches[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown batch %q", id)
+	}
+	return results, nil
+}
@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFakeTransform(t *testing.T) {
+	f := &FakeClient{}
+
+	result, err := f.Transform(TransformInput{
+		Headline: "BREAKING: TESLA STOCK CRASHES AFTER SHOCKING RECALL",
+		Detail:   "Analysts say shares will fall further.",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Headline != "Tesla stock dropped after recall" {
+		t.Errorf("headline: got %q", result.Headline)
+	}
+	if result.Detail != "Analysts say shares may fall further." {
+		t.Errorf("detail: got %q", result.Detail)
+	}
+	if result.Category != "Market Movement" {
+		t.Errorf("category: got %q", result.Category)
+	}
+	if result.SentimentScore != 6 || result.ModelUsed != "fake" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestFakeScriptedError(t *testing.T) {
+	want := &Error{Class: ErrorRateLimit, Err: errors.New("slow down")}
+	f := &FakeClient{Errors: map[string]error{"Fed holds rates": want}}
+
+	if _, err := f.Transform(TransformInput{Headline: "Fed holds rates"}); err != want {
+		t.Errorf("expected the scripted error, got %v", err)
+	}
+	if f.Calls() != 1 {
+		t.Errorf("calls: got %d, want 1", f.Calls())
+	}
+}
+
+func TestFakeClusterBySharedSymbols(t *testing.T) {
+	at := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	articles := []SummaryInput{
+		{Headline: "Apple beats estimates", Symbols: []string{"AAPL"}, Publisher: "Reuters", PublishedAt: at},
+		{Headline: "Bitcoin rises", Symbols: []string{"BTC"}},
+		{Headline: "Apple and Microsoft lead gains", Symbols: []string{"MSFT", "AAPL"}, Publisher: "CNBC", PublishedAt: at.Add(time.Hour)},
+		{Headline: "Microsoft cloud growth", Symbols: []string{"MSFT"}, Publisher: "Reuters", PublishedAt: at.Add(30 * time.Minute)},
+		{Headline: "Oil steady"},
+	}
+
+	result, err := (&FakeClient{}).ClusterAndSummarize(articles)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Stories) != 3 {
+		t.Fatalf("expected 3 stories, got %d", len(result.Stories))
+	}
+
+	top := result.Stories[0]
+	if top.Headline != "Apple beats estimates" || len(top.Angles) != 2 {
+		t.Errorf("unexpected top story: %+v", top)
+	}
+	if len(top.Tickers) != 2 || top.Tickers[0] != "AAPL" || top.Tickers[1] != "MSFT" {
+		t.Errorf("tickers: got %v", top.Tickers)
+	}
+	if len(top.Publishers) != 2 || top.TimeRange != "Mar 2 14:00 - Mar 2 15:00 UTC" {
+		t.Errorf("unexpected publishers/time range: %v %q", top.Publishers, top.TimeRange)
+	}
+	if result.Stories[1].Headline != "Bitcoin rises" || result.Stories[2].Headline != "Oil steady" {
+		t.Errorf("unexpected story order: %q, %q", result.Stories[1].Headline, result.Stories[2].Headline)
+	}
+}
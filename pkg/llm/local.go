@@ -0,0 +1,48 @@
+package llm
+
+import (
+	"fmt"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// DefaultLocalBaseURL is Ollama's OpenAI-compatible endpoint.
+const DefaultLocalBaseURL = "http://localhost:11434/v1"
+
+// NewLocalClient returns a client for a self-hosted OpenAI-compatible server
+// such as Ollama, vLLM or llama.cpp. The same model is used for every call.
+func NewLocalClient(baseURL, model string) (*OpenAIClient, error) {
+	return newLocalClient(Config{Provider: ProviderLocal, BaseURL: baseURL, Model: model})
+}
+
+func newLocalClient(cfg Config) (*OpenAIClient, error) {
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("local LLM provider requires a model name")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultLocalBaseURL
+	}
+
+	// Most local servers ignore the key, but the header must still be sent.
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		apiKey = "local"
+	}
+
+	client := openai.NewClient(option.WithBaseURL(cfg.BaseURL), option.WithAPIKey(apiKey))
+	c := &OpenAIClient{
+		provider:     ProviderLocal,
+		client:       &client,
+		model:        cfg.Model,
+		modelName:    cfg.Model,
+		clusterModel: cfg.Model,
+		maxTokens:    cfg.MaxTokens,
+		temperature:  cfg.Temperature,
+		prompts:      cfg.Prompts.withDefaults(),
+	}
+	if cfg.ClusterModel != "" {
+		c.clusterModel = cfg.ClusterModel
+	}
+	return c, nil
+}
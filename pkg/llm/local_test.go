@@ -0,0 +1,208 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type chatRequest struct {
+	Model               string  `json:"model"`
+	MaxCompletionTokens int64   `json:"max_completion_tokens"`
+	Temperature         float64 `json:"temperature"`
+	ResponseFormat      struct {
+		Type       string `json:"type"`
+		JSONSchema struct {
+			Name   string `json:"name"`
+			Strict bool   `json:"strict"`
+		} `json:"json_schema"`
+	} `json:"response_format"`
+	Messages []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	} `json:"messages"`
+}
+
+// newFakeChatServer starts an OpenAI-compatible chat completions endpoint
+// that answers each request with reply(system prompt, user prompt) and
+// records the requests it received.
+func newFakeChatServer(t *testing.T, reply func(system, user string) string) (*httptest.Server, *[]chatRequest) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var requests []chatRequest
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+
+		// The last user message is the original prompt or a repair request.
+		var system, user string
+		for _, m := range req.Messages {
+			switch m.Role {
+			case "system":
+				system = m.Content
+			case "user":
+				user = m.Content
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":      "chatcmpl-local",
+			"object":  "chat.completion",
+			"created": 0,
+			"model":   req.Model,
+			"choices": []map[string]any{{
+				"index":         0,
+				"finish_reason": "stop",
+				"message":       map[string]any{"role": "assistant", "content": reply(system, user)},
+			}},
+			"usage": map[string]any{"prompt_tokens": 100, "completion_tokens": 20, "total_tokens": 120},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+func fakeLocalReply(system, user string) string {
+	switch system {
+	case summaryPrompt:
+		return `{"paragraph": "Markets were mixed.", "bullets": ["Apple rose 2%"]}`
+	case clusterRankPrompt:
+		return `{"clusters": [{"topic": "Apple earnings", "article_indices": [0, 1], "importance_reason": "large cap"}]}`
+	case synthesizePrompt:
+		return "```json\n{\"stories\": [{\"headline\": \"Apple reported earnings\", \"summary\": \"Revenue rose.\", \"tickers\": [\"AAPL\"]}]}\n```"
+	default:
+		headline := strings.TrimPrefix(strings.SplitN(user, "\n", 2)[0], "Headline: ")
+		return `{"headline": "` + strings.ToLower(headline) + `", "summary": "Shares rose 3%.", "category": "Company News", "sentiment_score": 7}`
+	}
+}
+
+func TestLocalClient(t *testing.T) {
+	srv, requests := newFakeChatServer(t, fakeLocalReply)
+
+	temperature := 0.1
+	client, err := New(Config{Provider: ProviderLocal, BaseURL: srv.URL + "/v1", Model: "llama3.1", MaxTokens: 512, Temperature: &temperature})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := client.Transform(TransformInput{Headline: "APPLE SOARS", Detail: "Shares skyrocket 3%"})
+	if err != nil {
+		t.Fatalf("transform: %v", err)
+	}
+	if result.Headline != "apple soars" || result.Category != "Company News" || result.SentimentScore != 7 {
+		t.Errorf("unexpected transform result: %+v", result)
+	}
+	if result.ModelUsed != "llama3.1" {
+		t.Errorf("model used: got %q", result.ModelUsed)
+	}
+
+	summary, err := client.Summarize([]SummaryInput{{Headline: "Apple rose"}})
+	if err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+	if summary.Paragraph != "Markets were mixed." || len(summary.Bullets) != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	clusters, err := client.ClusterAndSummarize([]SummaryInput{{Headline: "Apple beats"}, {Headline: "Apple revenue up"}})
+	if err != nil {
+		t.Fatalf("cluster: %v", err)
+	}
+	if len(clusters.Stories) != 1 || clusters.Stories[0].Headline != "Apple reported earnings" {
+		t.Errorf("unexpected stories: %+v", clusters.Stories)
+	}
+	if summary.Usage.InputTokens != 100 || clusters.Usage != (Usage{Provider: ProviderLocal, Model: "llama3.1", InputTokens: 200, OutputTokens: 40}) {
+		t.Errorf("usage should cover every pass: summary %+v, clusters %+v", summary.Usage, clusters.Usage)
+	}
+
+	// transform, summarize, cluster pass and one synthesis pass
+	if len(*requests) != 4 {
+		t.Fatalf("expected 4 requests, got %d", len(*requests))
+	}
+	for _, req := range *requests {
+		if req.Model != "llama3.1" || req.MaxCompletionTokens != 512 || req.Temperature != 0.1 {
+			t.Errorf("unexpected request parameters: model=%q max_tokens=%d temperature=%v",
+				req.Model, req.MaxCompletionTokens, req.Temperature)
+		}
+		if req.ResponseFormat.Type != "json_schema" || !req.ResponseFormat.JSONSchema.Strict {
+			t.Errorf("expected a strict json_schema response format, got %+v", req.ResponseFormat)
+		}
+	}
+	if (*requests)[0].ResponseFormat.JSONSchema.Name != "transform_article" {
+		t.Errorf("schema name: got %q", (*requests)[0].ResponseFormat.JSONSchema.Name)
+	}
+}
+
+func TestRepairInvalidOutput(t *testing.T) {
+	srv, requests := newFakeChatServer(t, func(system, user string) string {
+		if strings.HasPrefix(user, "Your previous response was invalid") {
+			return `{"headline": "Apple rose", "summary": "Shares rose 3%.", "category": "Company News", "sentiment_score": 4}`
+		}
+		return `{"headline": "Apple rose", "summary": "Shares rose 3%.", "category": "Tech", "sentiment_score": 11}`
+	})
+
+	client, err := NewLocalClient(srv.URL+"/v1", "llama3.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := client.Transform(TransformInput{Headline: "APPLE SOARS"})
+	if err != nil {
+		t.Fatalf("expected the repaired output to be accepted, got %v", err)
+	}
+	if result.Category != "Company News" || result.SentimentScore != 4 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.Usage != (Usage{Provider: ProviderLocal, Model: "llama3.1", InputTokens: 200, OutputTokens: 40}) {
+		t.Errorf("usage should cover the repair request, got %+v", result.Usage)
+	}
+
+	if len(*requests) != 2 {
+		t.Fatalf("expected one repair request, got %d requests", len(*requests))
+	}
+	repair := (*requests)[1].Messages
+	if len(repair) != 4 || repair[2].Role != "assistant" || !strings.Contains(repair[3].Content, `category "Tech"`) {
+		t.Errorf("repair request should replay the bad output and the validation error: %+v", repair)
+	}
+}
+
+func TestLocalClientErrors(t *testing.T) {
+	srv, requests := newFakeChatServer(t, func(system, user string) string {
+		return "I can't produce JSON today"
+	})
+
+	client, err := NewLocalClient(srv.URL+"/v1", "llama3.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = client.Transform(TransformInput{Headline: "Stocks fell"})
+	if Classify(err).Class != ErrorParse {
+		t.Errorf("expected a parse error, got %v", err)
+	}
+	if len(*requests) != 1+maxRepairAttempts {
+		t.Errorf("expected %d requests, got %d", 1+maxRepairAttempts, len(*requests))
+	}
+
+	if _, err := NewLocalClient(srv.URL, ""); err == nil {
+		t.Error("expected an error without a model")
+	}
+}
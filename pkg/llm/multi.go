@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"strings"
+)
+
+// transformBatchInstructions follows the transform prompt when several
+// articles share one call.
+const transformBatchInstructions = `
+
+You will receive several articles, each marked with its [index]. Rewrite every article on its own, following the rules above, and return one entry per article in the "articles" array with the index of the article it rewrites.`
+
+var transformBatchSchema = outputSchema{
+	name:        "transform_articles",
+	description: "Neutral rewrites of several financial news articles",
+	properties: map[string]any{
+		"articles": map[string]any{
+			"type":  "array",
+			"items": object(indexedTransformProperties()),
+		},
+	},
+}
+
+func indexedTransformProperties() map[string]any {
+	properties := maps.Clone(transformSchema.properties)
+	properties["index"] = map[string]any{"type": "integer"}
+	return properties
+}
+
+type transformBatchOutput struct {
+	Articles []struct {
+		Index int `json:"index"`
+		transformOutput
+	} `json:"articles"`
+}
+
+// validate only rejects output with no articles at all. Entries are checked
+// one by one when they are mapped back, so one bad rewrite does not cost the
+// whole call.
+func (o *transformBatchOutput) validate() error {
+	if len(o.Articles) == 0 {
+		return fmt.Errorf("articles must not be empty")
+	}
+	return nil
+}
+
+func transformBatchUserPrompt(inputs []TransformInput) string {
+	var sb strings.Builder
+	for i, input := range inputs {
+		sb.WriteString(fmt.Sprintf("[%d] %s\n\n", i, transformUserPrompt(input)))
+	}
+	return sb.String()
+}
+
+// transformEach sends inputs to the model in one call made by complete and
+// maps the indexed rewrites back to them. Inputs the call left out or
+// answered invalidly are transformed one at a time by single, as are all of
+// them when the call fails for a reason other than the provider being
+// unavailable. The call's tokens are split evenly across the inputs, and
+// inputs that fail carry their share in a UsageError.
+func transformEach(inputs []TransformInput, single LLMClient, prompt Prompt, model string,
+	complete func(system, user string, decode func(content string) error) (Usage, error)) ([]*TransformResult, []error) {
+	results := make([]*TransformResult, len(inputs))
+	errs := make([]error, len(inputs))
+	if len(inputs) == 1 {
+		results[0], errs[0] = single.Transform(inputs[0])
+	}
+	if len(inputs) <= 1 {
+		return results, errs
+	}
+
+	var parsed transformBatchOutput
+	usage, err := complete(prompt.Body+transformBatchInstructions, transformBatchUserPrompt(inputs), func(content string) error {
+		parsed = transformBatchOutput{}
+		return decodeOutput(content, &parsed)
+	})
+	shares := usage.split(len(inputs))
+	if err != nil && failsOver(Classify(err).Class) {
+		for i := range errs {
+			errs[i] = withUsage(shares[i], err)
+		}
+		return results, errs
+	}
+	if err != nil {
+		slog.Warn("multi-article transform failed, transforming one at a time", "error", err, "count", len(inputs))
+	}
+
+	for _, article := range parsed.Articles {
+		i := article.Index
+		if i < 0 || i >= len(inputs) || results[i] != nil || article.validate() != nil {
+			continue
+		}
+		results[i] = article.result(prompt, model, shares[i])
+	}
+
+	for i := range inputs {
+		if results[i] != nil {
+			continue
+		}
+		results[i], errs[i] = single.Transform(inputs[i])
+		if results[i] != nil {
+			results[i].Usage.add(shares[i])
+		} else {
+			errs[i] = withUsage(shares[i], errs[i])
+		}
+	}
+	return results, errs
+}
+
+// split divides u's tokens evenly across n results. The first share also
+// takes the remainder, so the shares add up to u.
+func (u Usage) split(n int) []Usage {
+	shares := make([]Usage, n)
+	for i := range shares {
+		shares[i] = u
+		shares[i].InputTokens /= int64(n)
+		shares[i].OutputTokens /= int64(n)
+	}
+	shares[0].InputTokens += u.InputTokens % int64(n)
+	shares[0].OutputTokens += u.OutputTokens % int64(n)
+	return shares
+}
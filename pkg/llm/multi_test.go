@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var packInputs = []TransformInput{
+	{Headline: "APPLE SOARS", Detail: "Shares skyrocket 3%"},
+	{Headline: "OIL EXPLODES", Detail: "Crude rose 4%"},
+	{Headline: "BITCOIN TANKS", Detail: "Bitcoin fell 9%"},
+}
+
+func TestTransformBatch(t *testing.T) {
+	srv, requests := newFakeChatServer(t, func(system, user string) string {
+		if !strings.Contains(system, "several articles") {
+			return fakeLocalReply(system, user)
+		}
+		// Article 1 has an invalid category and index 7 does not exist.
+		return `{"articles": [
+			{"index": 2, "headline": "Bitcoin fell", "summary": "Bitcoin fell 9%.", "category": "Crypto", "sentiment_score": 8},
+			{"index": 0, "headline": "Apple rose", "summary": "Shares rose 3%.", "category": "Company News", "sentiment_score": 6},
+			{"index": 1, "headline": "Oil rose", "summary": "Crude rose 4%.", "category": "Oil", "sentiment_score": 7},
+			{"index": 7, "headline": "Gold rose", "summary": "Gold rose 1%.", "category": "Economy", "sentiment_score": 2}
+		]}`
+	})
+
+	client, err := NewLocalClient(srv.URL+"/v1", "llama3.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	results, errs := client.TransformBatch(packInputs)
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("input %d: unexpected error: %v", i, err)
+		}
+	}
+	if results[0].Headline != "Apple rose" || results[2].Headline != "Bitcoin fell" || results[2].Category != "Crypto" {
+		t.Errorf("results were not mapped back by index: %+v, %+v", results[0], results[2])
+	}
+	if results[1].Headline != "oil explodes" {
+		t.Errorf("invalid entry should fall back to a single call, got %+v", results[1])
+	}
+
+	// The shared call's 100/20 tokens are split three ways, the first input
+	// taking the remainder.
+	if results[0].Usage != (Usage{Provider: ProviderLocal, Model: "llama3.1", InputTokens: 34, OutputTokens: 8}) {
+		t.Errorf("usage: got %+v", results[0].Usage)
+	}
+	if results[1].Usage.InputTokens != 133 {
+		t.Errorf("fallback usage should include its share, got %+v", results[1].Usage)
+	}
+
+	if len(*requests) != 2 {
+		t.Fatalf("expected the pack and one fallback request, got %d", len(*requests))
+	}
+	pack := (*requests)[0]
+	if pack.ResponseFormat.JSONSchema.Name != "transform_articles" || !strings.Contains(pack.Messages[1].Content, "[2] Headline: BITCOIN TANKS") {
+		t.Errorf("unexpected pack request: %+v", pack)
+	}
+}
+
+func TestTransformBatchFallsBack(t *testing.T) {
+	srv, requests := newFakeChatServer(t, func(system, user string) string {
+		if strings.Contains(system, "several articles") {
+			return "I can only rewrite one article at a time"
+		}
+		return fakeLocalReply(system, user)
+	})
+
+	client, err := NewLocalClient(srv.URL+"/v1", "llama3.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	results, errs := client.TransformBatch(packInputs)
+	for i := range packInputs {
+		if errs[i] != nil || results[i].Headline != strings.ToLower(packInputs[i].Headline) {
+			t.Errorf("input %d: got %+v, %v", i, results[i], errs[i])
+		}
+	}
+	// The pack, its repair and one call per article.
+	if len(*requests) != 5 {
+		t.Errorf("expected 5 requests, got %d", len(*requests))
+	}
+}
+
+func TestFakeTransformBatch(t *testing.T) {
+	client := &FakeClient{Errors: map[string]error{"OIL EXPLODES": errors.New("timeout")}}
+
+	results, errs := client.TransformBatch(packInputs)
+	if errs[0] != nil || errs[2] != nil || errs[1] == nil {
+		t.Errorf("expected only the scripted error, got %v", errs)
+	}
+	if results[0] == nil || results[0].Headline != "Apple rose" {
+		t.Errorf("unexpected result: %+v", results[0])
+	}
+	if client.Calls() != 2 {
+		t.Errorf("expected the pack and the scripted fallback, got %d calls", client.Calls())
+	}
+}
+
+func TestTransformBatchMaxTokens(t *testing.T) {
+	srv, requests := newFakeChatServer(t, fakeLocalReply)
+	client, err := newLocalClient(Config{Provider: ProviderLocal, BaseURL: srv.URL + "/v1", Model: "llama3.1", MaxTokens: 500})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// LLM_MAX_TOKENS sizes a single rewrite; a pack of three needs more.
+	client.TransformBatch(packInputs)
+	if len(*requests) == 0 || (*requests)[0].MaxCompletionTokens != 3*1024 {
+		t.Fatalf("pack max tokens: got %+v", *requests)
+	}
+
+	client.Transform(packInputs[0])
+	if last := (*requests)[len(*requests)-1]; last.MaxCompletionTokens != 500 {
+		t.Errorf("single max tokens: got %d", last.MaxCompletionTokens)
+	}
+}
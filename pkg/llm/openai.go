@@ -2,92 +2,91 @@ package llm
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 )
 
-const promptVersion = "v1"
-const systemPrompt = `You are a financial news editor. Your job is to rewrite news headlines and summaries in a neutral, calm tone.
-
-Rules:
-1. Remove urgency words (BREAKING, NOW, ALERT, JUST IN)
-2. Remove ALL CAPS
-3. Replace emotional verbs:
-   - crash, plummet, tank → dropped, decreased
-   - explode, soar, skyrocket → rose, increased
-4. Remove judgmental words (smart, dumb, crazy, shocking, terrifying)
-5. Add uncertainty to predictions (will → may, could, might)
-6. Remove dramatic metaphors (bloodbath, shockwave, chaos)
-7. Keep all facts: numbers, names, dates, percentages
-
-Output as JSON only, no other text:
-{
-  "headline": "transformed headline",
-  "summary": "transformed summary",
-  "category": "one of: Earnings, Market Movement, Economy, Crypto, Mergers & Acquisitions, Policy & Regulation, Company News, Analysis",
-  "sentiment_score": 1-10 how emotional was the original (10 = very emotional)
-}`
-
 type OpenAIClient struct {
-	client    *openai.Client
-	model     openai.ChatModel
-	modelName string
+	provider     string
+	client       *openai.Client
+	model        openai.ChatModel
+	modelName    string
+	clusterModel openai.ChatModel
+	maxTokens    int64
+	temperature  *float64
+	prompts      PromptSet
 }
 
 func NewOpenAIClient(apiKey string) *OpenAIClient {
-	client := openai.NewClient(option.WithAPIKey(apiKey))
-	return &OpenAIClient{
-		client:    &client,
-		model:     openai.ChatModelGPT4oMini,
-		modelName: "gpt-4o-mini",
-	}
+	return newOpenAIClient(Config{APIKey: apiKey})
 }
 
-func (c *OpenAIClient) Transform(input TransformInput) (*TransformResult, error) {
-	userPrompt := fmt.Sprintf("Headline: %s\nSummary: %s", input.Headline, input.Detail)
+func newOpenAIClient(cfg Config) *OpenAIClient {
+	client := openai.NewClient(option.WithAPIKey(cfg.APIKey))
+	c := &OpenAIClient{
+		provider:     ProviderOpenAI,
+		client:       &client,
+		model:        openai.ChatModelGPT4oMini,
+		modelName:    defaultOpenAIModel,
+		clusterModel: openai.ChatModelGPT4_1Mini,
+		maxTokens:    cfg.MaxTokens,
+		temperature:  cfg.Temperature,
+		prompts:      cfg.Prompts.withDefaults(),
+	}
+	if cfg.Model != "" {
+		c.model, c.modelName = cfg.Model, cfg.Model
+	}
+	if cfg.ClusterModel != "" {
+		c.clusterModel = cfg.ClusterModel
+	}
+	return c
+}
 
-	resp, err := c.client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
-		Model: c.model,
+// params builds a chat completion request with the configured limits.
+func (c *OpenAIClient) params(model openai.ChatModel, system, user string) openai.ChatCompletionNewParams {
+	params := openai.ChatCompletionNewParams{
+		Model: model,
 		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(systemPrompt),
-			openai.UserMessage(userPrompt),
+			openai.SystemMessage(system),
+			openai.UserMessage(user),
 		},
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("openai API error: %w", err)
 	}
-
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from openai")
+	if c.maxTokens > 0 {
+		params.MaxCompletionTokens = openai.Int(c.maxTokens)
 	}
-
-	content := cleanJSONResponse(resp.Choices[0].Message.Content)
-
-	var parsed struct {
-		Headline       string `json:"headline"`
-		Summary        string `json:"summary"`
-		Category       string `json:"category"`
-		SentimentScore int    `json:"sentiment_score"`
+	if c.temperature != nil {
+		params.Temperature = openai.Float(*c.temperature)
 	}
+	return params
+}
 
-	err = json.Unmarshal([]byte(content), &parsed)
+func (c *OpenAIClient) Transform(input TransformInput) (*TransformResult, error) {
+	var parsed transformOutput
+	usage, err := c.completeJSON(c.params(c.model, c.prompts.Transform.Body, transformUserPrompt(input)), transformSchema, func(content string) error {
+		parsed = transformOutput{}
+		return decodeOutput(content, &parsed)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w, content: %s", err, content)
+		return nil, withUsage(usage, err)
 	}
 
-	return &TransformResult{
-		Headline:       parsed.Headline,
-		Detail:         parsed.Summary,
-		Category:       parsed.Category,
-		SentimentScore: parsed.SentimentScore,
-		PromptVersion:  promptVersion,
-		ModelUsed:      c.modelName,
-	}, nil
+	return parsed.result(c.prompts.Transform, c.modelName, usage), nil
+}
+
+func (c *OpenAIClient) TransformBatch(inputs []TransformInput) ([]*TransformResult, []error) {
+	return transformEach(inputs, c, c.prompts.Transform, c.modelName, func(system, user string, decode func(content string) error) (Usage, error) {
+		params := c.params(c.model, system, user)
+		// Every article needs room to be rewritten, whatever limit is set
+		// for a single one.
+		if c.maxTokens > 0 {
+			params.MaxCompletionTokens = openai.Int(max(c.maxTokens, 1024*int64(len(inputs))))
+		}
+		return c.completeJSON(params, transformBatchSchema, decode)
+	})
 }
 
 func (c *OpenAIClient) Summarize(articles []SummaryInput) (*SummaryResult, error) {
@@ -96,38 +95,20 @@ func (c *OpenAIClient) Summarize(articles []SummaryInput) (*SummaryResult, error
 		sb.WriteString(fmt.Sprintf("%d. Headline: %s\nSummary: %s\n\n", i+1, a.Headline, a.Detail))
 	}
 
-	resp, err := c.client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
-		Model: c.model,
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(summarySystemPrompt),
-			openai.UserMessage(sb.String()),
-		},
+	var parsed summaryOutput
+	usage, err := c.completeJSON(c.params(c.model, c.prompts.Summary.Body, sb.String()), summarySchema, func(content string) error {
+		parsed = summaryOutput{}
+		return decodeOutput(content, &parsed)
 	})
-
-	if err != nil {
-		return nil, fmt.Errorf("openai API error: %w", err)
-	}
-
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from openai")
-	}
-
-	content := cleanJSONResponse(resp.Choices[0].Message.Content)
-
-	var parsed struct {
-		Paragraph string   `json:"paragraph"`
-		Bullets   []string `json:"bullets"`
-	}
-
-	err = json.Unmarshal([]byte(content), &parsed)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w, content: %s", err, content)
+		return nil, withUsage(usage, err)
 	}
 
 	return &SummaryResult{
 		Paragraph: parsed.Paragraph,
 		Bullets:   parsed.Bullets,
 		ModelUsed: c.modelName,
+		Usage:     usage,
 	}, nil
 }
 
@@ -135,79 +116,106 @@ func (c *OpenAIClient) ClusterAndSummarize(articles []SummaryInput) (*ClusterSum
 	// Pass 1: Cluster & Rank
 	userPrompt := formatArticlesForClustering(articles)
 
-	resp, err := c.client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
-		Model: openai.ChatModelGPT4_1Mini,
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(clusterRankPrompt),
-			openai.UserMessage(userPrompt),
-		},
+	var clusterResult clusterOutput
+	usage, err := c.completeJSON(c.params(c.clusterModel, c.prompts.ClusterRank.Body, userPrompt), clusterSchema, func(content string) error {
+		clusterResult = clusterOutput{articleCount: len(articles)}
+		return decodeOutput(content, &clusterResult)
 	})
 	if err != nil {
-		return nil, fmt.Errorf("openai cluster pass error: %w", err)
-	}
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from openai (cluster pass)")
-	}
-
-	content := cleanJSONResponse(resp.Choices[0].Message.Content)
-
-	var clusterResult struct {
-		Clusters []struct {
-			Topic            string `json:"topic"`
-			ArticleIndices   []int  `json:"article_indices"`
-			ImportanceReason string `json:"importance_reason"`
-		} `json:"clusters"`
-	}
-	if err := json.Unmarshal([]byte(content), &clusterResult); err != nil {
-		return nil, fmt.Errorf("failed to parse cluster response: %w, content: %s", err, content)
+		return nil, withUsage(usage, fmt.Errorf("%s cluster pass error: %w", c.provider, err))
 	}
 
 	// Pass 2: Synthesize each cluster
 	var stories []StorySummary
 	for _, cluster := range clusterResult.Clusters {
 		clusterArticles := gatherClusterArticles(articles, cluster.ArticleIndices)
-		story, err := c.synthesizeCluster(clusterArticles)
+		story, synthesisUsage, err := c.synthesizeCluster(clusterArticles)
+		usage.add(synthesisUsage)
 		if err != nil {
-			return nil, fmt.Errorf("openai synthesis error for cluster %q: %w", cluster.Topic, err)
+			return nil, withUsage(usage, fmt.Errorf("%s synthesis error for cluster %q: %w", c.provider, cluster.Topic, err))
 		}
 		stories = append(stories, *story)
 	}
 
 	return &ClusterSummaryResult{
 		Stories:   stories,
-		ModelUsed: "gpt-4.1-mini",
+		ModelUsed: string(c.clusterModel),
+		Usage:     usage,
 	}, nil
 }
 
-func (c *OpenAIClient) synthesizeCluster(articles []SummaryInput) (*StorySummary, error) {
+func (c *OpenAIClient) synthesizeCluster(articles []SummaryInput) (*StorySummary, Usage, error) {
 	userPrompt := formatArticlesForSynthesis(articles)
 
-	resp, err := c.client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
-		Model: openai.ChatModelGPT4_1Mini,
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(synthesizePrompt),
-			openai.UserMessage(userPrompt),
-		},
+	var parsed synthesisOutput
+	usage, err := c.completeJSON(c.params(c.clusterModel, c.prompts.Synthesize.Body, userPrompt), synthesisSchema, func(content string) error {
+		parsed = synthesisOutput{}
+		return decodeOutput(content, &parsed)
 	})
 	if err != nil {
-		return nil, fmt.Errorf("openai API error: %w", err)
-	}
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from openai")
+		return nil, usage, err
 	}
 
-	content := cleanJSONResponse(resp.Choices[0].Message.Content)
+	return &parsed.Stories[0], usage, nil
+}
 
-	var parsed struct {
-		Stories []StorySummary `json:"stories"`
-	}
-	if err := json.Unmarshal([]byte(content), &parsed); err != nil {
-		return nil, fmt.Errorf("failed to parse synthesis response: %w, content: %s", err, content)
+// completeJSON requests output matching schema and hands the content to
+// decode. Output that fails to decode is sent back once with the error for
+// the model to repair before the call fails with a parse error.
+func (c *OpenAIClient) completeJSON(params openai.ChatCompletionNewParams, schema outputSchema, decode func(content string) error) (Usage, error) {
+	params.ResponseFormat = openAIResponseFormat(schema)
+
+	usage := Usage{Provider: c.provider, Model: string(params.Model)}
+	for attempt := 0; ; attempt++ {
+		resp, err := c.client.Chat.Completions.New(context.Background(), params)
+		if err != nil {
+			return usage, fmt.Errorf("%s API error: %w", c.provider, err)
+		}
+		usage.add(Usage{InputTokens: resp.Usage.PromptTokens, OutputTokens: resp.Usage.CompletionTokens})
+
+		if len(resp.Choices) == 0 {
+			return usage, fmt.Errorf("no response from %s", c.provider)
+		}
+		if err := openAIRefusal(c.provider, resp.Choices[0]); err != nil {
+			return usage, err
+		}
+
+		content := resp.Choices[0].Message.Content
+		err = decode(content)
+		if err == nil {
+			return usage, nil
+		}
+
+		if attempt >= maxRepairAttempts {
+			return usage, newParseError("invalid %s response: %w, content: %s", schema.name, err, content)
+		}
+
+		slog.Warn("LLM output failed validation, asking for a repair", "provider", c.provider, "schema", schema.name, "error", err)
+		params.Messages = append(params.Messages, openai.AssistantMessage(content), openai.UserMessage(repairPrompt(err)))
 	}
+}
 
-	if len(parsed.Stories) == 0 {
-		return nil, fmt.Errorf("no stories in synthesis response")
+// openAIResponseFormat asks for strict JSON output matching schema.
+func openAIResponseFormat(schema outputSchema) openai.ChatCompletionNewParamsResponseFormatUnion {
+	return openai.ChatCompletionNewParamsResponseFormatUnion{
+		OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{
+			JSONSchema: openai.ResponseFormatJSONSchemaJSONSchemaParam{
+				Name:        schema.name,
+				Description: openai.String(schema.description),
+				Schema:      schema.jsonSchema(),
+				Strict:      openai.Bool(true),
+			},
+		},
 	}
+}
 
-	return &parsed.Stories[0], nil
+// openAIRefusal reports a refused or content-filtered completion.
+func openAIRefusal(provider string, choice openai.ChatCompletionChoice) error {
+	if choice.Message.Refusal != "" {
+		return newRefusalError(provider, choice.Message.Refusal)
+	}
+	if choice.FinishReason == "content_filter" {
+		return newRefusalError(provider, "content filtered")
+	}
+	return nil
 }
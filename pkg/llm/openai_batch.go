@@ -0,0 +1,164 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/openai/openai-go"
+)
+
+// openAIBatchLine is one line of a batch input, output or error file.
+type openAIBatchLine struct {
+	CustomID string                          `json:"custom_id"`
+	Method   string                          `json:"method,omitempty"`
+	URL      string                          `json:"url,omitempty"`
+	Body     *openai.ChatCompletionNewParams `json:"body,omitempty"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response,omitempty"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// SubmitBatch uploads the requests as a JSONL file and starts a batch of
+// chat completions over it.
+func (c *OpenAIClient) SubmitBatch(ctx context.Context, requests []BatchRequest) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range requests {
+		params := c.params(c.model, c.prompts.Transform.Body, transformUserPrompt(r.Input))
+		params.ResponseFormat = openAIResponseFormat(transformSchema)
+		line := openAIBatchLine{CustomID: r.CustomID, Method: "POST", URL: "/v1/chat/completions", Body: &params}
+		if err := enc.Encode(line); err != nil {
+			return "", fmt.Errorf("encoding batch request %s: %w", r.CustomID, err)
+		}
+	}
+
+	file, err := c.client.Files.New(ctx, openai.FileNewParams{
+		File:    openai.File(&buf, "transform-batch.jsonl", "application/jsonl"),
+		Purpose: openai.FilePurposeBatch,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%s API error uploading batch: %w", c.provider, err)
+	}
+
+	batch, err := c.client.Batches.New(ctx, openai.BatchNewParams{
+		InputFileID:      file.ID,
+		Endpoint:         openai.BatchNewParamsEndpointV1ChatCompletions,
+		CompletionWindow: openai.BatchNewParamsCompletionWindow24h,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%s API error creating batch: %w", c.provider, err)
+	}
+	return batch.ID, nil
+}
+
+func (c *OpenAIClient) GetBatch(ctx context.Context, id string) (*BatchStatus, error) {
+	batch, err := c.client.Batches.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s API error: %w", c.provider, err)
+	}
+
+	status := &BatchStatus{
+		ID:        batch.ID,
+		State:     BatchInProgress,
+		Total:     int(batch.RequestCounts.Total),
+		Succeeded: int(batch.RequestCounts.Completed),
+		Failed:    int(batch.RequestCounts.Failed),
+	}
+	switch batch.Status {
+	case openai.BatchStatusCompleted, openai.BatchStatusExpired, openai.BatchStatusCancelled:
+		status.State = BatchEnded
+	case openai.BatchStatusFailed:
+		status.State = BatchFailed
+	}
+	return status, nil
+}
+
+// BatchResults reads the output file and the error file of an ended batch.
+func (c *OpenAIClient) BatchResults(ctx context.Context, id string) ([]BatchResult, error) {
+	batch, err := c.client.Batches.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s API error: %w", c.provider, err)
+	}
+
+	var results []BatchResult
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		lines, err := c.batchFile(ctx, fileID)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			results = append(results, c.batchResult(line))
+		}
+	}
+	return results, nil
+}
+
+func (c *OpenAIClient) batchFile(ctx context.Context, fileID string) ([]openAIBatchLine, error) {
+	resp, err := c.client.Files.Content(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("%s API error downloading batch file %s: %w", c.provider, fileID, err)
+	}
+	defer resp.Body.Close()
+
+	var lines []openAIBatchLine
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var line openAIBatchLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("invalid line in batch file %s: %w", fileID, err)
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading batch file %s: %w", fileID, err)
+	}
+	return lines, nil
+}
+
+func (c *OpenAIClient) batchResult(line openAIBatchLine) BatchResult {
+	result := BatchResult{CustomID: line.CustomID}
+	switch {
+	case line.Error != nil:
+		result.Err = fmt.Errorf("%s batch request failed: %s: %s", c.provider, line.Error.Code, line.Error.Message)
+		return result
+	case line.Response == nil:
+		result.Err = fmt.Errorf("%s batch request has no response", c.provider)
+		return result
+	case line.Response.StatusCode != 200:
+		result.Err = fmt.Errorf("%s batch request failed with status %d: %s", c.provider, line.Response.StatusCode, line.Response.Body)
+		return result
+	}
+
+	var resp openai.ChatCompletion
+	if err := json.Unmarshal(line.Response.Body, &resp); err != nil {
+		result.Err = fmt.Errorf("invalid %s batch response: %w", c.provider, err)
+		return result
+	}
+	if len(resp.Choices) == 0 {
+		result.Err = fmt.Errorf("no response from %s", c.provider)
+		return result
+	}
+	if err := openAIRefusal(c.provider, resp.Choices[0]); err != nil {
+		result.Err = err
+		return result
+	}
+
+	usage := Usage{Provider: c.provider, Model: string(c.model), InputTokens: resp.Usage.PromptTokens, OutputTokens: resp.Usage.CompletionTokens}
+	result.Result, result.Err = decodeBatchTransform(resp.Choices[0].Message.Content, c.prompts.Transform, c.modelName, usage)
+	return result
+}
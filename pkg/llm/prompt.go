@@ -0,0 +1,188 @@
+package llm
+
+import "fmt"
+
+// Prompt names as stored in the prompt table.
+const (
+	PromptTransform   = "transform"
+	PromptSummary     = "summary"
+	PromptClusterRank = "cluster_rank"
+	PromptSynthesize  = "synthesize"
+)
+
+// Prompt is one version of a system prompt. ID is the prompt table row it
+// was loaded from and is zero for the built-in defaults.
+type Prompt struct {
+	ID      int64
+	Name    string
+	Version string
+	Body    string
+}
+
+// PromptSet holds the system prompt used for each kind of call.
+type PromptSet struct {
+	Transform   Prompt
+	Summary     Prompt
+	ClusterRank Prompt
+	Synthesize  Prompt
+}
+
+// DefaultPrompts returns the built-in prompts below, which are also seeded
+// into the prompt table as version v1.
+func DefaultPrompts() PromptSet {
+	return PromptSet{
+		Transform:   Prompt{Name: PromptTransform, Version: defaultPromptVersion, Body: transformPrompt},
+		Summary:     Prompt{Name: PromptSummary, Version: defaultPromptVersion, Body: summaryPrompt},
+		ClusterRank: Prompt{Name: PromptClusterRank, Version: defaultPromptVersion, Body: clusterRankPrompt},
+		Synthesize:  Prompt{Name: PromptSynthesize, Version: defaultPromptVersion, Body: synthesizePrompt},
+	}
+}
+
+// NewPromptSet builds a set from stored prompts, keeping the built-in
+// default for any name that has no stored prompt.
+func NewPromptSet(prompts []Prompt) (PromptSet, error) {
+	set := DefaultPrompts()
+	for _, p := range prompts {
+		if p.Body == "" {
+			return PromptSet{}, fmt.Errorf("prompt %s %s has an empty body", p.Name, p.Version)
+		}
+
+		switch p.Name {
+		case PromptTransform:
+			set.Transform = p
+		case PromptSummary:
+			set.Summary = p
+		case PromptClusterRank:
+			set.ClusterRank = p
+		case PromptSynthesize:
+			set.Synthesize = p
+		default:
+			return PromptSet{}, fmt.Errorf("unknown prompt name %q", p.Name)
+		}
+	}
+	return set, nil
+}
+
+func (s PromptSet) withDefaults() PromptSet {
+	d := DefaultPrompts()
+	if s.Transform.Body == "" {
+		s.Transform = d.Transform
+	}
+	if s.Summary.Body == "" {
+		s.Summary = d.Summary
+	}
+	if s.ClusterRank.Body == "" {
+		s.ClusterRank = d.ClusterRank
+	}
+	if s.Synthesize.Body == "" {
+		s.Synthesize = d.Synthesize
+	}
+	return s
+}
+
+// defaultPromptVersion is the version of the built-in prompts.
+const defaultPromptVersion = "v1"
+
+const transformPrompt = `You are a financial news editor. Your job is to rewrite news headlines and summaries in a neutral, calm tone.
+
+Rules:
+1. Remove urgency words (BREAKING, NOW, ALERT, JUST IN)
+2. Remove ALL CAPS
+3. Replace emotional verbs:
+   - crash, plummet, tank → dropped, decreased
+   - explode, soar, skyrocket → rose, increased
+4. Remove judgmental words (smart, dumb, crazy, shocking, terrifying)
+5. Add uncertainty to predictions (will → may, could, might)
+6. Remove dramatic metaphors (bloodbath, shockwave, chaos)
+7. Keep all facts: numbers, names, dates, percentages
+
+Output as JSON only, no other text:
+{
+  "headline": "transformed headline",
+  "summary": "transformed summary",
+  "category": "one of: Earnings, Market Movement, Economy, Crypto, Mergers & Acquisitions, Policy & Regulation, Company News, Analysis",
+  "sentiment_score": 1-10 how emotional was the original (10 = very emotional)
+}`
+
+const summaryPrompt = `You are a financial news editor. Given a list of financial news headlines and summaries, provide an executive summary.
+
+Rules for the paragraph:
+- Single paragraph, concise and neutral
+- Summarizing the overall market mood
+
+Rules for bullets:
+- 3 to 5 bullet points
+- Each bullet covers a distinct key event or theme
+- Include company names, numbers, and percentages where relevant
+- One sentence per bullet
+
+Output as JSON only, no other text:
+{
+  "paragraph": "executive summary paragraph",
+  "bullets": ["key event 1", "key event 2", "key event 3"]
+}`
+
+const clusterRankPrompt = `You are a financial news editor. You will receive a list of financial news articles with metadata (index, headline, summary, publisher, published time, stock symbols).
+
+Your task is to cluster these articles by their PRIMARY SUBJECT and rank the clusters by importance.
+
+### Clustering Rules
+
+CRITICAL: Do NOT group articles by headline similarity. Articles about the same company or event often have completely different headlines because each publisher writes from a different angle.
+
+Instead, cluster articles by their PRIMARY SUBJECT — the company, event, or topic they are fundamentally about. For example, articles titled "Nvidia Crushes Q4 Estimates", "Why Nvidia's Stock Is Falling Despite Earnings Beat", and "S&P 500 Falls After Nvidia Plunge" are all about the same underlying story: Nvidia's earnings.
+
+- If multiple articles mention the same company as their primary subject, they are likely the same story
+- Articles about market-wide reactions (e.g. "S&P 500 falls") should be clustered with the company that CAUSED the reaction if one is clearly identified
+- An article that mentions a company in passing (e.g. "stocks to buy like Nvidia") is NOT primarily about that company — only cluster if the company is the main subject
+- Track ALL publishers and article count per cluster — this is the primary importance signal
+
+### Ranking Criteria (in order of weight)
+
+1. Total coverage volume — clusters with more articles are bigger stories
+2. Publisher diversity — stories covered by many DIFFERENT publishers are more significant. 6 different publishers > 10 articles from 1 publisher
+3. Market impact — earnings beats/misses, major M&A, regulatory actions, large price movements
+4. Broad relevance — stories affecting major indices, sectors, or widely-held stocks rank above niche/small-cap news
+5. Recency — more recent stories rank higher when other factors are equal
+
+### Output
+
+Return the top 10 clusters as JSON. If fewer than 10 distinct clusters exist, return all of them.
+
+Output JSON only, no other text:
+{
+  "clusters": [
+    {
+      "topic": "short descriptive label for the cluster",
+      "article_indices": [0, 3, 7],
+      "importance_reason": "brief explanation of why this ranks here"
+    }
+  ]
+}`
+
+const synthesizePrompt = `You are a financial news editor. You will receive a cluster of related news articles about the same topic/event.
+
+Your task is to synthesize these articles into a single comprehensive story summary.
+
+### Rules
+
+- Write a clear, informative headline (rewrite for clarity — never use clickbait or emotional language)
+- Write a 2-3 sentence summary that SYNTHESIZES key facts from ALL articles. Capture the full picture: what happened, market reaction, and why it matters
+- List the different angles/sub-stories covered within the cluster
+- List stock tickers mentioned across all articles
+- List all publishers that covered this story
+- Note the time range of coverage (e.g. "2h ago - 30min ago" or "Mar 1 10:00 - Mar 1 14:00")
+
+Output JSON only, no other text:
+{
+  "stories": [
+    {
+      "headline": "clear neutral headline",
+      "summary": "2-3 sentence synthesis of all articles in this cluster",
+      "angles": ["angle 1", "angle 2"],
+      "tickers": ["AAPL", "MSFT"],
+      "publishers": ["Reuters", "Bloomberg"],
+      "time_range": "time range of coverage"
+    }
+  ]
+}`
@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewPromptSet(t *testing.T) {
+	set, err := NewPromptSet([]Prompt{{ID: 7, Name: PromptSynthesize, Version: "v3", Body: "Synthesize carefully."}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if set.Synthesize.ID != 7 || set.Synthesize.Version != "v3" {
+		t.Errorf("stored prompt not used: %+v", set.Synthesize)
+	}
+	if set.Transform != DefaultPrompts().Transform {
+		t.Errorf("missing prompts should fall back to the defaults, got %+v", set.Transform)
+	}
+
+	if _, err := NewPromptSet([]Prompt{{Name: "headline", Body: "x"}}); err == nil {
+		t.Error("expected an error for an unknown prompt name")
+	}
+	if _, err := NewPromptSet([]Prompt{{Name: PromptSummary, Version: "v2"}}); err == nil {
+		t.Error("expected an error for an empty body")
+	}
+}
+
+// The prompt table is seeded with the built-in prompts, so the two must not
+// drift apart.
+func TestDefaultPromptsMatchSeed(t *testing.T) {
+	seed, err := os.ReadFile("../../migration/007_add_prompt.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := DefaultPrompts()
+	for _, p := range []Prompt{d.Transform, d.Summary, d.ClusterRank, d.Synthesize} {
+		row := fmt.Sprintf("('%s', '%s', $prompt$%s$prompt$", p.Name, p.Version, p.Body)
+		if !strings.Contains(string(seed), row) {
+			t.Errorf("the %s prompt differs from its seed in migration 007", p.Name)
+		}
+	}
+}
+
+func TestClientUsesStoredPrompts(t *testing.T) {
+	srv, requests := newFakeChatServer(t, fakeLocalReply)
+
+	prompts, err := NewPromptSet([]Prompt{{ID: 9, Name: PromptTransform, Version: "v2", Body: "Rewrite neutrally."}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client, err := New(Config{Provider: ProviderLocal, BaseURL: srv.URL + "/v1", Model: "llama3.1", Prompts: prompts})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := client.Transform(TransformInput{Headline: "Apple rose"})
+	if err != nil {
+		t.Fatalf("transform: %v", err)
+	}
+	if result.PromptVersion != "v2" || result.PromptID != 9 {
+		t.Errorf("expected prompt v2 (id 9), got %q (id %d)", result.PromptVersion, result.PromptID)
+	}
+	if system := (*requests)[0].Messages[0].Content; system != "Rewrite neutrally." {
+		t.Errorf("system prompt: got %q", system)
+	}
+}
@@ -0,0 +1,230 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxRepairAttempts is how many times invalid output is sent back to the
+// model with the validation error before the call fails.
+const maxRepairAttempts = 1
+
+// Categories is the set of categories the transform prompt allows.
+var Categories = []string{
+	"Earnings",
+	"Market Movement",
+	"Economy",
+	"Crypto",
+	"Mergers & Acquisitions",
+	"Policy & Regulation",
+	"Company News",
+	"Analysis",
+}
+
+// outputSchema describes the JSON object a call must return. It is sent as
+// an OpenAI json_schema response format or as the input schema of a forced
+// Anthropic tool call. Every property is required and no others are allowed,
+// as OpenAI's strict mode demands.
+type outputSchema struct {
+	name        string
+	description string
+	properties  map[string]any
+}
+
+func (s outputSchema) required() []string {
+	return requiredKeys(s.properties)
+}
+
+func (s outputSchema) jsonSchema() map[string]any {
+	return object(s.properties)
+}
+
+func object(properties map[string]any) map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             requiredKeys(properties),
+		"additionalProperties": false,
+	}
+}
+
+func requiredKeys(properties map[string]any) []string {
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func stringArray() map[string]any {
+	return map[string]any{"type": "array", "items": map[string]any{"type": "string"}}
+}
+
+var transformSchema = outputSchema{
+	name:        "transform_article",
+	description: "Neutral rewrite of a financial news article",
+	properties: map[string]any{
+		"headline":        map[string]any{"type": "string"},
+		"summary":         map[string]any{"type": "string"},
+		"category":        map[string]any{"type": "string", "enum": Categories},
+		"sentiment_score": map[string]any{"type": "integer", "description": "1-10, how emotional the original was"},
+	},
+}
+
+var summarySchema = outputSchema{
+	name:        "summarize_articles",
+	description: "Executive summary of a set of financial news articles",
+	properties: map[string]any{
+		"paragraph": map[string]any{"type": "string"},
+		"bullets":   stringArray(),
+	},
+}
+
+var clusterSchema = outputSchema{
+	name:        "cluster_articles",
+	description: "Articles clustered by primary subject and ranked by importance",
+	properties: map[string]any{
+		"clusters": map[string]any{
+			"type": "array",
+			"items": object(map[string]any{
+				"topic":             map[string]any{"type": "string"},
+				"article_indices":   map[string]any{"type": "array", "items": map[string]any{"type": "integer"}},
+				"importance_reason": map[string]any{"type": "string"},
+			}),
+		},
+	},
+}
+
+var synthesisSchema = outputSchema{
+	name:        "synthesize_story",
+	description: "A single story synthesized from a cluster of related articles",
+	properties: map[string]any{
+		"stories": map[string]any{
+			"type": "array",
+			"items": object(map[string]any{
+				"headline":   map[string]any{"type": "string"},
+				"summary":    map[string]any{"type": "string"},
+				"angles":     stringArray(),
+				"tickers":    stringArray(),
+				"publishers": stringArray(),
+				"time_range": map[string]any{"type": "string"},
+			}),
+		},
+	},
+}
+
+type validator interface {
+	validate() error
+}
+
+type transformOutput struct {
+	Headline       string `json:"headline"`
+	Summary        string `json:"summary"`
+	Category       string `json:"category"`
+	SentimentScore int    `json:"sentiment_score"`
+}
+
+func (o *transformOutput) validate() error {
+	if strings.TrimSpace(o.Headline) == "" || strings.TrimSpace(o.Summary) == "" {
+		return errors.New("headline and summary must not be empty")
+	}
+	if !slices.Contains(Categories, o.Category) {
+		return fmt.Errorf("category %q is not one of: %s", o.Category, strings.Join(Categories, ", "))
+	}
+	if o.SentimentScore < 1 || o.SentimentScore > 10 {
+		return fmt.Errorf("sentiment_score %d is outside 1-10", o.SentimentScore)
+	}
+	return nil
+}
+
+// result is the TransformResult for output produced with prompt by model.
+func (o *transformOutput) result(prompt Prompt, model string, usage Usage) *TransformResult {
+	return &TransformResult{
+		Headline:       o.Headline,
+		Detail:         o.Summary,
+		Category:       o.Category,
+		SentimentScore: o.SentimentScore,
+		PromptVersion:  prompt.Version,
+		PromptID:       prompt.ID,
+		ModelUsed:      model,
+		Usage:          usage,
+	}
+}
+
+// transformUserPrompt is the user message of a transform call.
+func transformUserPrompt(input TransformInput) string {
+	return fmt.Sprintf("Headline: %s\nSummary: %s", input.Headline, input.Detail)
+}
+
+type summaryOutput struct {
+	Paragraph string   `json:"paragraph"`
+	Bullets   []string `json:"bullets"`
+}
+
+func (o *summaryOutput) validate() error {
+	if strings.TrimSpace(o.Paragraph) == "" {
+		return errors.New("paragraph must not be empty")
+	}
+	if len(o.Bullets) == 0 {
+		return errors.New("bullets must not be empty")
+	}
+	return nil
+}
+
+type clusterOutput struct {
+	Clusters []struct {
+		Topic            string `json:"topic"`
+		ArticleIndices   []int  `json:"article_indices"`
+		ImportanceReason string `json:"importance_reason"`
+	} `json:"clusters"`
+
+	articleCount int
+}
+
+func (o *clusterOutput) validate() error {
+	if len(o.Clusters) == 0 && o.articleCount > 0 {
+		return errors.New("clusters must not be empty")
+	}
+	for _, c := range o.Clusters {
+		if len(c.ArticleIndices) == 0 {
+			return fmt.Errorf("cluster %q has no article_indices", c.Topic)
+		}
+		for _, idx := range c.ArticleIndices {
+			if idx < 0 || idx >= o.articleCount {
+				return fmt.Errorf("cluster %q has article index %d, valid indices are 0-%d", c.Topic, idx, o.articleCount-1)
+			}
+		}
+	}
+	return nil
+}
+
+type synthesisOutput struct {
+	Stories []StorySummary `json:"stories"`
+}
+
+func (o *synthesisOutput) validate() error {
+	if len(o.Stories) == 0 {
+		return errors.New("no stories in synthesis response")
+	}
+	if strings.TrimSpace(o.Stories[0].Headline) == "" {
+		return errors.New("story headline must not be empty")
+	}
+	return nil
+}
+
+// decodeOutput parses content into out and validates it. Fences and prose
+// around the JSON are tolerated for servers that ignore the schema.
+func decodeOutput(content string, out validator) error {
+	if err := json.Unmarshal([]byte(cleanJSONResponse(content)), out); err != nil {
+		return err
+	}
+	return out.validate()
+}
+
+func repairPrompt(err error) string {
+	return fmt.Sprintf("Your previous response was invalid: %v. Reply again with the corrected output, following the schema exactly.", err)
+}
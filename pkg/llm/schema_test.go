@@ -0,0 +1,32 @@
+package llm
+
+import "testing"
+
+func TestDecodeOutputValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		out     validator
+		wantErr bool
+	}{
+		{"valid transform", `{"headline": "h", "summary": "s", "category": "Crypto", "sentiment_score": 10}`, &transformOutput{}, false},
+		{"unknown category", `{"headline": "h", "summary": "s", "category": "Tech", "sentiment_score": 5}`, &transformOutput{}, true},
+		{"sentiment out of range", `{"headline": "h", "summary": "s", "category": "Crypto", "sentiment_score": 0}`, &transformOutput{}, true},
+		{"empty headline", `{"headline": " ", "summary": "s", "category": "Crypto", "sentiment_score": 5}`, &transformOutput{}, true},
+		{"summary without bullets", `{"paragraph": "p", "bullets": []}`, &summaryOutput{}, true},
+		{"cluster indices in range", `{"clusters": [{"topic": "t", "article_indices": [0, 2]}]}`, &clusterOutput{articleCount: 3}, false},
+		{"cluster index out of range", `{"clusters": [{"topic": "t", "article_indices": [3]}]}`, &clusterOutput{articleCount: 3}, true},
+		{"negative cluster index", `{"clusters": [{"topic": "t", "article_indices": [-1]}]}`, &clusterOutput{articleCount: 3}, true},
+		{"no stories", `{"stories": []}`, &synthesisOutput{}, true},
+		{"not JSON", `no JSON here`, &summaryOutput{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := decodeOutput(tt.content, tt.out)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,24 +2,6 @@ package llm
 
 import "time"
 
-const summarySystemPrompt = `You are a financial news editor. Given a list of financial news headlines and summaries, provide an executive summary.
-
-Rules for the paragraph:
-- Single paragraph, concise and neutral
-- Summarizing the overall market mood
-
-Rules for bullets:
-- 3 to 5 bullet points
-- Each bullet covers a distinct key event or theme
-- Include company names, numbers, and percentages where relevant
-- One sentence per bullet
-
-Output as JSON only, no other text:
-{
-  "paragraph": "executive summary paragraph",
-  "bullets": ["key event 1", "key event 2", "key event 3"]
-}`
-
 type SummaryInput struct {
 	ID          int64
 	Headline    string
@@ -33,6 +15,7 @@ type SummaryResult struct {
 	Paragraph string
 	Bullets   []string
 	ModelUsed string
+	Usage     Usage
 }
 
 type StorySummary struct {
@@ -47,6 +30,7 @@ type StorySummary struct {
 type ClusterSummaryResult struct {
 	Stories   []StorySummary
 	ModelUsed string
+	Usage     Usage
 }
 
 type SummaryClient interface {
@@ -1,15 +1,19 @@
 package news
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
 type AlphaVantageClient struct {
 	apiKey     string
+	topics     []string
 	httpClient *http.Client
 }
 
@@ -24,21 +28,44 @@ func (c *AlphaVantageClient) Name() string {
 	return "AlphaVantage"
 }
 
-func (c *AlphaVantageClient) Fetch(limit int) ([]Article, error) {
-	url := fmt.Sprintf(
-		"https://www.alphavantage.co/query?function=NEWS_SENTIMENT&limit=%d&sort=LATEST&apikey=%s",
-		limit, c.apiKey,
-	)
+// Fetch returns the latest articles on a first fetch and afterwards the
+// earliest articles from req.Since on. time_from has minute precision, so
+// articles from that minute come back again.
+func (c *AlphaVantageClient) Fetch(ctx context.Context, req FetchRequest) ([]Article, string, error) {
+	query := url.Values{}
+	query.Set("function", "NEWS_SENTIMENT")
+	query.Set("limit", fmt.Sprint(req.Limit))
+	query.Set("sort", "LATEST")
+	if len(c.topics) > 0 {
+		query.Set("topics", strings.Join(c.topics, ","))
+	}
+	if !req.Since.IsZero() {
+		query.Set("sort", "EARLIEST")
+		query.Set("time_from", req.Since.UTC().Format("20060102T1504"))
+	}
+	query.Set("apikey", c.apiKey)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://www.alphavantage.co/query?"+query.Encode(), nil)
+	if err != nil {
+		return nil, req.Cursor, fmt.Errorf("alphavantage fetch: %w", err)
+	}
 
-	resp, err := c.httpClient.Get(url)
+	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("alphavantage fetch: %w", err)
+		return nil, req.Cursor, fmt.Errorf("alphavantage fetch: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, req.Cursor, statusError(c.Name(), resp)
+	}
+
 	var raw avResponse
 	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-		return nil, fmt.Errorf("alphavantage decode: %w", err)
+		return nil, req.Cursor, fmt.Errorf("alphavantage decode: %w", err)
+	}
+	if err := raw.err(c.Name()); err != nil {
+		return nil, req.Cursor, err
 	}
 
 	articles := make([]Article, 0, len(raw.Feed))
@@ -67,7 +94,7 @@ func (c *AlphaVantageClient) Fetch(limit int) ([]Article, error) {
 		})
 	}
 
-	return articles, nil
+	return articles, req.Cursor, nil
 }
 
 func generateExternalID(url string) string {
@@ -76,7 +103,31 @@ func generateExternalID(url string) string {
 }
 
 type avResponse struct {
-	Feed []avFeedItem `json:"feed"`
+	Feed         []avFeedItem `json:"feed"`
+	Note         string       `json:"Note"`
+	Information  string       `json:"Information"`
+	ErrorMessage string       `json:"Error Message"`
+}
+
+// err returns the error AlphaVantage sent in place of a feed, with HTTP 200:
+// a Note when calls come too fast, an Information message for the daily
+// limit, call frequency or premium endpoints, or an Error Message for invalid
+// requests and keys.
+func (r avResponse) err(source string) error {
+	info := strings.ToLower(r.Information)
+	switch {
+	case r.Note != "":
+		return rateLimited(source, r.Note, 0)
+	case strings.Contains(info, "per day"):
+		return quotaExceeded(source, r.Information)
+	case strings.Contains(info, "per second") || strings.Contains(info, "per minute") || strings.Contains(info, "spreading out"):
+		return rateLimited(source, r.Information, 0)
+	case r.Information != "":
+		return &APIError{Source: source, Message: r.Information}
+	case r.ErrorMessage != "":
+		return &APIError{Source: source, Message: r.ErrorMessage}
+	}
+	return nil
 }
 
 type avFeedItem struct {
@@ -1,9 +1,11 @@
 package news
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -65,7 +67,7 @@ func TestFetch(t *testing.T) {
 	}
 	client.httpClient.Transport = &rewriteTransport{base: srv.URL, inner: http.DefaultTransport}
 
-	articles, err := client.Fetch(1)
+	articles, _, err := client.Fetch(context.Background(), FetchRequest{Limit: 1})
 
 	assert.Equal(t, nil, err)
 	assert.Equal(t, 1, len(articles))
@@ -81,6 +83,26 @@ func TestFetch(t *testing.T) {
 	assert.NotEqual(t, time.Time{}, a.PublishedAt)
 }
 
+func TestFetchSince(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"feed": []}`))
+	}))
+	defer srv.Close()
+
+	client := &AlphaVantageClient{apiKey: "test-key", httpClient: srv.Client()}
+	client.httpClient.Transport = &rewriteTransport{base: srv.URL, inner: http.DefaultTransport}
+
+	since := time.Date(2026, 2, 26, 12, 0, 30, 0, time.UTC)
+	_, _, err := client.Fetch(context.Background(), FetchRequest{Since: since, Limit: 50})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "20260226T1200", query.Get("time_from"))
+	assert.Equal(t, "EARLIEST", query.Get("sort"))
+}
+
 // rewriteTransport redirects all requests to a fixed base URL (test server).
 type rewriteTransport struct {
 	base  string
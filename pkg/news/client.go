@@ -1,6 +1,9 @@
 package news
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type Article struct {
 	ExternalID  string
@@ -13,7 +16,21 @@ type Article struct {
 	Publisher   string
 }
 
+// FetchRequest asks a source for up to Limit articles newer than the last
+// fetch. Since is the newest publish time seen from the source and Cursor the
+// position its previous fetch returned; both are zero on a first fetch.
+type FetchRequest struct {
+	Since  time.Time
+	Cursor string
+	Limit  int
+}
+
+// NewsClient fetches articles from one source. Fetch returns the cursor to
+// pass on the next request, which is the one it was given when the source
+// has nothing to track. Sources may still return articles already seen, for
+// the store to skip. A Fetch that fails partway may return the articles it
+// got before the error, which are saved, along with the cursor it was given.
 type NewsClient interface {
-	Fetch(limit int) ([]Article, error)
+	Fetch(ctx context.Context, req FetchRequest) ([]Article, string, error)
 	Name() string
 }
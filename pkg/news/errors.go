@@ -0,0 +1,121 @@
+package news
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LimitError reports that a source may not be fetched until Until, because
+// the provider or the source's configured limit refused a request. Quota is
+// true for a daily quota and false for a short-term rate limit.
+type LimitError struct {
+	Source  string
+	Quota   bool
+	Until   time.Time
+	Message string
+}
+
+func (e *LimitError) Error() string {
+	kind := "rate limited"
+	if e.Quota {
+		kind = "out of quota"
+	}
+	msg := fmt.Sprintf("%s %s until %s", e.Source, kind, e.Until.UTC().Format(time.RFC3339))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// APIError is an error reported by a provider, such as an invalid API key or
+// parameter, including those sent with HTTP 200.
+type APIError struct {
+	Source     string
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s API error", e.Source)
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(" (HTTP %d)", e.StatusCode)
+	}
+	if e.Code != "" {
+		msg += " " + e.Code
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// rateLimited is a short-term limit, lifted after retryAfter or a minute
+// when the provider did not say.
+func rateLimited(source, message string, retryAfter time.Duration) *LimitError {
+	if retryAfter <= 0 {
+		retryAfter = time.Minute
+	}
+	return &LimitError{Source: source, Until: time.Now().Add(retryAfter), Message: message}
+}
+
+// quotaExceeded is a daily quota, reset at midnight UTC.
+func quotaExceeded(source, message string) *LimitError {
+	return &LimitError{Source: source, Quota: true, Until: nextUTCDay(time.Now()), Message: message}
+}
+
+func nextUTCDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+}
+
+// statusError returns the error for a response other than 200 OK: a
+// LimitError for 429 and an APIError with the start of the body otherwise.
+func statusError(source string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	message := errorMessage(body)
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return rateLimited(source, message, parseRetryAfter(resp.Header))
+	}
+	return &APIError{Source: source, StatusCode: resp.StatusCode, Message: message}
+}
+
+// errorMessage reads the message of a JSON error body, falling back to the
+// body itself.
+func errorMessage(body []byte) string {
+	var payload struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		var text string
+		if json.Unmarshal(payload.Error, &text) == nil && text != "" {
+			return text
+		}
+		if payload.Message != "" {
+			return payload.Message
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// parseRetryAfter reads the Retry-After header in either its seconds or
+// HTTP-date form.
+func parseRetryAfter(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
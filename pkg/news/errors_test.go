@@ -0,0 +1,97 @@
+package news
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// errorServer answers every request with status and body.
+func errorServer(t *testing.T, status int, header http.Header, body string) *http.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := srv.Client()
+	client.Transport = &rewriteTransport{base: srv.URL, inner: http.DefaultTransport}
+	return client
+}
+
+func TestAlphaVantageErrorPayloads(t *testing.T) {
+	tests := []struct {
+		body  string
+		limit bool
+		quota bool
+	}{
+		{`{"Note": "Thank you for using Alpha Vantage! Our standard API call frequency is 5 calls per minute."}`, true, false},
+		{`{"Information": "We have detected your API key as demo and our standard API rate limit is 25 requests per day."}`, true, true},
+		{`{"Information": "Please consider spreading out your free API requests more sparingly (1 request per second)."}`, true, false},
+		{`{"Information": "This is a premium endpoint."}`, false, false},
+		{`{"Error Message": "Invalid API call."}`, false, false},
+	}
+
+	for _, tt := range tests {
+		client := &AlphaVantageClient{apiKey: "test-key", httpClient: errorServer(t, http.StatusOK, nil, tt.body)}
+		_, _, err := client.Fetch(context.Background(), FetchRequest{Limit: 50})
+
+		var limitErr *LimitError
+		var apiErr *APIError
+		assert.Equal(t, tt.limit, errors.As(err, &limitErr))
+		assert.Equal(t, !tt.limit, errors.As(err, &apiErr))
+		if tt.limit {
+			assert.Equal(t, tt.quota, limitErr.Quota)
+			assert.Equal(t, true, limitErr.Until.After(time.Now()))
+		}
+	}
+}
+
+func TestMarketauxUsageLimit(t *testing.T) {
+	client := NewMarketauxClient("test-key", 1)
+	client.httpClient = errorServer(t, http.StatusPaymentRequired, nil,
+		`{"error": {"code": "usage_limit_reached", "message": "The usage limit for this account has been reached."}}`)
+
+	_, _, err := client.Fetch(context.Background(), FetchRequest{Limit: 50})
+
+	var limitErr *LimitError
+	assert.Equal(t, true, errors.As(err, &limitErr))
+	assert.Equal(t, true, limitErr.Quota)
+	assert.Equal(t, nextUTCDay(time.Now()), limitErr.Until)
+}
+
+func TestMassiveTooManyRequests(t *testing.T) {
+	client := NewMassiveClient("test-key")
+	client.httpClient = errorServer(t, http.StatusTooManyRequests, http.Header{"Retry-After": {"120"}},
+		`{"status": "ERROR", "error": "You've exceeded the maximum requests per minute."}`)
+
+	_, _, err := client.Fetch(context.Background(), FetchRequest{Limit: 50})
+
+	var limitErr *LimitError
+	assert.Equal(t, true, errors.As(err, &limitErr))
+	assert.Equal(t, false, limitErr.Quota)
+	assert.Equal(t, "You've exceeded the maximum requests per minute.", limitErr.Message)
+	assert.Equal(t, true, time.Until(limitErr.Until) > time.Minute)
+}
+
+func TestStatusErrorIsAPIError(t *testing.T) {
+	client := NewMassiveClient("test-key")
+	client.httpClient = errorServer(t, http.StatusUnauthorized, nil, `{"status": "ERROR", "error": "Unknown API Key"}`)
+
+	_, _, err := client.Fetch(context.Background(), FetchRequest{Limit: 50})
+
+	var apiErr *APIError
+	assert.Equal(t, true, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, "Unknown API Key", apiErr.Message)
+}
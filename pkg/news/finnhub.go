@@ -2,6 +2,7 @@ package news
 
 import (
 	"context"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -10,20 +11,35 @@ import (
 )
 
 type FinnHubClient struct {
-	client *finnhub.DefaultApiService
+	client   *finnhub.DefaultApiService
+	category string
 }
 
 func NewFinnHubClient(apiKey string) *FinnHubClient {
 	cfg := finnhub.NewConfiguration()
 	cfg.AddDefaultHeader("X-Finnhub-Token", apiKey)
 	client := finnhub.NewAPIClient(cfg).DefaultApi
-	return &FinnHubClient{client: client}
+	return &FinnHubClient{client: client, category: "general"}
 }
 
-func (c *FinnHubClient) Fetch(limit int) ([]Article, error) {
-	res, _, err := c.client.MarketNews(context.Background()).Category("general").Execute()
+// Fetch returns the market news in the client's category after the ID in req.Cursor. The
+// next cursor is the highest ID returned.
+func (c *FinnHubClient) Fetch(ctx context.Context, req FetchRequest) ([]Article, string, error) {
+	call := c.client.MarketNews(ctx).Category(c.category)
+	lastID, _ := strconv.ParseInt(req.Cursor, 10, 64)
+	if lastID > 0 {
+		call = call.MinId(lastID)
+	}
+
+	res, httpResp, err := call.Execute()
 	if err != nil {
-		return nil, err
+		if httpResp != nil && httpResp.StatusCode == http.StatusTooManyRequests {
+			return nil, req.Cursor, rateLimited(c.Name(), err.Error(), parseRetryAfter(httpResp.Header))
+		}
+		if httpResp != nil && (httpResp.StatusCode == http.StatusUnauthorized || httpResp.StatusCode == http.StatusForbidden) {
+			return nil, req.Cursor, &APIError{Source: c.Name(), StatusCode: httpResp.StatusCode, Message: err.Error()}
+		}
+		return nil, req.Cursor, err
 	}
 
 	var articles []Article
@@ -34,7 +50,12 @@ func (c *FinnHubClient) Fetch(limit int) ([]Article, error) {
 		}
 
 		if news.Id != nil {
+			// Skip the article at the cursor in case minId is inclusive.
+			if *news.Id <= lastID {
+				continue
+			}
 			a.ExternalID = strconv.FormatInt(*news.Id, 10)
+			lastID = max(lastID, *news.Id)
 		}
 
 		if news.Headline != nil {
@@ -66,7 +87,10 @@ func (c *FinnHubClient) Fetch(limit int) ([]Article, error) {
 		articles = append(articles, a)
 	}
 
-	return articles, nil
+	if lastID == 0 {
+		return articles, req.Cursor, nil
+	}
+	return articles, strconv.FormatInt(lastID, 10), nil
 }
 
 func (c *FinnHubClient) Name() string {
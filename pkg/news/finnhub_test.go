@@ -0,0 +1,37 @@
+package news
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	finnhub "github.com/Finnhub-Stock-API/finnhub-go/v2"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestFinnHubFetchAfterCursor(t *testing.T) {
+	var minID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		minID = r.URL.Query().Get("minId")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[
+			{"id": 7, "headline": "Fed holds rates", "url": "https://example.com/fed", "datetime": 1772107200, "source": "Reuters", "related": "SPY"},
+			{"id": 9, "headline": "Oil rises", "url": "https://example.com/oil", "datetime": 1772110800, "source": "CNBC", "related": ""}
+		]`))
+	}))
+	defer srv.Close()
+
+	cfg := finnhub.NewConfiguration()
+	cfg.Servers = finnhub.ServerConfigurations{{URL: srv.URL}}
+	client := &FinnHubClient{client: finnhub.NewAPIClient(cfg).DefaultApi}
+
+	articles, cursor, err := client.Fetch(context.Background(), FetchRequest{Cursor: "7", Limit: 50})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "7", minID)
+	assert.Equal(t, "9", cursor)
+	assert.Equal(t, 1, len(articles))
+	assert.Equal(t, "Oil rises", articles[0].Headline)
+	assert.Equal(t, "FinnHub", articles[0].Source)
+}
@@ -0,0 +1,78 @@
+package news
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Limiter counts requests against limits shared by every process fetching
+// from a source. Allow counts one request in the current window of key and
+// reports whether it is within limit, and otherwise how long until the
+// window ends.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error)
+}
+
+// limitedTransport holds a source's requests to its requests per minute,
+// waiting for the next minute when its fetch has time to, and refuses them
+// with a LimitError once its requests per day are used up. Limiter errors
+// are logged and the request is sent.
+type limitedTransport struct {
+	base      http.RoundTripper
+	limiter   Limiter
+	source    string
+	perMinute int
+	perDay    int
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+func (t *limitedTransport) wait(ctx context.Context) error {
+	for t.perMinute > 0 {
+		ok, retry, err := t.limiter.Allow(ctx, t.source+":minute", t.perMinute, time.Minute)
+		if err != nil {
+			slog.Warn("error checking rate limit, sending the request", "source", t.source, "error", err)
+			break
+		}
+		if ok {
+			break
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < retry {
+			return rateLimited(t.source, fmt.Sprintf("configured limit of %d requests per minute", t.perMinute), retry)
+		}
+		slog.Info("source rate limited, waiting", "source", t.source, "wait", retry)
+		timer := time.NewTimer(retry)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if t.perDay > 0 {
+		ok, retry, err := t.limiter.Allow(ctx, t.source+":day", t.perDay, 24*time.Hour)
+		if err != nil {
+			slog.Warn("error checking daily quota, sending the request", "source", t.source, "error", err)
+			return nil
+		}
+		if !ok {
+			return &LimitError{
+				Source:  t.source,
+				Quota:   true,
+				Until:   time.Now().Add(retry),
+				Message: fmt.Sprintf("configured limit of %d requests per day", t.perDay),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package news
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// fakeLimiter allows limit requests per key, then refuses for retry.
+type fakeLimiter struct {
+	mu     sync.Mutex
+	counts map[string]int
+	retry  time.Duration
+}
+
+func (l *fakeLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.counts[key]++
+	if l.counts[key] > limit {
+		return false, l.retry, nil
+	}
+	return true, 0, nil
+}
+
+// limitedServer returns a client limited by limiter, the URL of a test
+// server and the number of requests the server received.
+func limitedServer(t *testing.T, limiter Limiter, perMinute, perDay int) (*http.Client, string, *int) {
+	t.Helper()
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &http.Client{Transport: &limitedTransport{
+		base:      http.DefaultTransport,
+		limiter:   limiter,
+		source:    "test",
+		perMinute: perMinute,
+		perDay:    perDay,
+	}}
+	return client, srv.URL, &requests
+}
+
+func TestLimitedTransportDailyQuota(t *testing.T) {
+	limiter := &fakeLimiter{counts: map[string]int{}, retry: 3 * time.Hour}
+	client, url, requests := limitedServer(t, limiter, 0, 2)
+
+	var err error
+	for range 3 {
+		_, err = client.Get(url)
+	}
+
+	var limitErr *LimitError
+	assert.Equal(t, true, errors.As(err, &limitErr))
+	assert.Equal(t, true, limitErr.Quota)
+	assert.Equal(t, true, time.Until(limitErr.Until) > 2*time.Hour)
+	assert.Equal(t, 2, *requests)
+}
+
+func TestLimitedTransportRateLimit(t *testing.T) {
+	limiter := &fakeLimiter{counts: map[string]int{}, retry: time.Minute}
+	client, url, requests := limitedServer(t, limiter, 1, 0)
+
+	_, err := client.Get(url)
+	assert.Equal(t, nil, err)
+
+	// The fetch cannot wait a minute for the next window.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	start := time.Now()
+	_, err = client.Do(req)
+
+	var limitErr *LimitError
+	assert.Equal(t, true, errors.As(err, &limitErr))
+	assert.Equal(t, false, limitErr.Quota)
+	assert.Equal(t, true, time.Since(start) < time.Second)
+	assert.Equal(t, 1, *requests)
+}
@@ -1,10 +1,14 @@
 package news
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -13,6 +17,7 @@ const marketauxPerPage = 3
 type MarketauxClient struct {
 	apiKey     string
 	maxPages   int
+	industries []string
 	httpClient *http.Client
 }
 
@@ -28,25 +33,48 @@ func (c *MarketauxClient) Name() string {
 	return "Marketaux"
 }
 
-func (c *MarketauxClient) Fetch(limit int) ([]Article, error) {
+// Fetch pages through the latest articles on a first fetch and afterwards
+// through the oldest articles published after req.Since, so a backlog larger
+// than the limit is caught up over several fetches.
+func (c *MarketauxClient) Fetch(ctx context.Context, req FetchRequest) ([]Article, string, error) {
 	var articles []Article
 
 	for page := 1; page <= c.maxPages; page++ {
-		url := fmt.Sprintf(
-			"https://api.marketaux.com/v1/news/all?language=en&limit=%d&page=%d&api_token=%s",
-			marketauxPerPage, page, c.apiKey,
-		)
+		query := url.Values{}
+		query.Set("language", "en")
+		query.Set("limit", fmt.Sprint(marketauxPerPage))
+		query.Set("page", fmt.Sprint(page))
+		if len(c.industries) > 0 {
+			query.Set("industries", strings.Join(c.industries, ","))
+		}
+		if !req.Since.IsZero() {
+			query.Set("published_after", req.Since.UTC().Format("2006-01-02T15:04:05"))
+			query.Set("sort", "published_on")
+			query.Set("sort_order", "asc")
+		}
+		query.Set("api_token", c.apiKey)
 
-		resp, err := c.httpClient.Get(url)
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.marketaux.com/v1/news/all?"+query.Encode(), nil)
 		if err != nil {
-			return articles, fmt.Errorf("marketaux fetch page %d: %w", page, err)
+			return articles, req.Cursor, fmt.Errorf("marketaux fetch page %d: %w", page, err)
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return articles, req.Cursor, fmt.Errorf("marketaux fetch page %d: %w", page, err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := c.statusError(resp)
+			resp.Body.Close()
+			return articles, req.Cursor, err
 		}
 
 		var raw marketauxResponse
 		err = json.NewDecoder(resp.Body).Decode(&raw)
 		resp.Body.Close()
 		if err != nil {
-			return articles, fmt.Errorf("marketaux decode page %d: %w", page, err)
+			return articles, req.Cursor, fmt.Errorf("marketaux decode page %d: %w", page, err)
 		}
 
 		if len(raw.Data) == 0 {
@@ -59,12 +87,12 @@ func (c *MarketauxClient) Fetch(limit int) ([]Article, error) {
 
 		slog.Info("marketaux page fetched", "page", page, "count", len(raw.Data))
 
-		if len(articles) >= limit {
+		if len(articles) >= req.Limit {
 			break
 		}
 	}
 
-	return articles, nil
+	return articles, req.Cursor, nil
 }
 
 func (c *MarketauxClient) toArticle(item marketauxArticle) Article {
@@ -97,6 +125,31 @@ func (c *MarketauxClient) toArticle(item marketauxArticle) Article {
 	}
 }
 
+// statusError reads a Marketaux error body, which names the limit that was
+// reached: usage_limit_reached for the daily quota and rate_limit_reached
+// for too many requests per minute.
+func (c *MarketauxClient) statusError(resp *http.Response) error {
+	var raw marketauxErrorResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&raw); err != nil {
+		return &APIError{Source: c.Name(), StatusCode: resp.StatusCode}
+	}
+
+	switch raw.Error.Code {
+	case "usage_limit_reached":
+		return quotaExceeded(c.Name(), raw.Error.Message)
+	case "rate_limit_reached":
+		return rateLimited(c.Name(), raw.Error.Message, parseRetryAfter(resp.Header))
+	}
+	return &APIError{Source: c.Name(), StatusCode: resp.StatusCode, Code: raw.Error.Code, Message: raw.Error.Message}
+}
+
+type marketauxErrorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
 type marketauxResponse struct {
 	Data []marketauxArticle `json:"data"`
 }
@@ -0,0 +1,40 @@
+package news
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestMarketauxFetchSince(t *testing.T) {
+	var queries []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": []}`))
+	}))
+	defer srv.Close()
+
+	client := NewMarketauxClient("test-key", 2)
+	client.httpClient.Transport = &rewriteTransport{base: srv.URL, inner: http.DefaultTransport}
+
+	// A first fetch takes the latest articles.
+	_, _, err := client.Fetch(context.Background(), FetchRequest{Limit: 10})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "", queries[0].Get("sort_order"))
+
+	// Later fetches page forward from the oldest article after Since.
+	since := time.Date(2026, 2, 26, 11, 2, 0, 0, time.UTC)
+	_, _, err = client.Fetch(context.Background(), FetchRequest{Since: since, Limit: 10})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "2026-02-26T11:02:00", queries[1].Get("published_after"))
+	assert.Equal(t, "published_on", queries[1].Get("sort"))
+	assert.Equal(t, "asc", queries[1].Get("sort_order"))
+}
@@ -1,9 +1,11 @@
 package news
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -23,21 +25,38 @@ func (c *MassiveClient) Name() string {
 	return "Massive"
 }
 
-func (c *MassiveClient) Fetch(limit int) ([]Article, error) {
-	url := fmt.Sprintf(
-		"https://api.massive.com/v2/reference/news?limit=%d&order=desc&sort=published_utc&apiKey=%s",
-		limit, c.apiKey,
-	)
+// Fetch returns the latest articles on a first fetch and afterwards the
+// oldest articles published after req.Since, so a backlog larger than the
+// limit is caught up over several fetches.
+func (c *MassiveClient) Fetch(ctx context.Context, req FetchRequest) ([]Article, string, error) {
+	query := url.Values{}
+	query.Set("limit", fmt.Sprint(req.Limit))
+	query.Set("sort", "published_utc")
+	query.Set("order", "desc")
+	if !req.Since.IsZero() {
+		query.Set("order", "asc")
+		query.Set("published_utc.gt", req.Since.UTC().Format(time.RFC3339))
+	}
+	query.Set("apiKey", c.apiKey)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.massive.com/v2/reference/news?"+query.Encode(), nil)
+	if err != nil {
+		return nil, req.Cursor, fmt.Errorf("massive fetch: %w", err)
+	}
 
-	resp, err := c.httpClient.Get(url)
+	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("massive fetch: %w", err)
+		return nil, req.Cursor, fmt.Errorf("massive fetch: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, req.Cursor, statusError(c.Name(), resp)
+	}
+
 	var raw massiveResponse
 	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-		return nil, fmt.Errorf("massive decode: %w", err)
+		return nil, req.Cursor, fmt.Errorf("massive decode: %w", err)
 	}
 
 	articles := make([]Article, 0, len(raw.Results))
@@ -59,7 +78,7 @@ func (c *MassiveClient) Fetch(limit int) ([]Article, error) {
 		})
 	}
 
-	return articles, nil
+	return articles, req.Cursor, nil
 }
 
 type massiveResponse struct {
@@ -1,9 +1,11 @@
 package news
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -40,7 +42,7 @@ func TestMassiveFetch(t *testing.T) {
 	}
 	client.httpClient.Transport = &rewriteTransport{base: srv.URL, inner: http.DefaultTransport}
 
-	articles, err := client.Fetch(1)
+	articles, _, err := client.Fetch(context.Background(), FetchRequest{Limit: 1})
 
 	assert.Equal(t, nil, err)
 	assert.Equal(t, 1, len(articles))
@@ -89,9 +91,31 @@ func TestMassiveFetchEmptyTickers(t *testing.T) {
 	}
 	client.httpClient.Transport = &rewriteTransport{base: srv.URL, inner: http.DefaultTransport}
 
-	articles, err := client.Fetch(1)
+	articles, _, err := client.Fetch(context.Background(), FetchRequest{Limit: 1})
 
 	assert.Equal(t, nil, err)
 	assert.Equal(t, 1, len(articles))
 	assert.Equal(t, 0, len(articles[0].Symbols))
 }
+
+func TestMassiveFetchSince(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"results": [], "status": "OK"}`))
+	}))
+	defer srv.Close()
+
+	client := &MassiveClient{apiKey: "test-key", httpClient: srv.Client()}
+	client.httpClient.Transport = &rewriteTransport{base: srv.URL, inner: http.DefaultTransport}
+
+	since := time.Date(2026, 2, 26, 11, 2, 0, 0, time.UTC)
+	_, cursor, err := client.Fetch(context.Background(), FetchRequest{Since: since, Limit: 50})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "", cursor)
+	assert.Equal(t, "2026-02-26T11:02:00Z", query.Get("published_utc.gt"))
+	assert.Equal(t, "asc", query.Get("order"))
+	assert.Equal(t, "50", query.Get("limit"))
+}
@@ -0,0 +1,280 @@
+package news
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	finnhub "github.com/Finnhub-Stock-API/finnhub-go/v2"
+	"github.com/goccy/go-yaml"
+)
+
+// Config lists the news sources to fetch from.
+type Config struct {
+	Sources []SourceConfig `yaml:"sources"`
+}
+
+// SourceConfig describes one news source. Name identifies the source and
+// where its fetches left off, so it must be unique and should not change.
+// Topics narrow what the source returns: FinnHub takes one news category,
+// AlphaVantage topics and Marketaux industries. Limit replaces the fetcher's
+// per-source limit when set, and Publisher replaces the publisher of every
+// article. RequestsPerMinute and RequestsPerDay cap the source's HTTP
+// requests across every process sharing the registry's Limiter. Schedule is
+// a cron expression or interval for schedulers; the fetcher fetches every
+// enabled source on each run.
+type SourceConfig struct {
+	Name      string   `yaml:"name"`
+	Type      string   `yaml:"type"`
+	APIKeyEnv string   `yaml:"api_key_env"`
+	URL       string   `yaml:"url"`
+	Limit     int      `yaml:"limit"`
+	MaxPages  int      `yaml:"max_pages"`
+	Schedule  string   `yaml:"schedule"`
+	Topics    []string `yaml:"topics"`
+	Enabled   *bool    `yaml:"enabled"`
+	Publisher string   `yaml:"publisher"`
+
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	RequestsPerDay    int `yaml:"requests_per_day"`
+}
+
+// IsEnabled reports whether the source is enabled, which it is unless the
+// config says otherwise.
+func (c SourceConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// LoadConfig reads a source config from a YAML file, or a JSON one, since
+// JSON is valid YAML. Unknown fields are rejected to catch typos.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading source config: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.UnmarshalWithOptions(data, &cfg, yaml.Strict(), yaml.DisallowUnknownField()); err != nil {
+		return nil, fmt.Errorf("parsing source config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// SourceType builds the clients of one type of source, making their requests
+// with httpClient. KeyEnv is the environment variable holding the API key
+// when a source does not name one; it is empty for sources that need no key.
+type SourceType struct {
+	KeyEnv string
+	New    func(cfg SourceConfig, apiKey string, httpClient *http.Client) (NewsClient, error)
+}
+
+// Registry builds sources from their config by type.
+type Registry struct {
+	types   map[string]SourceType
+	limiter Limiter
+}
+
+// NewRegistry returns a registry of the built-in source types: finnhub,
+// alphavantage, massive, marketaux and rss. Sources' request limits are
+// counted by limiter; with a nil limiter they are not enforced.
+func NewRegistry(limiter Limiter) *Registry {
+	r := &Registry{types: map[string]SourceType{}, limiter: limiter}
+	r.Register("finnhub", SourceType{KeyEnv: "FINNHUB_API_KEY", New: newFinnHubSource})
+	r.Register("alphavantage", SourceType{KeyEnv: "ALPHA_VANTAGE_API_KEY", New: newAlphaVantageSource})
+	r.Register("massive", SourceType{KeyEnv: "MASSIVE_API_KEY", New: newMassiveSource})
+	r.Register("marketaux", SourceType{KeyEnv: "MARKETAUX_API_KEY", New: newMarketauxSource})
+	r.Register("rss", SourceType{New: newRSSSource})
+	return r
+}
+
+// Register adds or replaces a source type.
+func (r *Registry) Register(name string, t SourceType) {
+	r.types[name] = t
+}
+
+// Sources builds the enabled sources in cfg, in order. Sources whose API key
+// is not set are skipped with a warning, so a config can list every source
+// and each deployment fetches from those it has keys for. Any invalid source
+// fails the whole config.
+func (r *Registry) Sources(cfg *Config) ([]*Source, error) {
+	var errs []error
+	var sources []*Source
+	names := map[string]bool{}
+
+	for i, c := range cfg.Sources {
+		if c.Name == "" {
+			errs = append(errs, fmt.Errorf("source %d: no name", i+1))
+			continue
+		}
+		if names[c.Name] {
+			errs = append(errs, fmt.Errorf("source %s: duplicate name", c.Name))
+			continue
+		}
+		names[c.Name] = true
+
+		t, ok := r.types[c.Type]
+		if !ok {
+			errs = append(errs, fmt.Errorf("source %s: unknown type %q, want one of %v", c.Name, c.Type, r.typeNames()))
+			continue
+		}
+		if c.Limit < 0 || c.MaxPages < 0 || c.RequestsPerMinute < 0 || c.RequestsPerDay < 0 {
+			errs = append(errs, fmt.Errorf("source %s: limits must not be negative", c.Name))
+			continue
+		}
+
+		keyEnv := c.APIKeyEnv
+		if keyEnv == "" {
+			keyEnv = t.KeyEnv
+		}
+		var apiKey string
+		if keyEnv != "" {
+			apiKey = os.Getenv(keyEnv)
+		}
+
+		client, err := t.New(c, apiKey, r.httpClient(c))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source %s: %w", c.Name, err))
+			continue
+		}
+
+		if !c.IsEnabled() {
+			continue
+		}
+		if keyEnv != "" && apiKey == "" {
+			slog.Warn("news source skipped, its API key is not set", "source", c.Name, "env", keyEnv)
+			continue
+		}
+		sources = append(sources, &Source{client: client, config: c})
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// httpClient returns the HTTP client for a source, holding its requests to
+// its limits.
+func (r *Registry) httpClient(c SourceConfig) *http.Client {
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	if r.limiter != nil && (c.RequestsPerMinute > 0 || c.RequestsPerDay > 0) {
+		httpClient.Transport = &limitedTransport{
+			base:      http.DefaultTransport,
+			limiter:   r.limiter,
+			source:    c.Name,
+			perMinute: c.RequestsPerMinute,
+			perDay:    c.RequestsPerDay,
+		}
+	}
+	return httpClient
+}
+
+func (r *Registry) typeNames() []string {
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Source is a configured news client. It fetches under the configured name,
+// limit and publisher.
+type Source struct {
+	client NewsClient
+	config SourceConfig
+}
+
+// NewSource wraps client in the settings of cfg.
+func NewSource(client NewsClient, cfg SourceConfig) *Source {
+	return &Source{client: client, config: cfg}
+}
+
+func (s *Source) Name() string {
+	return s.config.Name
+}
+
+// Config returns the config the source was built from.
+func (s *Source) Config() SourceConfig {
+	return s.config
+}
+
+// Fetch fetches up to the configured limit and labels the articles with the
+// source's name and publisher.
+func (s *Source) Fetch(ctx context.Context, req FetchRequest) ([]Article, string, error) {
+	if s.config.Limit > 0 {
+		req.Limit = s.config.Limit
+	}
+
+	articles, cursor, err := s.client.Fetch(ctx, req)
+	for i := range articles {
+		articles[i].Source = s.config.Name
+		if s.config.Publisher != "" {
+			articles[i].Publisher = s.config.Publisher
+		}
+	}
+	return articles, cursor, err
+}
+
+func newFinnHubSource(cfg SourceConfig, apiKey string, httpClient *http.Client) (NewsClient, error) {
+	if len(cfg.Topics) > 1 {
+		return nil, errors.New("finnhub takes a single topic, its news category")
+	}
+	finnhubCfg := finnhub.NewConfiguration()
+	finnhubCfg.AddDefaultHeader("X-Finnhub-Token", apiKey)
+	finnhubCfg.HTTPClient = httpClient
+	c := &FinnHubClient{client: finnhub.NewAPIClient(finnhubCfg).DefaultApi, category: "general"}
+	if len(cfg.Topics) == 1 {
+		c.category = cfg.Topics[0]
+	}
+	return c, nil
+}
+
+func newAlphaVantageSource(cfg SourceConfig, apiKey string, httpClient *http.Client) (NewsClient, error) {
+	c := NewAlphaVantageClient(apiKey)
+	c.httpClient = httpClient
+	c.topics = slices.Clone(cfg.Topics)
+	return c, nil
+}
+
+func newMassiveSource(cfg SourceConfig, apiKey string, httpClient *http.Client) (NewsClient, error) {
+	if len(cfg.Topics) > 0 {
+		return nil, errors.New("massive does not support topics")
+	}
+	c := NewMassiveClient(apiKey)
+	c.httpClient = httpClient
+	return c, nil
+}
+
+// defaultMarketauxPages is how many pages a Marketaux fetch reads without
+// max_pages.
+const defaultMarketauxPages = 4
+
+func newMarketauxSource(cfg SourceConfig, apiKey string, httpClient *http.Client) (NewsClient, error) {
+	pages := cfg.MaxPages
+	if pages == 0 {
+		pages = defaultMarketauxPages
+	}
+	c := NewMarketauxClient(apiKey, pages)
+	c.httpClient = httpClient
+	c.industries = slices.Clone(cfg.Topics)
+	return c, nil
+}
+
+func newRSSSource(cfg SourceConfig, apiKey string, httpClient *http.Client) (NewsClient, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("rss needs a url")
+	}
+	if len(cfg.Topics) > 0 {
+		return nil, errors.New("rss does not support topics")
+	}
+	c := NewRSSClient(cfg.URL, cfg.Name)
+	c.httpClient = httpClient
+	return c, nil
+}
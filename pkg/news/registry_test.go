@@ -0,0 +1,126 @@
+package news
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sources.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRegistrySources(t *testing.T) {
+	t.Setenv("FINNHUB_API_KEY", "finnhub-key")
+	t.Setenv("MASSIVE_API_KEY", "")
+	t.Setenv("TEST_MARKETAUX_KEY", "marketaux-key")
+
+	cfg, err := LoadConfig(writeConfig(t, `
+sources:
+  - name: FinnHub Crypto
+    type: finnhub
+    topics: [crypto]
+    limit: 20
+    schedule: "*/15 * * * *"
+  - name: Massive
+    type: massive
+  - name: Marketaux
+    type: marketaux
+    api_key_env: TEST_MARKETAUX_KEY
+    topics: [Technology, Energy]
+  - name: Yahoo
+    type: rss
+    url: https://example.com/yahoo.xml
+    enabled: false
+  - name: CNBC World
+    type: rss
+    url: https://example.com/cnbc.xml
+    publisher: CNBC
+`))
+	assert.Equal(t, nil, err)
+
+	sources, err := NewRegistry(nil).Sources(cfg)
+	assert.Equal(t, nil, err)
+
+	// Massive has no key and Yahoo is disabled.
+	assert.Equal(t, 3, len(sources))
+	assert.Equal(t, "FinnHub Crypto", sources[0].Name())
+	assert.Equal(t, "*/15 * * * *", sources[0].Config().Schedule)
+	assert.Equal(t, "crypto", sources[0].client.(*FinnHubClient).category)
+
+	marketaux := sources[1].client.(*MarketauxClient)
+	assert.Equal(t, "marketaux-key", marketaux.apiKey)
+	assert.Equal(t, 4, marketaux.maxPages)
+	assert.Equal(t, []string{"Technology", "Energy"}, marketaux.industries)
+
+	rss := sources[2].client.(*RSSClient)
+	assert.Equal(t, "https://example.com/cnbc.xml", rss.url)
+}
+
+func TestRegistrySourcesInvalid(t *testing.T) {
+	cfg := &Config{Sources: []SourceConfig{
+		{Name: "FinnHub", Type: "finnhub"},
+		{Name: "FinnHub", Type: "finnhub"},
+		{Name: "Bloomberg", Type: "bloomberg"},
+		{Name: "Feed", Type: "rss"},
+		{Type: "massive"},
+	}}
+
+	_, err := NewRegistry(nil).Sources(cfg)
+	assert.NotEqual(t, nil, err)
+	for _, want := range []string{"FinnHub: duplicate name", `unknown type "bloomberg"`, "Feed: rss needs a url", "source 5: no name"} {
+		assert.Equal(t, true, strings.Contains(err.Error(), want))
+	}
+}
+
+func TestLoadConfigRejectsUnknownFields(t *testing.T) {
+	_, err := LoadConfig(writeConfig(t, `
+sources:
+  - name: FinnHub
+    type: finnhub
+    limt: 20
+`))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestDefaultConfig(t *testing.T) {
+	cfg, err := LoadConfig("../../config/sources.yaml")
+	assert.Equal(t, nil, err)
+
+	_, err = NewRegistry(nil).Sources(cfg)
+	assert.Equal(t, nil, err)
+}
+
+type stubClient struct {
+	req FetchRequest
+}
+
+func (c *stubClient) Fetch(ctx context.Context, req FetchRequest) ([]Article, string, error) {
+	c.req = req
+	return []Article{{Headline: "Fed holds rates", Source: "stub", Publisher: "Reuters"}}, "next", nil
+}
+
+func (c *stubClient) Name() string {
+	return "stub"
+}
+
+func TestSourceFetch(t *testing.T) {
+	client := &stubClient{}
+	source := NewSource(client, SourceConfig{Name: "Wire", Limit: 10, Publisher: "Wire Service"})
+
+	articles, cursor, err := source.Fetch(context.Background(), FetchRequest{Limit: 50})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "next", cursor)
+	assert.Equal(t, 10, client.req.Limit)
+	assert.Equal(t, "Wire", articles[0].Source)
+	assert.Equal(t, "Wire Service", articles[0].Publisher)
+}
@@ -1,9 +1,14 @@
 package news
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"net/http"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/mmcdole/gofeed"
 )
@@ -14,6 +19,7 @@ type RSSClient struct {
 	url        string
 	sourceName string
 	parser     *gofeed.Parser
+	httpClient *http.Client
 }
 
 func NewRSSClient(url, sourceName string) *RSSClient {
@@ -21,21 +27,62 @@ func NewRSSClient(url, sourceName string) *RSSClient {
 		url:        url,
 		sourceName: sourceName,
 		parser:     gofeed.NewParser(),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
 	}
 }
 
-func (c *RSSClient) Fetch(limit int) ([]Article, error) {
-	feed, err := c.parser.ParseURL(c.url)
+// Fetch downloads the feed unless it is unchanged since the ETag in
+// req.Cursor, and returns its oldest items published after req.Since, undated
+// items last. The next cursor is the feed's ETag, unless the limit left items
+// behind for the next fetch.
+func (c *RSSClient) Fetch(ctx context.Context, req FetchRequest) ([]Article, string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, req.Cursor, err
+	}
+	httpReq.Header.Set("User-Agent", c.parser.UserAgent)
+	if req.Cursor != "" {
+		httpReq.Header.Set("If-None-Match", req.Cursor)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		slog.Error("failed to fetch RSS feed", "url", c.url, "error", err)
+		return nil, req.Cursor, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, req.Cursor, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, req.Cursor, fmt.Errorf("RSS feed %s: %w", c.url, statusError(c.Name(), resp))
+	}
+
+	feed, err := c.parser.Parse(resp.Body)
 	if err != nil {
 		slog.Error("failed to parse RSS feed", "url", c.url, "error", err)
-		return nil, err
+		return nil, req.Cursor, err
 	}
 
-	var articles []Article
+	var items []*gofeed.Item
 	for _, item := range feed.Items {
-		if len(articles) >= limit {
-			break
+		if item.PublishedParsed == nil || item.PublishedParsed.After(req.Since) {
+			items = append(items, item)
 		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].PublishedParsed, items[j].PublishedParsed
+		return a != nil && (b == nil || a.Before(*b))
+	})
+
+	cursor := resp.Header.Get("ETag")
+	if len(items) > req.Limit {
+		items, cursor = items[:req.Limit], req.Cursor
+	}
+
+	var articles []Article
+	for _, item := range items {
 
 		detail := item.Description
 		if detail == "" {
@@ -65,7 +112,7 @@ func (c *RSSClient) Fetch(limit int) ([]Article, error) {
 		articles = append(articles, a)
 	}
 
-	return articles, nil
+	return articles, cursor, nil
 }
 
 func (c *RSSClient) Name() string {
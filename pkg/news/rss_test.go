@@ -0,0 +1,73 @@
+package news
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+const rssFeed = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Markets</title>
+<item><title>Stocks rise</title><link>https://example.com/stocks</link><description>&lt;p&gt;Stocks rose 1%.&lt;/p&gt;</description><pubDate>Thu, 26 Feb 2026 12:00:00 GMT</pubDate></item>
+<item><title>Oil slips</title><link>https://example.com/oil</link><description>Oil fell 2%.</description><pubDate>Thu, 26 Feb 2026 10:00:00 GMT</pubDate></item>
+</channel></rss>`
+
+func TestRSSFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(rssFeed))
+	}))
+	defer srv.Close()
+
+	client := NewRSSClient(srv.URL, "CNBC")
+	since := time.Date(2026, 2, 26, 11, 0, 0, 0, time.UTC)
+
+	articles, cursor, err := client.Fetch(context.Background(), FetchRequest{Since: since, Limit: 10})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `"v1"`, cursor)
+	assert.Equal(t, 1, len(articles))
+	assert.Equal(t, "Stocks rise", articles[0].Headline)
+	assert.Equal(t, "Stocks rose 1%.", articles[0].Detail)
+
+	// An unchanged feed is not downloaded again.
+	articles, cursor, err = client.Fetch(context.Background(), FetchRequest{Cursor: cursor, Limit: 10})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `"v1"`, cursor)
+	assert.Equal(t, 0, len(articles))
+}
+
+func TestRSSFetchOldestFirst(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(rssFeed))
+	}))
+	defer srv.Close()
+
+	client := NewRSSClient(srv.URL, "CNBC")
+
+	// The feed lists the newest item first; a limit keeps the oldest and
+	// the feed is downloaded again next time for the rest.
+	articles, cursor, err := client.Fetch(context.Background(), FetchRequest{Limit: 1})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "", cursor)
+	assert.Equal(t, 1, len(articles))
+	assert.Equal(t, "Oil slips", articles[0].Headline)
+
+	articles, cursor, err = client.Fetch(context.Background(), FetchRequest{Since: articles[0].PublishedAt, Limit: 1})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `"v1"`, cursor)
+	assert.Equal(t, 1, len(articles))
+	assert.Equal(t, "Stocks rise", articles[0].Headline)
+}